@@ -4,8 +4,10 @@ go 1.23.6
 
 require (
 	al.essio.dev/pkg/shellescape v1.6.0
+	filippo.io/age v1.2.1
 	github.com/adrg/xdg v0.5.3
 	github.com/charmbracelet/x/ansi v0.8.0
+	github.com/fsnotify/fsnotify v1.8.0
 	github.com/go-viper/mapstructure/v2 v2.2.1
 	github.com/google/go-cmp v0.7.0
 	github.com/junegunn/fzf v0.61.1
@@ -40,6 +42,7 @@ require (
 	github.com/tklauser/go-sysconf v0.3.12 // indirect
 	github.com/tklauser/numcpus v0.6.1 // indirect
 	github.com/yusufpapurcu/wmi v1.2.4 // indirect
+	golang.org/x/crypto v0.24.0 // indirect
 	golang.org/x/sys v0.30.0 // indirect
 	golang.org/x/text v0.21.0 // indirect
 )
@@ -1,16 +1,19 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"math/rand/v2"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/charmbracelet/x/ansi"
 	"github.com/eth-p/kubesel/internal/cobraprint"
-	"github.com/eth-p/kubesel/internal/kubectl"
+	"github.com/eth-p/kubesel/pkg/kubectl"
 	"github.com/eth-p/kubesel/pkg/kubesel"
 	"github.com/spf13/cobra"
 )
@@ -22,9 +25,33 @@ const (
 )
 
 const (
-	colorFlagName = "color"
-	listFlagName  = "list"
-	debugFlagName = "debug"
+	// annotationNoKubeconfigWarnings is a command annotation that prevents
+	// warnings about unloadable kubeconfig files from being printed, for
+	// commands which report them differently.
+	annotationNoKubeconfigWarnings = "kubesel/no-kubeconfig-warnings"
+
+	// annotationNoCredentialWarnings is a command annotation that prevents
+	// warnings about the session's expiring credentials from being printed,
+	// for commands which report them differently.
+	annotationNoCredentialWarnings = "kubesel/no-credential-warnings"
+
+	// annotationNoConfig is a command annotation that prevents the kubesel
+	// config file from being loaded before running the command. This is used
+	// by commands that need to work even when the config file is invalid.
+	annotationNoConfig = "kubesel/no-config"
+
+	// annotationAllowInvalidConfig is a command annotation that lets the
+	// command run with the default config when the kubesel config file is
+	// invalid. A warning is printed instead of failing. This is used by
+	// commands that are run automatically or are needed to fix the config.
+	annotationAllowInvalidConfig = "kubesel/allow-invalid-config"
+)
+
+const (
+	colorFlagName  = "color"
+	listFlagName   = "list"
+	debugFlagName  = "debug"
+	strictFlagName = "strict"
 )
 
 // RootCommand is the root `kubesel` command.
@@ -35,6 +62,11 @@ var RootCommand = cobra.Command{
 		HiddenDefaultCmd: initScriptLoadsCompletions,
 	},
 
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		runningCommand = cmd
+		return applyConfig(cmd, args)
+	},
+
 	SilenceErrors: true,
 	SilenceUsage:  true,
 }
@@ -42,13 +74,18 @@ var RootCommand = cobra.Command{
 var GlobalOptions struct {
 	Debug       bool // --debug (hidden flag)
 	Color       bool // --color
+	Strict      bool // --strict
 	OutputIsTTY bool // not a flag
 }
 
 var (
 	// Kubesel is the global instance of [kubesel.Kubesel] used by all
 	// subcommands.
-	Kubesel = sync.OnceValues(kubesel.NewKubesel)
+	Kubesel = sync.OnceValues(newKubesel)
+
+	// createdKubesel is the global instance of [kubesel.Kubesel], or nil if
+	// it was not created yet.
+	createdKubesel atomic.Pointer[kubesel.Kubesel]
 
 	// Kubectl is the global instance of the [kubectl.Kubectl] wrapper used by
 	// all subcommands.
@@ -61,6 +98,9 @@ var (
 	helpPrinter    = sync.OnceValue(makeHelpPrinter)
 	errorPrinter   = sync.OnceValue(makeErrorPrinter)
 
+	// runningCommand is the command being run, or nil if it is not known yet.
+	runningCommand *cobra.Command
+
 	// gcWait is a WaitGroup that waits for automatic garbage collection to
 	// finish running.
 	gcWait sync.WaitGroup
@@ -90,6 +130,11 @@ func init() {
 
 	RootCommand.SetHelpFunc(func(cmd *cobra.Command, args []string) {
 		hasPrintedHelp = true
+
+		// With `--help`, the command's PersistentPreRunE doesn't run. The
+		// config is still applied so the help uses its colors and defaults.
+		_ = applyConfig(cmd, args)
+
 		out := cmd.OutOrStdout()
 		usage := helpPrinter().PrintCommandHelp(cmd, args)
 		_, _ = io.WriteString(out, usage)
@@ -117,6 +162,13 @@ func init() {
 		"Print with colors",
 	)
 
+	RootCommand.PersistentFlags().BoolVar(
+		&GlobalOptions.Strict,
+		strictFlagName,
+		false,
+		"Fail if any kubeconfig file cannot be loaded",
+	)
+
 	RootCommand.PersistentFlags().Lookup(colorFlagName).DefValue = "auto"
 	RootCommand.PersistentFlags().Lookup(debugFlagName).Hidden = true
 }
@@ -147,11 +199,135 @@ func makeErrorPrinter() *cobraprint.ErrorPrinter {
 		opts.ErrorCommandColor = ansi.SGR(ansi.BoldAttr, ansi.BrightRedForegroundColorAttr)
 		opts.ErrorTextColor = ansi.SGR(ansi.RedForegroundColorAttr)
 		opts.TipColor = ansi.SGR(ansi.YellowForegroundColorAttr)
+		opts.WarningColor = ansi.SGR(ansi.YellowForegroundColorAttr)
 	}
 
 	return cobraprint.NewErrorPrinter(opts)
 }
 
+// newKubesel creates the global [kubesel.Kubesel] instance.
+//
+// If the current shell has a kubesel session, its sources file and decrypted
+// kubeconfig files are updated so kubectl sees any changes to the kubeconfig
+// source directories, kubeconfig commands, and encrypted kubeconfig files.
+// They are only updated if the files they were generated from changed, since
+// updating the sources file needs every kubeconfig file to be loaded.
+//
+// Hidden commands (e.g. shell completions) don't run kubeconfig commands,
+// since they need to be fast. They use the previous output of the commands.
+//
+// With --strict, all kubeconfig files are loaded upfront, and an error is
+// returned if any of them could not be loaded.
+func newKubesel() (*kubesel.Kubesel, error) {
+	config, err := loadConfig(runningCommand)
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := kubesel.DefaultOptionsForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// Overridden users get their credentials from kubesel itself.
+	opts.CredentialCommand = []string{kubeselExecutable(), internalCredentialsCommandName}
+
+	if runningCommand != nil && runningCommand.Hidden {
+		opts.SkipKubeconfigCommands = true
+	}
+
+	ksel, err := kubesel.New(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if GlobalOptions.Strict {
+		var errs []error
+		for _, loadErr := range ksel.GetKubeconfigLoadErrors() {
+			errs = append(errs, loadErr)
+		}
+
+		if len(errs) > 0 {
+			return nil, errors.Join(errs...)
+		}
+	}
+
+	createdKubesel.Store(ksel)
+
+	hasSources := len(ksel.GetKubeconfigSourceDirs()) > 0 || len(ksel.GetKubeconfigCommands()) > 0
+	if hasSources || ksel.HasDecryptedFiles() {
+		if managedKc, err := ksel.GetManagedKubeconfig(); err == nil {
+			if ksel.IsSourcesFileOutdated(managedKc.Owner()) {
+				_, err = ksel.UpdateSourcesFile(managedKc.Owner())
+				debugf("Updated sources file. err=%v\n", err)
+			}
+
+			err = ksel.UpdateDecryptedFiles(managedKc.Owner())
+			debugf("Updated decrypted files. err=%v\n", err)
+		}
+	}
+
+	return ksel, nil
+}
+
+// updateSourcesFile updates the sources file of the current shell's kubesel
+// session after running a command, in case the command changed any of the
+// kubeconfig files in the source directories.
+//
+// This is only done if the command already loaded the kubeconfig files.
+// kubeselExecutable returns how kubesel should be run by generated scripts
+// and kubeconfig files. This is the name it was run as, rather than the
+// resolved path of its executable, since that changes whenever kubesel is
+// upgraded by a package manager.
+//
+// Relative paths are made absolute, since they would otherwise be relative
+// to whatever directory the script or kubeconfig file is used from.
+func kubeselExecutable() string {
+	executable := os.Args[0]
+	if strings.ContainsRune(executable, filepath.Separator) && !filepath.IsAbs(executable) {
+		if abs, err := filepath.Abs(executable); err == nil {
+			return abs
+		}
+	}
+
+	return executable
+}
+
+func updateSourcesFile() {
+	ksel := createdKubesel.Load()
+	if ksel == nil || !ksel.HasLoadedKubeconfigs() {
+		return
+	}
+
+	if managedKc, err := ksel.GetManagedKubeconfig(); err == nil {
+		_, err = ksel.UpdateSourcesFile(managedKc.Owner())
+		debugf("Updated sources file after command. err=%v\n", err)
+	}
+}
+
+// printKubeconfigWarnings prints a warning for each kubeconfig file that could
+// not be loaded.
+//
+// To avoid loading kubeconfig files just to check them, nothing is printed
+// unless the command already needed to load them. Hidden commands (e.g. shell
+// completions) never print warnings.
+func printKubeconfigWarnings(w io.Writer, cmd *cobra.Command) {
+	ksel := createdKubesel.Load()
+	if ksel == nil || !ksel.HasLoadedKubeconfigs() || cmd == nil || cmd.Hidden {
+		return
+	}
+
+	if _, ok := cmd.Annotations[annotationNoKubeconfigWarnings]; ok {
+		return
+	}
+
+	errorPrinter().PrintKubeconfigWarnings(
+		w,
+		cmd,
+		ksel.GetKubeconfigLoadErrors(),
+	)
+}
+
 // tryQuickGC has a 1 in 2 chance to run a background garbage collection over
 // 5 files. The files checked are nondeterministic, and _eventually_ all files
 // will end up checked.
@@ -167,6 +343,18 @@ func tryNormalGC(cmd *cobra.Command, args []string) {
 }
 
 func tryGC(chance, maxFiles int) {
+	if config, err := kubesel.LoadConfig(); err == nil {
+		switch config.GC.Aggressiveness {
+		case "off":
+			return
+		case "low":
+			chance *= 4
+		case "high":
+			chance = 1
+			maxFiles *= 2
+		}
+	}
+
 	debugf("Trying GC. chance=%d maxFiles=%d\n", chance, maxFiles)
 	randResult := rand.IntN(chance)
 	if randResult != 0 {
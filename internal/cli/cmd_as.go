@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"errors"
+
+	"github.com/eth-p/kubesel/pkg/kubesel"
+	"github.com/spf13/cobra"
+)
+
+var asCommand = cobra.Command{
+	Use:     "as [user]",
+	GroupID: CommandGroupKubeconfig,
+
+	Short: "Impersonate a user or service account",
+	Long: `
+		Impersonate a Kubernetes user or service account in the
+		current shell, using the credentials of the current user.
+		This is useful for testing RBAC rules.
+
+		The impersonation is only added to the current shell's
+		copy of the user. The kubeconfig files are not changed.
+		Changing to a different user or context stops the
+		impersonation, as does '--clear'.
+	`,
+	Example: `
+		kubesel as system:serviceaccount:my-namespace:my-sa
+		kubesel as jane --as-group=developers --as-group=testers
+		kubesel as jane --as-extra=scopes=view
+		kubesel as --clear
+	`,
+
+	Args:              cobra.RangeArgs(0, 1),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	RunE:              asCommandMain,
+	PreRun:            tryQuickGC,
+}
+
+var AsCommandOptions struct {
+	impersonationOptions
+	Clear bool
+}
+
+func init() {
+	RootCommand.AddCommand(&asCommand)
+	impersonationFlags(asCommand.Flags(), &AsCommandOptions.impersonationOptions)
+
+	asCommand.Flags().BoolVar(
+		&AsCommandOptions.Clear,
+		"clear",
+		false,
+		"stop impersonating",
+	)
+}
+
+func asCommandMain(cmd *cobra.Command, args []string) error {
+	opts := &AsCommandOptions
+	ksel, err := Kubesel()
+	if err != nil {
+		return err
+	}
+
+	managedKc, err := ksel.GetManagedKubeconfig()
+	if err != nil {
+		return err
+	}
+
+	state := managedKc.State()
+	switch {
+	case opts.Clear && (len(args) > 0 || !opts.impersonationOptions.IsZero()):
+		return errors.New("cannot impersonate and stop impersonating at the same time")
+
+	case opts.Clear:
+		state.AuthInfoOverrides = kubesel.AuthInfoOverrides{}
+
+	// Kubernetes requires a user to impersonate groups.
+	case len(args) == 0:
+		return errors.New("no user to impersonate specified")
+
+	default:
+		state.AuthInfoOverrides, err = opts.authInfoOverrides(args[0])
+		if err != nil {
+			return err
+		}
+	}
+
+	return managedKc.Apply(state)
+}
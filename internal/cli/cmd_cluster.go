@@ -2,10 +2,14 @@ package cli
 
 import (
 	"iter"
+	"net/url"
 
 	"github.com/eth-p/kubesel/pkg/kubeconfig"
+	"github.com/eth-p/kubesel/pkg/kubeconfig/kcutils"
+	"github.com/eth-p/kubesel/pkg/kubeconfig/loader"
 	"github.com/eth-p/kubesel/pkg/kubesel"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var clusterCommand = cobra.Command{
@@ -27,18 +31,44 @@ var clusterCommand = cobra.Command{
 		name fuzzily matches multiple clusters, a fzf picker will
 		be opened.
 
+		Use '--selector' to only pick from clusters with matching
+		labels.
+
+		Use '--proxy-url' or '--insecure-skip-tls-verify' to change
+		how the cluster is used in the current shell only. The
+		kubeconfig files are not changed. If no cluster is
+		specified, the current cluster is changed. Changing to a
+		cluster without them removes the changes.
+
 		Note: You may need to change the user as well.
 	`,
 	Example: `
 		kubesel cluster my.cluster.example  # full name
 		kubesel cluster myclstr             # fuzzy match
 		kubesel cluster                     # fzf picker
+		kubesel cluster -l env=prod         # fzf picker, only prod clusters
+		kubesel cluster --insecure-skip-tls-verify  # current cluster, without TLS verification
+
+		# Edit clusters.
+		kubesel cluster create my-cluster --server=https://localhost:6443
+		kubesel cluster rename my-cluster better-name
+		kubesel cluster delete better-name
 	`,
 
 	PreRun: tryQuickGC,
 }
 
 var ClusterCommandOptions struct {
+	ProxyURL              string
+	InsecureSkipTLSVerify bool
+}
+
+var ClusterCreateCommandOptions struct {
+	Server                string
+	CertificateAuthority  string
+	TLSServerName         string
+	InsecureSkipTLSVerify bool
+	ProxyURL              string
 }
 
 func init() {
@@ -47,14 +77,133 @@ func init() {
 		PropertyNameSingular: "cluster",
 		PropertyNamePlural:   "clusters",
 		GetItemInfos:         clusterInfoIter,
+		DescribeItem:         describeCluster,
 		GetItemNames:         clusterNames,
+		GetItemLabels:        (*kubesel.Kubesel).GetClusterLabels,
+		UsageKind:            kubesel.UsageCluster,
 		Switch:               clusterSwitchImpl,
+		GetActiveItem:        clusterActive,
+		GetItemDefinition:    clusterDefinition,
+		Editor: &managedPropertyEditor{
+			GetItemSources:    clusterSources,
+			Create:            clusterCreateImpl,
+			CreateFlags:       clusterCreateFlags,
+			Rename:            kcutils.RenameCluster,
+			FindReferences:    kcutils.FindClusterReferences,
+			ReplaceReferences: kcutils.ReplaceClusterReferences,
+			RenameActive:      clusterRenameActiveImpl,
+			Delete:            kcutils.RemoveCluster,
+		},
 	})
+
+	clusterCommand.Flags().StringVar(
+		&ClusterCommandOptions.ProxyURL,
+		"proxy-url",
+		"",
+		"use a proxy for the cluster in the current shell",
+	)
+
+	clusterCommand.Flags().BoolVar(
+		&ClusterCommandOptions.InsecureSkipTLSVerify,
+		"insecure-skip-tls-verify",
+		false,
+		"do not verify the cluster's TLS certificate in the current shell",
+	)
+
+	// Change the current cluster if only the overrides are given.
+	switchRunE := clusterCommand.RunE
+	clusterCommand.RunE = func(cmd *cobra.Command, args []string) error {
+		if len(args) > 0 || clusterOverridesFromFlags().IsZero() {
+			return switchRunE(cmd, args)
+		}
+
+		ksel, err := Kubesel()
+		if err != nil {
+			return err
+		}
+
+		managedKc, err := ksel.GetManagedKubeconfig()
+		if err != nil {
+			return err
+		}
+
+		return clusterSwitchImpl(ksel, managedKc, managedKc.GetClusterName())
+	}
+}
+
+func clusterOverridesFromFlags() kubesel.ClusterOverrides {
+	return kubesel.ClusterOverrides{
+		ProxyURL:              ClusterCommandOptions.ProxyURL,
+		InsecureSkipTLSVerify: ClusterCommandOptions.InsecureSkipTLSVerify,
+	}
 }
 
 func clusterSwitchImpl(ksel *kubesel.Kubesel, managedKc *kubesel.ManagedKubeconfig, target string) error {
-	managedKc.SetClusterName(target)
-	return managedKc.Save()
+	state := managedKc.State()
+	state.ClusterName = target
+	state.ClusterOverrides = clusterOverridesFromFlags()
+	return managedKc.Apply(state)
+}
+
+func clusterActive(ksel *kubesel.Kubesel, managedKc *kubesel.ManagedKubeconfig) string {
+	return managedKc.GetClusterName()
+}
+
+// clusterDefinition returns the cluster with its certificates redacted,
+// since they only make the preview harder to read.
+func clusterDefinition(ksel *kubesel.Kubesel, name string) any {
+	kcCluster := kcutils.FindCluster(name, ksel.GetMergedKubeconfig())
+	if kcCluster == nil {
+		return nil
+	}
+
+	kcCluster = kcCluster.Clone()
+	kcutils.RedactCluster(kcCluster)
+	return kcCluster
+}
+
+func clusterSources(ksel *kubesel.Kubesel) loader.NamedItemSources {
+	return ksel.GetProvenance().Clusters
+}
+
+func clusterCreateFlags(flags *pflag.FlagSet) {
+	opts := &ClusterCreateCommandOptions
+	flags.StringVar(&opts.Server, "server", "", "the cluster's API server URL")
+	flags.StringVar(&opts.CertificateAuthority, "certificate-authority", "", "path to the cluster's CA certificate")
+	flags.StringVar(&opts.TLSServerName, "tls-server-name", "", "server name for TLS verification")
+	flags.BoolVar(&opts.InsecureSkipTLSVerify, "insecure-skip-tls-verify", false, "do not verify the server's TLS certificate")
+	flags.StringVar(&opts.ProxyURL, "proxy-url", "", "the proxy used to connect to the cluster")
+}
+
+func clusterCreateImpl(name string, kc *kubeconfig.Config) {
+	opts := &ClusterCreateCommandOptions
+	kcCluster := &kubeconfig.Cluster{
+		Server:                   nilIfEmpty(opts.Server),
+		CertificateAuthorityFile: nilIfEmpty(expandTilde(opts.CertificateAuthority)),
+		TLSServerName:            nilIfEmpty(opts.TLSServerName),
+		ProxyURL:                 nilIfEmpty(opts.ProxyURL),
+	}
+
+	if opts.InsecureSkipTLSVerify {
+		kcCluster.InsecureSkipTLSVerify = kcutils.PointerFor(true)
+	}
+
+	kc.Clusters = append(kc.Clusters, kubeconfig.NamedCluster{
+		Name:    &name,
+		Cluster: kcCluster,
+	})
+}
+
+// clusterRenameActiveImpl switches the managed kubeconfig to the renamed
+// cluster, keeping its overrides.
+func clusterRenameActiveImpl(managedKc *kubesel.ManagedKubeconfig, from, to string) error {
+	state := managedKc.State()
+	if state.ClusterName != from {
+		return nil
+	}
+
+	state.ClusterName = to
+	return managedKc.Apply(state)
 }
 
 func clusterNames() ([]string, error) {
@@ -70,25 +219,55 @@ type clusterInfo struct {
 	Name     *string `yaml:"name" printer:"Name,order=1"`
 	Server   *string `yaml:"server" printer:"Server,order=2"`
 	ProxyURL *string `yaml:"proxy-url" printer:"Proxy URL,order=3,wide"`
+	Labels   string  `yaml:"labels" printer:"Labels,order=4,wide"`
+	Source   *string `yaml:"source" printer:"Source,order=5,wide"`
+	Shadowed string  `yaml:"shadowed" printer:"Shadowed,order=6,wide"`
 }
 
-func clusterInfoIter() (iter.Seq[clusterInfo], error) {
-	kubesel, err := Kubesel()
+// describeCluster describes a cluster by its server's host.
+func describeCluster(info clusterInfo) string {
+	if info.Server == nil {
+		return ""
+	}
+
+	serverUrl, err := url.Parse(*info.Server)
+	if err != nil || serverUrl.Host == "" {
+		return *info.Server
+	}
+
+	return serverUrl.Host
+}
+
+func clusterInfoIter(selector kubesel.LabelSelector) (iter.Seq[clusterInfo], error) {
+	ksel, err := Kubesel()
 	if err != nil {
 		return nil, err
 	}
 
+	sources := ksel.GetProvenance().Clusters
 	return func(yield func(clusterInfo) bool) {
-		for _, kcNamedCluster := range kubesel.GetMergedKubeconfig().Clusters {
+		for _, kcNamedCluster := range ksel.GetMergedKubeconfig().Clusters {
+			if kubesel.IsManagedCluster(&kcNamedCluster) {
+				continue
+			}
+
 			kcCluster := kcNamedCluster.Cluster
 			if kcCluster == nil {
 				kcCluster = &kubeconfig.Cluster{}
 			}
 
+			labels, selected := labelsColumn(kcNamedCluster.Name, ksel.GetClusterLabels, selector)
+			if !selected {
+				continue
+			}
+
 			item := clusterInfo{
 				Name:     kcNamedCluster.Name,
 				Server:   kcCluster.Server,
 				ProxyURL: kcCluster.ProxyURL,
+				Labels:   labels,
+				Source:   sourceOf(sources, kcNamedCluster.Name),
+				Shadowed: shadowedSourcesOf(sources, kcNamedCluster.Name),
 			}
 
 			if !yield(item) {
@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/eth-p/kubesel/pkg/kubesel"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// newConfigFileContents is written to the config file when it is created by
+// `kubesel config edit`.
+const newConfigFileContents = `# kubesel configuration file
+# Use 'kubesel config view' to see the available options.
+`
+
+var configCommand = cobra.Command{
+	Use:     "config",
+	GroupID: CommandGroupKubesel,
+
+	Short: "View or change the kubesel config file",
+	Long: `
+		View or change the kubesel config file.
+
+		The config file is located at $XDG_CONFIG_HOME/kubesel/config.yaml,
+		or the path in the KUBESEL_CONFIG environment variable. Its values
+		are used as the defaults for command-line flags.
+	`,
+	Example: `
+		kubesel config view
+		kubesel config edit
+		kubesel config set list.output columns
+		kubesel config set protected-contexts '[prod-*]'
+	`,
+
+	Args: cobra.NoArgs,
+}
+
+var configViewCommand = cobra.Command{
+	Use:   "view",
+	Short: "Print the kubesel config",
+	Long: `
+		Print the kubesel config, including the default values of
+		options that are not in the config file.
+	`,
+
+	Args: cobra.NoArgs,
+	RunE: configViewCommandMain,
+	Annotations: map[string]string{
+		annotationAllowInvalidConfig: "true",
+	},
+}
+
+var configEditCommand = cobra.Command{
+	Use:   "edit",
+	Short: "Edit the kubesel config file",
+	Long: `
+		Open the kubesel config file in a text editor.
+
+		The editor is taken from the VISUAL or EDITOR environment
+		variables. Once the editor exits, the config file is checked
+		for errors.
+	`,
+
+	Args: cobra.NoArgs,
+	RunE: configEditCommandMain,
+	Annotations: map[string]string{
+		annotationNoConfig: "true",
+	},
+}
+
+var configSetCommand = cobra.Command{
+	Use:   "set key value",
+	Short: "Change an option in the kubesel config file",
+	Long: `
+		Change an option in the kubesel config file.
+
+		The key is the option's path, separated by dots. The value is
+		parsed as YAML, so lists can be written as '[a, b]'.
+	`,
+
+	Args: cobra.ExactArgs(2),
+	RunE: configSetCommandMain,
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return kubesel.ConfigKeys(), cobra.ShellCompDirectiveNoFileComp
+	},
+	Annotations: map[string]string{
+		annotationNoConfig: "true",
+	},
+}
+
+func init() {
+	RootCommand.AddCommand(&configCommand)
+	configCommand.AddCommand(&configViewCommand)
+	configCommand.AddCommand(&configEditCommand)
+	configCommand.AddCommand(&configSetCommand)
+}
+
+func configViewCommandMain(cmd *cobra.Command, args []string) error {
+	config, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	encoder := yaml.NewEncoder(cmd.OutOrStdout())
+	encoder.SetIndent(2)
+	if err := encoder.Encode(config); err != nil {
+		return fmt.Errorf("cannot print config: %w", err)
+	}
+
+	return encoder.Close()
+}
+
+func configEditCommandMain(cmd *cobra.Command, args []string) error {
+	file := kubesel.DefaultConfigFile()
+
+	// Create the file if it doesn't exist.
+	if _, err := os.Stat(file); errors.Is(err, os.ErrNotExist) {
+		if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+			return fmt.Errorf("cannot create config file: %w", err)
+		}
+
+		if err := os.WriteFile(file, []byte(newConfigFileContents), 0o600); err != nil {
+			return fmt.Errorf("cannot create config file: %w", err)
+		}
+	}
+
+	// Open it in the editor.
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+
+	if editor == "" {
+		editor = "vi"
+	}
+
+	proc := exec.Command("/bin/sh", "-c", editor+` "$1"`, "kubesel", file)
+	proc.Stdin = os.Stdin
+	proc.Stdout = os.Stdout
+	proc.Stderr = os.Stderr
+	if err := proc.Run(); err != nil {
+		return fmt.Errorf("editor failed: %w", err)
+	}
+
+	// Check it.
+	_, err := kubesel.LoadConfigFile(file)
+	return err
+}
+
+func configSetCommandMain(cmd *cobra.Command, args []string) error {
+	return kubesel.SetConfigFileValue(kubesel.DefaultConfigFile(), args[0], args[1])
+}
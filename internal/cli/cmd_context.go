@@ -1,12 +1,20 @@
 package cli
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"iter"
+	"slices"
+	"strings"
 
+	"github.com/eth-p/kubesel/internal/fuzzy"
 	"github.com/eth-p/kubesel/pkg/kubeconfig"
 	"github.com/eth-p/kubesel/pkg/kubeconfig/kcutils"
+	"github.com/eth-p/kubesel/pkg/kubeconfig/loader"
 	"github.com/eth-p/kubesel/pkg/kubesel"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var contextCommand = cobra.Command{
@@ -16,7 +24,7 @@ var contextCommand = cobra.Command{
 		"ctx",
 	},
 
-	Use:     "context [name]",
+	Use:     "context [name] [namespace]",
 	GroupID: "Kubeconfig",
 
 	Short: "Change to a different cluster, user, and namespace",
@@ -25,56 +33,342 @@ var contextCommand = cobra.Command{
 		specified in a context.
 
 		When selecting a context, you can use its full name as it
-		appears in 'kubesel list contexts' or a fuzzy match of
-		its name. If no context is specified or if the specified
+		appears in 'kubesel list contexts', one of its aliases, or a
+		fuzzy match of either. If no context is specified or if the specified
 		name fuzzily matches multiple contexts, a fzf picker will
 		be opened.
 
-		If the context does not specify a namespace (or the -n
-		flag is set), the current namespace will be kept.
+		Use '--selector' to only pick from contexts with matching
+		labels. A context has the labels of its cluster, as well as
+		its own.
+
+		A namespace can be given after the context name (or with
+		the --namespace flag) to change to that namespace instead of
+		the context's. It is fuzzy matched against the namespaces in
+		the context's cluster before anything is changed, unless the
+		--exact-namespace flag is set.
+
+		If the context does not specify a namespace (or the
+		--keep-namespace flag is set), the current namespace will
+		be kept.
 	`,
 	Example: `
 		kubesel cluster my.cluster.example  # full name
 		kubesel cluster myclstr             # fuzzy match
 		kubesel cluster                     # fzf picker
+		kubesel context staging payments    # context and namespace
+		kubesel context staging --namespace payments
+		kubesel context staging payments --exact-namespace
+
+		# Edit contexts.
+		kubesel context create my-context --cluster=my-cluster --user=me
+		kubesel context rename my-context better-name
+		kubesel context delete better-name
 	`,
 
 	PreRun: tryQuickGC,
 }
 
 var ContextCommandOptions struct {
-	KeepNamespace bool
+	KeepNamespace  bool
+	Namespace      string
+	ExactNamespace bool
+}
+
+var ContextCreateCommandOptions struct {
+	Cluster   string
+	User      string
+	Namespace string
 }
 
 func init() {
 	RootCommand.AddCommand(&contextCommand)
-	contextCommand.PersistentFlags().BoolVarP(
+	contextCommand.PersistentFlags().BoolVar(
 		&ContextCommandOptions.KeepNamespace,
-		"keep-namespace", "n",
+		"keep-namespace",
 		false,
 		"keep the current namespace",
 	)
 
+	// The -n shorthand will be moved to --namespace in a future release.
+	contextCommand.PersistentFlags().VarPF(
+		&contextNamespaceShorthand,
+		"keep-namespace-or-namespace", "n",
+		"keep the current namespace, or change to the given namespace",
+	).NoOptDefVal = "true"
+
+	contextCommand.PersistentFlags().MarkHidden("keep-namespace-or-namespace") // nolint:errcheck
+	contextCommand.PersistentFlags().MarkShorthandDeprecated(                  // nolint:errcheck
+		"keep-namespace-or-namespace",
+		"use --keep-namespace or --namespace instead, -n will become --namespace in a future release",
+	)
+
+	contextCommand.Flags().StringVar(
+		&ContextCommandOptions.Namespace,
+		"namespace",
+		"",
+		"change to this namespace in the context's cluster",
+	)
+
+	contextCommand.Flags().BoolVar(
+		&ContextCommandOptions.ExactNamespace,
+		"exact-namespace",
+		false,
+		"change to the namespace without looking it up",
+	)
+
 	createManagedPropertyCommands(&contextCommand, managedProperty[contextInfo]{
 		PropertyNameSingular: "context",
 		PropertyNamePlural:   "contexts",
 		GetItemInfos:         contextInfoIter,
+		DescribeItem:         describeContext,
 		GetItemNames:         contextNames,
+		GetItemAliases:       contextAliases,
+		GetItemLabels:        (*kubesel.Kubesel).GetContextLabels,
+		UsageKind:            kubesel.UsageContext,
 		Switch:               contextSwitchImpl,
+		GetActiveItem:        contextActive,
+		GetItemDefinition:    contextDefinition,
+		Editor: &managedPropertyEditor{
+			GetItemSources: contextSources,
+			Create:         contextCreateImpl,
+			CreateFlags:    contextCreateFlags,
+			Rename:         kcutils.RenameContext,
+			Delete:         kcutils.RemoveContext,
+			IsProtected:    contextIsProtected,
+		},
 	})
+
+	// Allow the namespace to be given after the context name.
+	switchRunE := contextCommand.RunE
+	switchValidArgs := contextCommand.ValidArgsFunction
+	contextCommand.Args = cobra.RangeArgs(0, 2)
+	contextCommand.RunE = func(cmd *cobra.Command, args []string) error {
+		if err := contextNamespaceShorthand.apply(cmd, args); err != nil {
+			return err
+		}
+
+		if len(args) > 1 {
+			if cmd.Flags().Changed("namespace") {
+				return errors.New("the namespace was given as both an argument and a flag")
+			}
+
+			ContextCommandOptions.Namespace = args[1]
+			args = args[:1]
+		}
+
+		if ContextCommandOptions.Namespace != "" && ContextCommandOptions.KeepNamespace {
+			return errors.New("cannot change to a namespace and keep the current namespace")
+		}
+
+		return switchRunE(cmd, args)
+	}
+
+	contextCommand.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if len(args) == 1 && !cmd.Flags().Changed("namespace") {
+			return completeContextNamespaces(args, toComplete)
+		}
+
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return switchValidArgs(cmd, args, toComplete)
+	}
+
+	contextCommand.RegisterFlagCompletionFunc("namespace", // nolint:errcheck
+		func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+			return completeContextNamespaces(args, toComplete)
+		},
+	)
+}
+
+// contextNamespaceShorthand is the value of the deprecated -n flag.
+var contextNamespaceShorthand namespaceShorthandValue
+
+// namespaceShorthandValue is a [pflag.Value] for the -n flag.
+//
+// It used to be the shorthand for --keep-namespace, and will become the
+// shorthand for --namespace. Until then, it is treated as --namespace when it
+// is given a namespace (e.g. `-n payments` or `-n=payments`), and as
+// --keep-namespace when it is not.
+type namespaceShorthandValue struct {
+	set       bool
+	namespace string
+}
+
+func (v *namespaceShorthandValue) String() string {
+	return v.namespace
+}
+
+func (v *namespaceShorthandValue) Set(value string) error {
+	v.set = true
+	if value != "true" {
+		v.namespace = value
+	}
+
+	return nil
+}
+
+func (v *namespaceShorthandValue) Type() string {
+	return "string"
+}
+
+// apply sets the [ContextCommandOptions] from the -n flag.
+//
+// Since -n doesn't take a value unless it's joined with "=", the namespace in
+// `-n payments` is the second argument.
+func (v *namespaceShorthandValue) apply(cmd *cobra.Command, args []string) error {
+	switch {
+	case !v.set || len(args) > 1:
+		return nil
+
+	case v.namespace != "":
+		if cmd.Flags().Changed("namespace") {
+			return errors.New("the namespace was given with both -n and --namespace")
+		}
+
+		ContextCommandOptions.Namespace = v.namespace
+
+	default:
+		ContextCommandOptions.KeepNamespace = true
+	}
+
+	return nil
 }
 
 func contextSwitchImpl(ksel *kubesel.Kubesel, managedKc *kubesel.ManagedKubeconfig, target string) error {
-	kcContext := kcutils.FindContext(target, ksel.GetMergedKubeconfig())
+	session, err := ksel.GetSession()
+	if err != nil {
+		return err
+	}
+
+	opts := kubesel.SwitchContextOptions{
+		KeepNamespace: ContextCommandOptions.KeepNamespace,
+	}
+
+	// Find the namespace before changing anything, so the session is left
+	// alone if it can't be found.
+	if query := ContextCommandOptions.Namespace; query != "" && ContextCommandOptions.ExactNamespace {
+		opts.Namespace = query
+	} else if query != "" {
+		opts.Namespace, err = resolveNamespaceInContext(target, query)
+		if err != nil {
+			return err
+		}
+	}
 
-	managedKc.SetClusterName(*kcContext.Cluster)
-	managedKc.SetAuthInfoName(*kcContext.User)
+	err = session.SwitchContext(context.Background(), target, opts)
+	if err != nil {
+		return err
+	}
 
-	if !ContextCommandOptions.KeepNamespace && kcContext.Namespace != nil {
-		managedKc.SetNamespace(*kcContext.Namespace)
+	if opts.Namespace != "" {
+		_ = ksel.RecordUsage(kubesel.UsageNamespace, opts.Namespace)
 	}
 
-	return managedKc.Save()
+	return nil
+}
+
+// resolveNamespaceInContext fuzzy matches a namespace against the namespaces
+// in the cluster of a kubeconfig context.
+func resolveNamespaceInContext(kubeContext string, query string) (string, error) {
+	namespaces, err := namespaceNamesInContext(kubeContext)
+	if err != nil {
+		return "", fmt.Errorf("cannot get namespaces for context %s: %w", kubeContext, err)
+	}
+
+	namespace, err := fuzzy.MatchOneOrPick(namespaces, pickOptions(query, kubesel.UsageNamespace, nil))
+	if err != nil {
+		return "", fmt.Errorf("namespace %s: %w", query, err)
+	}
+
+	return namespace, nil
+}
+
+// completeContextNamespaces completes the namespaces in the cluster of the
+// context given as the first argument.
+func completeContextNamespaces(args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	ksel, err := Kubesel()
+	if len(args) == 0 || err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	target := ksel.ResolveContextAlias(args[0])
+	if !slices.Contains(ksel.GetContextNames(), target) {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return completeNamespacesInContext(target, toComplete)
+}
+
+// contextActive returns the first context with the session's cluster and
+// user. The namespace can be changed without changing the context, so it
+// isn't compared.
+func contextActive(ksel *kubesel.Kubesel, managedKc *kubesel.ManagedKubeconfig) string {
+	for _, kcNamedContext := range ksel.GetMergedKubeconfig().Contexts {
+		kcContext := kcNamedContext.Context
+		if kcNamedContext.Name == nil || kcContext == nil || kubesel.IsManagedContext(&kcNamedContext) {
+			continue
+		}
+
+		if kcContext.Cluster == nil || *kcContext.Cluster != managedKc.GetClusterName() {
+			continue
+		}
+
+		if kcContext.User == nil || *kcContext.User != managedKc.GetAuthInfoName() {
+			continue
+		}
+
+		return *kcNamedContext.Name
+	}
+
+	return ""
+}
+
+func contextDefinition(ksel *kubesel.Kubesel, name string) any {
+	kcContext := kcutils.FindContext(name, ksel.GetMergedKubeconfig())
+	if kcContext == nil {
+		return nil
+	}
+
+	return kcContext
+}
+
+func contextIsProtected(ksel *kubesel.Kubesel, name string) bool {
+	return ksel.GetConfig().IsProtectedContext(name)
+}
+
+func contextSources(ksel *kubesel.Kubesel) loader.NamedItemSources {
+	return ksel.GetProvenance().Contexts
+}
+
+func contextCreateFlags(flags *pflag.FlagSet) {
+	opts := &ContextCreateCommandOptions
+	flags.StringVar(&opts.Cluster, "cluster", "", "the context's cluster")
+	flags.StringVar(&opts.User, "user", "", "the context's user")
+	flags.StringVar(&opts.Namespace, "namespace", "", "the context's namespace")
+}
+
+func contextCreateImpl(name string, kc *kubeconfig.Config) {
+	opts := &ContextCreateCommandOptions
+	kc.Contexts = append(kc.Contexts, kubeconfig.NamedContext{
+		Name: &name,
+		Context: &kubeconfig.Context{
+			Cluster:   nilIfEmpty(opts.Cluster),
+			User:      nilIfEmpty(opts.User),
+			Namespace: nilIfEmpty(opts.Namespace),
+		},
+	})
+}
+
+func contextAliases() (map[string]string, error) {
+	kubesel, err := Kubesel()
+	if err != nil {
+		return nil, err
+	}
+
+	return kubesel.GetContextAliases(), nil
 }
 
 func contextNames() ([]string, error) {
@@ -88,17 +382,36 @@ func contextNames() ([]string, error) {
 
 type contextInfo struct {
 	Name      *string `yaml:"name" printer:"Name,order=0"`
-	Cluster   *string `yaml:"cluster" printer:"Cluster,order=1"`
-	User      *string `yaml:"user" printer:"User,order=2"`
-	Namespace *string `yaml:"namespace" printer:"Namespace,order=3"`
+	Alias     string  `yaml:"alias" printer:"Alias,order=1"`
+	Cluster   *string `yaml:"cluster" printer:"Cluster,order=2"`
+	User      *string `yaml:"user" printer:"User,order=3"`
+	Namespace *string `yaml:"namespace" printer:"Namespace,order=4"`
+	Labels    string  `yaml:"labels" printer:"Labels,order=5,wide"`
+	Source    *string `yaml:"source" printer:"Source,order=6,wide"`
+	Shadowed  string  `yaml:"shadowed" printer:"Shadowed,order=7,wide"`
+}
+
+// describeContext describes a context as "cluster/user/namespace", leaving
+// out the trailing parts that aren't specified.
+func describeContext(info contextInfo) string {
+	parts := []*string{info.Cluster, info.User, info.Namespace}
+	strs := make([]string, len(parts))
+	for i, part := range parts {
+		if part != nil {
+			strs[i] = *part
+		}
+	}
+
+	return strings.TrimRight(strings.Join(strs, "/"), "/")
 }
 
-func contextInfoIter() (iter.Seq[contextInfo], error) {
+func contextInfoIter(selector kubesel.LabelSelector) (iter.Seq[contextInfo], error) {
 	ksel, err := Kubesel()
 	if err != nil {
 		return nil, err
 	}
 
+	sources := ksel.GetProvenance().Contexts
 	return func(yield func(contextInfo) bool) {
 		for _, kcNamedContext := range ksel.GetMergedKubeconfig().Contexts {
 			if kubesel.IsManagedContext(&kcNamedContext) {
@@ -110,11 +423,25 @@ func contextInfoIter() (iter.Seq[contextInfo], error) {
 				kcContext = &kubeconfig.Context{}
 			}
 
+			labels, selected := labelsColumn(kcNamedContext.Name, ksel.GetContextLabels, selector)
+			if !selected {
+				continue
+			}
+
+			var aliases []string
+			if kcNamedContext.Name != nil {
+				aliases = ksel.GetAliasesForContext(*kcNamedContext.Name)
+			}
+
 			item := contextInfo{
 				Name:      kcNamedContext.Name,
+				Alias:     strings.Join(aliases, ", "),
 				Cluster:   kcContext.Cluster,
 				User:      kcContext.User,
 				Namespace: kcContext.Namespace,
+				Labels:    labels,
+				Source:    sourceOf(sources, kcNamedContext.Name),
+				Shadowed:  shadowedSourcesOf(sources, kcNamedContext.Name),
 			}
 
 			if !yield(item) {
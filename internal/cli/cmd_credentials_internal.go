@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"encoding/json"
+
+	"github.com/spf13/cobra"
+)
+
+// internalCredentialsCommandName is the name of the hidden command used as
+// the kubeconfig exec plugin of overridden users. See
+// [kubesel.Options.CredentialCommand].
+const internalCredentialsCommandName = "__credentials"
+
+var internalCredentialsCommand = cobra.Command{
+	RunE: internalCredentialsCommandMain,
+
+	Use:    internalCredentialsCommandName + " user",
+	Hidden: true,
+
+	Short: "Print the credentials of a user for a kubeconfig exec plugin",
+	Example: `
+		kubesel __credentials my-user
+	`,
+
+	Args: cobra.ExactArgs(1),
+	Annotations: map[string]string{
+		annotationNoKubeconfigWarnings: "true",
+		annotationAllowInvalidConfig:   "true",
+	},
+
+	SilenceUsage: true,
+}
+
+func init() {
+	RootCommand.AddCommand(&internalCredentialsCommand)
+}
+
+func internalCredentialsCommandMain(cmd *cobra.Command, args []string) error {
+	ksel, err := Kubesel()
+	if err != nil {
+		return err
+	}
+
+	credential, err := ksel.GetExecCredential(args[0])
+	if err != nil {
+		return err
+	}
+
+	return json.NewEncoder(cmd.OutOrStdout()).Encode(credential)
+}
@@ -0,0 +1,228 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"github.com/eth-p/kubesel/internal/fuzzy"
+	"github.com/eth-p/kubesel/pkg/kubeconfig"
+	"github.com/eth-p/kubesel/pkg/kubeconfig/kcutils"
+	"github.com/eth-p/kubesel/pkg/kubeconfig/loader"
+	"github.com/eth-p/kubesel/pkg/kubesel"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var exportCommand = cobra.Command{
+	Use:     "export [context]",
+	GroupID: CommandGroupKubeconfig,
+
+	Short: "Print a standalone kubeconfig for a context",
+	Long: `
+		Print a minimal, standalone kubeconfig containing only a single
+		context and the cluster and user it refers to.
+
+		Certificate, key, and token files are inlined into the printed
+		kubeconfig, allowing it to be shared or used on another machine.
+
+		If no context is specified, the current kubesel session is
+		exported instead. The exported context will be named after the
+		session's cluster.
+	`,
+	Example: `
+		kubesel export
+		kubesel export my-context > my-context.yaml
+		kubesel export my-context --redact
+	`,
+
+	Args: cobra.RangeArgs(0, 1),
+	RunE: exportCommandMain,
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if len(args) > 0 || contextCommand.ValidArgsFunction == nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return contextCommand.ValidArgsFunction(cmd, args, toComplete)
+	},
+}
+
+var ExportCommandOptions struct {
+	Exact   bool
+	Flatten bool
+	Redact  bool
+}
+
+func init() {
+	RootCommand.AddCommand(&exportCommand)
+	exportCommand.Flags().BoolVarP(
+		&ExportCommandOptions.Exact,
+		"exact", "e",
+		false,
+		"context must be exact match",
+	)
+
+	exportCommand.Flags().BoolVar(
+		&ExportCommandOptions.Flatten,
+		"flatten",
+		true,
+		"inline certificate, key, and token files",
+	)
+
+	exportCommand.Flags().BoolVar(
+		&ExportCommandOptions.Redact,
+		"redact",
+		false,
+		"replace secrets with placeholder text",
+	)
+}
+
+func exportCommandMain(cmd *cobra.Command, args []string) error {
+	ksel, err := Kubesel()
+	if err != nil {
+		return err
+	}
+
+	var exported *kubeconfig.Config
+	if len(args) == 0 {
+		exported, err = exportSession(ksel)
+	} else {
+		exported, err = exportContext(ksel, args[0])
+	}
+
+	if err != nil {
+		return err
+	}
+
+	provenance := ksel.GetProvenance()
+
+	// Inline the files.
+	if ExportCommandOptions.Flatten {
+		for _, namedCluster := range exported.Clusters {
+			baseDir := baseDirOf(provenance.Clusters, *namedCluster.Name)
+			err = kcutils.FlattenCluster(namedCluster.Cluster, baseDir)
+			if err != nil {
+				return fmt.Errorf("flattening cluster %s: %w", *namedCluster.Name, err)
+			}
+		}
+
+		for _, namedAuthInfo := range exported.AuthInfos {
+			baseDir := baseDirOf(provenance.AuthInfos, *namedAuthInfo.Name)
+			err = kcutils.FlattenAuthInfo(namedAuthInfo.User, baseDir)
+			if err != nil {
+				return fmt.Errorf("flattening user %s: %w", *namedAuthInfo.Name, err)
+			}
+		}
+	}
+
+	// Redact the secrets.
+	if ExportCommandOptions.Redact {
+		for _, namedCluster := range exported.Clusters {
+			kcutils.RedactCluster(namedCluster.Cluster)
+		}
+
+		for _, namedAuthInfo := range exported.AuthInfos {
+			kcutils.RedactAuthInfo(namedAuthInfo.User)
+		}
+	}
+
+	// Print it.
+	marshalled, err := yaml.Marshal(exported)
+	if err != nil {
+		return fmt.Errorf("marshalling kubeconfig: %w", err)
+	}
+
+	_, err = cmd.OutOrStdout().Write(marshalled)
+	return err
+}
+
+// exportContext returns a minified kubeconfig for the context matching the
+// query.
+func exportContext(ksel *kubesel.Kubesel, query string) (*kubeconfig.Config, error) {
+	available := ksel.GetContextNames()
+	aliases := ksel.GetContextAliases()
+
+	desired := query
+	if !ExportCommandOptions.Exact {
+		var err error
+		opts := pickOptions(query, kubesel.UsageContext, aliases)
+		addPickerColumns(&opts, managedProperties["context"], ksel)
+		desired, err = fuzzy.MatchOneOrPick(available, opts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	desired = ksel.ResolveContextAlias(desired)
+
+	if !slices.Contains(available, desired) {
+		return nil, fmt.Errorf("unknown context: %v", desired)
+	}
+
+	return kcutils.Minify(desired, ksel.GetMergedKubeconfig())
+}
+
+// exportSession returns a minified kubeconfig for the current kubesel session.
+// The session's context is renamed after its cluster.
+func exportSession(ksel *kubesel.Kubesel) (*kubeconfig.Config, error) {
+	managedKc, err := ksel.GetManagedKubeconfig()
+	if err != nil {
+		if errors.Is(err, kubesel.ErrUnmanaged) {
+			return nil, fmt.Errorf("%w: specify a context to export", err)
+		}
+
+		return nil, err
+	}
+
+	clusterName := managedKc.GetClusterName()
+	if clusterName == "" {
+		return nil, errors.New("the current session has no cluster")
+	}
+
+	exported, err := kcutils.Minify(kubesel.ManagedContextName, ksel.GetMergedKubeconfig())
+	if err != nil {
+		return nil, err
+	}
+
+	kcutils.RenameContext(kubesel.ManagedContextName, clusterName, exported)
+
+	// The session-scoped cluster and user have names unique to the session,
+	// so the originals are exported with the overrides applied instead.
+	exportedContext := kcutils.FindContext(clusterName, exported)
+	if clusterOverrides := managedKc.GetClusterOverrides(); !clusterOverrides.IsZero() {
+		cluster := kcutils.FindCluster(clusterName, ksel.GetMergedKubeconfig()).Clone()
+		if cluster == nil {
+			return nil, fmt.Errorf("%w: %s", kubesel.ErrUnknownCluster, clusterName)
+		}
+
+		clusterOverrides.ApplyTo(cluster)
+		exported.Clusters = []kubeconfig.NamedCluster{{Name: &clusterName, Cluster: cluster}}
+		exportedContext.Cluster = &clusterName
+	}
+
+	if authInfoOverrides := managedKc.GetAuthInfoOverrides(); !authInfoOverrides.IsZero() {
+		authInfoName := managedKc.GetAuthInfoName()
+		authInfo := kcutils.FindAuthInfo(authInfoName, ksel.GetMergedKubeconfig()).Clone()
+		if authInfo == nil {
+			return nil, fmt.Errorf("%w: %s", kubesel.ErrUnknownUser, authInfoName)
+		}
+
+		authInfoOverrides.ApplyTo(authInfo)
+		exported.AuthInfos = []kubeconfig.NamedAuthInfo{{Name: &authInfoName, User: authInfo}}
+		exportedContext.User = &authInfoName
+	}
+
+	return exported, nil
+}
+
+// baseDirOf returns the directory of the kubeconfig file which defined the
+// named item. Relative paths inside the item are relative to this directory.
+func baseDirOf(sources loader.NamedItemSources, name string) string {
+	kc := sources.DefinedBy(name)
+	if kc == nil {
+		return ""
+	}
+
+	return filepath.Dir(kc.Path)
+}
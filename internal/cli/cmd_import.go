@@ -0,0 +1,215 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eth-p/kubesel/pkg/kubeconfig"
+	"github.com/eth-p/kubesel/pkg/kubeconfig/kcutils"
+	"github.com/eth-p/kubesel/pkg/kubeconfig/loader"
+	"github.com/eth-p/kubesel/pkg/kubesel"
+	"github.com/spf13/cobra"
+)
+
+var importCommand = cobra.Command{
+	Use:     "import [file]",
+	GroupID: CommandGroupKubeconfig,
+
+	Short: "Add the contents of a kubeconfig file",
+	Long: `
+		Add the clusters, users, and contexts of a kubeconfig file to
+		your kubeconfig. If no file is specified, the kubeconfig is read
+		from stdin.
+
+		The items will be added to the first kubeconfig file that
+		exists, unless a different file is specified with --into. If a
+		directory is specified, the items are added to a new file
+		inside that directory.
+
+		If an imported item has the same name as a different, existing
+		item, nothing is imported. Use --prefix to give the imported items
+		a prefix, or --rename to rename the conflicting items.
+	`,
+	Example: `
+		kubesel import ~/Downloads/kubeconfig.yaml
+		kubesel import ~/Downloads/kubeconfig.yaml --prefix=staging-
+		kind get kubeconfig | kubesel import --into ~/.kube/configs
+	`,
+
+	Args: cobra.RangeArgs(0, 1),
+	RunE: importCommandMain,
+}
+
+var ImportCommandOptions struct {
+	Into   string
+	Prefix string
+	Rename bool
+}
+
+func init() {
+	RootCommand.AddCommand(&importCommand)
+	importCommand.Flags().StringVar(
+		&ImportCommandOptions.Into,
+		"into", "",
+		"kubeconfig file or directory to add the items to",
+	)
+
+	importCommand.Flags().StringVar(
+		&ImportCommandOptions.Prefix,
+		"prefix", "",
+		"prefix added to the name of imported items",
+	)
+
+	importCommand.Flags().BoolVar(
+		&ImportCommandOptions.Rename,
+		"rename",
+		false,
+		"rename imported items with conflicting names",
+	)
+}
+
+func importCommandMain(cmd *cobra.Command, args []string) error {
+	ksel, err := Kubesel()
+	if err != nil {
+		return err
+	}
+
+	// Read the kubeconfig being imported.
+	sourceFile := "-"
+	if len(args) > 0 {
+		sourceFile = expandTilde(args[0])
+	}
+
+	source, sourceDir, err := readImportedKubeconfig(cmd.InOrStdin(), sourceFile)
+	if err != nil {
+		return err
+	}
+
+	// Pick the file to add it to.
+	file := expandTilde(ImportCommandOptions.Into)
+	if file == "" {
+		file, err = ksel.GetKubeconfigFilePathForNewItems()
+		if err != nil {
+			return err
+		}
+	} else if stat, err := os.Stat(file); err == nil && stat.IsDir() {
+		file = filepath.Join(file, importedFileName(sourceFile, &source.Config))
+	}
+
+	// Add it.
+	// Relative paths are resolved against the file that contains them, so
+	// importing the same file again reuses the items it already added.
+	targetDir, _ := filepath.Abs(filepath.Dir(file))
+	opts := kcutils.ImportOptions{
+		Prefix:    ImportCommandOptions.Prefix,
+		Existing:  []*kubeconfig.Config{mergedKubeconfigWithAbsolutePaths(ksel)},
+		SourceDir: sourceDir,
+		TargetDir: targetDir,
+	}
+
+	if ImportCommandOptions.Rename {
+		opts.OnConflict = kcutils.ConflictRename
+	}
+
+	var result *kcutils.ImportResult
+	err = ksel.EditKubeconfigFile(file, func(kc *kubeconfig.Config) error {
+		result, err = kcutils.Import(&source.Config, kc, opts)
+		return err
+	})
+
+	if err != nil {
+		if errors.Is(err, kcutils.ErrNameConflict) {
+			return fmt.Errorf("%w\nUse --prefix or --rename to import it with a different name", err)
+		}
+
+		return err
+	}
+
+	// Print the summary.
+	w := cmd.OutOrStdout()
+	fmt.Fprintf(w, "Imported into %s\n", file)
+	printImportedItems(w, "cluster", result.Clusters)
+	printImportedItems(w, "user", result.AuthInfos)
+	printImportedItems(w, "context", result.Contexts)
+	return nil
+}
+
+// readImportedKubeconfig reads the kubeconfig file being imported, or stdin if
+// the file is "-". This also returns the absolute path of the directory that
+// relative paths inside the kubeconfig are relative to.
+func readImportedKubeconfig(stdin io.Reader, file string) (*loader.LoadedKubeconfig, string, error) {
+	var kc *loader.LoadedKubeconfig
+	baseDir := ""
+	if file == "-" {
+		kc = loader.LoadFromReader(stdin)
+		baseDir, _ = os.Getwd()
+	} else {
+		kc = loader.LoadFromFile(file)
+		baseDir = filepath.Dir(file)
+	}
+
+	if len(kc.Errors) > 0 {
+		return nil, "", fmt.Errorf("error loading %s: %w", file, errors.Join(kc.Errors...))
+	}
+
+	baseDir, _ = filepath.Abs(baseDir)
+	return kc, baseDir, nil
+}
+
+// mergedKubeconfigWithAbsolutePaths returns a copy of the merged kubeconfig
+// where the relative paths of each item are resolved against the directory
+// of the file which defined it.
+func mergedKubeconfigWithAbsolutePaths(ksel *kubesel.Kubesel) *kubeconfig.Config {
+	merged := ksel.GetMergedKubeconfig().Clone()
+	provenance := ksel.GetProvenance()
+	for _, namedCluster := range merged.Clusters {
+		if namedCluster.Name != nil {
+			kcutils.ResolveClusterPaths(namedCluster.Cluster, baseDirOf(provenance.Clusters, *namedCluster.Name))
+		}
+	}
+
+	for _, namedAuthInfo := range merged.AuthInfos {
+		if namedAuthInfo.Name != nil {
+			kcutils.ResolveAuthInfoPaths(namedAuthInfo.User, baseDirOf(provenance.AuthInfos, *namedAuthInfo.Name))
+		}
+	}
+
+	return merged
+}
+
+// importedFileName returns the name of the file created when importing into
+// a directory. This is the name of the imported file, or the name of its
+// current context if it was read from stdin.
+func importedFileName(sourceFile string, config *kubeconfig.Config) string {
+	if sourceFile != "-" {
+		return filepath.Base(sourceFile)
+	}
+
+	name := "imported"
+	if config.CurrentContext != nil && *config.CurrentContext != "" {
+		name = *config.CurrentContext
+	} else if len(config.Contexts) > 0 && config.Contexts[0].Name != nil {
+		name = *config.Contexts[0].Name
+	}
+
+	name = strings.NewReplacer("/", "-", string(filepath.Separator), "-").Replace(name)
+	return ImportCommandOptions.Prefix + name + ".yaml"
+}
+
+// printImportedItems prints a line describing each imported item.
+func printImportedItems(w io.Writer, kind string, items []kcutils.ImportedItem) {
+	for _, item := range items {
+		switch {
+		case item.Reused:
+			fmt.Fprintf(w, " - %s %s (unchanged, already exists)\n", kind, item.Name)
+		case item.Renamed():
+			fmt.Fprintf(w, " - %s %s (renamed from %s)\n", kind, item.Name, item.OriginalName)
+		default:
+			fmt.Fprintf(w, " - %s %s\n", kind, item.Name)
+		}
+	}
+}
@@ -1,23 +1,20 @@
 package cli
 
 import (
-	"embed"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
-	"text/template"
+	"time"
 
-	"al.essio.dev/pkg/shellescape"
 	"github.com/adrg/xdg"
 	"github.com/eth-p/kubesel/internal/cobraerr"
+	"github.com/eth-p/kubesel/pkg/kubesel"
 	"github.com/spf13/cobra"
 )
 
-//go:embed shell-init/init.*
-var initScripts embed.FS
-
 // initScriptLoadsCompletions is set to `false` when using the
 // `no-init-completions` go build tag.
 var initScriptLoadsCompletions = true
@@ -47,23 +44,32 @@ var initCommand = cobra.Command{
 
 		# Add kubeconfig files from a glob pattern.
 		kubesel init fish --add-kubeconfigs=~/.kube/configs/*.yaml | source
+
+		# Add kubeconfig files from a directory, including new ones.
+		kubesel init fish --add-kubeconfig-dirs=~/.kube/configs | source
+
+		# Add a kubeconfig printed by a command, running it every hour.
+		kubesel init fish --add-kubeconfig-commands='kind get kubeconfig' \
+			--kubeconfig-command-ttl=1h | source
 	`,
 
-	Args: cobra.ExactArgs(1),
-	ValidArgs: []string{
-		"bash",
-		"fish",
-		"zsh",
-	},
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: kubesel.InitScriptShells,
 }
 
 var InitCommandOptions struct {
-	KubeconfigFiles []string
+	KubeconfigFiles      []string
+	KubeconfigDirs       []string
+	KubeconfigCommands   []string
+	KubeconfigCommandTTL time.Duration
 }
 
 func init() {
 	RootCommand.AddCommand(&initCommand)
 	initCommand.Flags().StringArrayVar(&InitCommandOptions.KubeconfigFiles, "add-kubeconfigs", []string{}, "kubeconfig files to add")
+	initCommand.Flags().StringArrayVar(&InitCommandOptions.KubeconfigDirs, "add-kubeconfig-dirs", []string{}, "directories to load kubeconfig files from")
+	initCommand.Flags().StringArrayVar(&InitCommandOptions.KubeconfigCommands, "add-kubeconfig-commands", []string{}, "commands that print kubeconfig files")
+	initCommand.Flags().DurationVar(&InitCommandOptions.KubeconfigCommandTTL, "kubeconfig-command-ttl", kubesel.DefaultKubeconfigCommandTTL, "how long to reuse the output of kubeconfig commands")
 }
 
 func initCommandMain(cmd *cobra.Command, args []string) error {
@@ -73,59 +79,46 @@ func initCommandMain(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	// Parse the init script as a Go template.
-	initScript, err := getInitScript(os.Args[0], args[0], kcFiles)
+	// Find kubeconfig source directories.
+	kcDirs, err := resolveKubeconfigDirs()
 	if err != nil {
 		return err
 	}
 
-	// Print it to standard out.
-	_, err = io.WriteString(cmd.OutOrStdout(), initScript)
+	// Check the kubeconfig commands.
+	kcCommands, err := resolveKubeconfigCommands()
 	if err != nil {
-		return fmt.Errorf("failed to print shell init script: %w", err)
+		return err
 	}
 
-	return nil
-}
-
-// getInitScript generates the init script for the specified shell.
-//
-// Supported shells are:
-//   - bash
-//   - fish
-//   - zsh
-func getInitScript(argv0 string, shell string, extraKubeconfigFiles []string) (string, error) {
-	templateSource, err := initScripts.ReadFile("shell-init/init." + shell)
-	if err != nil {
-		return "", fmt.Errorf("unsupported shell: %s", shell)
+	// The TTL is only passed on if it was changed by the flag or the config
+	// file, so sessions use the default TTL of newer kubesel versions.
+	var kcCommandTTL *time.Duration
+	if InitCommandOptions.KubeconfigCommandTTL != kubesel.DefaultKubeconfigCommandTTL {
+		kcCommandTTL = &InitCommandOptions.KubeconfigCommandTTL
 	}
 
-	// Parse the script as a Go template.
-	tpl := template.New("init-script").
-		Funcs(template.FuncMap{
-			"shellquote": shellescape.Quote,
-			"join":       strings.Join,
-		})
+	// Generate the init script.
+	initScript, err := kubesel.InitScript(args[0], kubesel.InitScriptOptions{
+		Executable:           kubeselExecutable(),
+		KubeconfigFiles:      kcFiles,
+		KubeconfigDirs:       kcDirs,
+		KubeconfigCommands:   kcCommands,
+		KubeconfigCommandTTL: kcCommandTTL,
+		LoadCompletions:      initScriptLoadsCompletions,
+	})
 
-	tpl, err = tpl.Parse(string(templateSource))
 	if err != nil {
-		return "", fmt.Errorf("failed to parse %s init script as template: %w", shell, err)
+		return err
 	}
 
-	// Evaluate the template.
-	var sb strings.Builder
-	err = tpl.Execute(&sb, map[string]any{
-		"kubesel_executable": argv0,
-		"kubesel_name":       filepath.Base(argv0),
-		"load_completions":   initScriptLoadsCompletions,
-		"add_kubeconfigs":    extraKubeconfigFiles,
-	})
-
+	// Print it to standard out.
+	_, err = io.WriteString(cmd.OutOrStdout(), initScript)
 	if err != nil {
-		return "", fmt.Errorf("failed to evaluate %s init script template: %w", shell, err)
+		return fmt.Errorf("failed to print shell init script: %w", err)
 	}
 
-	return sb.String(), nil
+	return nil
 }
 
 // resolveKubeconfigFileGlobs searches for the files referenced in the
@@ -163,6 +156,60 @@ func resolveKubeconfigFileGlobs() ([]string, error) {
 	return results, nil
 }
 
+// resolveKubeconfigDirs checks the directories in the `--add-kubeconfig-dirs`
+// flag, returning their absolute paths.
+//
+// Unlike `--add-kubeconfigs`, the files inside these directories are not
+// searched for here. Kubesel searches for them every time it runs.
+func resolveKubeconfigDirs() ([]string, error) {
+	var results []string
+	for _, dir := range InitCommandOptions.KubeconfigDirs {
+		dir, err := filepath.Abs(expandTilde(dir))
+		if err == nil {
+			var stat os.FileInfo
+			stat, err = os.Stat(dir)
+			if err == nil && !stat.IsDir() {
+				err = errors.New("not a directory")
+			}
+		}
+
+		if err != nil {
+			return nil, &cobraerr.InvalidFlagError{
+				Flag:  "add-kubeconfig-dirs",
+				Value: dir,
+				Cause: err.Error(),
+			}
+		}
+
+		results = append(results, dir)
+	}
+
+	return results, nil
+}
+
+// resolveKubeconfigCommands checks the commands in the
+// `--add-kubeconfig-commands` flag.
+//
+// The commands are not run here. Kubesel runs them whenever their output is
+// older than the `--kubeconfig-command-ttl` duration.
+func resolveKubeconfigCommands() ([]string, error) {
+	var results []string
+	for _, command := range InitCommandOptions.KubeconfigCommands {
+		command = strings.TrimSpace(command)
+		if command == "" || strings.Contains(command, "\n") {
+			return nil, &cobraerr.InvalidFlagError{
+				Flag:  "add-kubeconfig-commands",
+				Value: command,
+				Cause: "commands must be a single, non-empty line",
+			}
+		}
+
+		results = append(results, command)
+	}
+
+	return results, nil
+}
+
 // expandTilde expands file paths that start with `~/` to `$HOME/`.
 // If the path does not start with a tilde, it will be returned as-is.
 func expandTilde(path string) string {
@@ -1,13 +1,11 @@
 package cli
 
 import (
-	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 
-	"github.com/eth-p/kubesel/pkg/kubeconfig/kcutils"
 	"github.com/eth-p/kubesel/pkg/kubesel"
 	"github.com/spf13/cobra"
 )
@@ -31,6 +29,9 @@ var internalInitCommand = cobra.Command{
 	`,
 
 	Args: cobra.NoArgs,
+	Annotations: map[string]string{
+		annotationAllowInvalidConfig: "true",
+	},
 
 	SilenceErrors: true,
 	SilenceUsage:  true,
@@ -66,70 +67,23 @@ func internalInitCommandMain(cmd *cobra.Command, args []string) error {
 		os.Exit(2)
 	}
 
-	// Create the managed kubeconfig.
-	managedKc, err := ksel.CreateManagedKubeconfig(*owner)
-
-	// If the managed kubeconfig already exists, we'll re-use it.
-	if errors.Is(err, kubesel.ErrAlreadyManaged) {
-		printNewKubeconfigEnvVar(ksel, ksel.GetManagedKubeconfigPathForOwner(*owner))
-		return nil
-	}
-
-	// If an error occurred, exit.
+	// Create the managed kubeconfig, or re-use the existing one.
+	session, err := ksel.OpenSession(cmd.Context(), *owner)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "kubesel error creating managed kubeconfig: %v\n", err)
 		os.Exit(2)
 	}
 
-	// Use the same cluster, user, and namespace we had before.
-	currentKc := ksel.GetMergedKubeconfig()
-	if currentKc.CurrentContext != nil {
-		currentContext := kcutils.FindContext(*currentKc.CurrentContext, currentKc)
-		if currentContext != nil {
-			if currentContext.Cluster != nil {
-				managedKc.SetClusterName(*currentContext.Cluster)
-			}
-			if currentContext.User != nil {
-				managedKc.SetAuthInfoName(*currentContext.User)
-			}
-			if currentContext.Namespace != nil {
-				managedKc.SetNamespace(*currentContext.Namespace)
-			}
-
-			err = managedKc.Save()
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "kubesel error creating managed kubeconfig: %v\n", err)
-				os.Exit(2)
-			}
-		}
-	}
-
 	// Print the new KUBECONFIG environment variable.
-	printNewKubeconfigEnvVar(ksel, managedKc.Path())
+	printNewKubeconfigEnvVar(session)
 	return nil
 }
 
-func printNewKubeconfigEnvVar(ksel *kubesel.Kubesel, managedKcPath string) {
-	var sb strings.Builder
-
-	// Add the managed kubeconfig file at the start.
-	sb.WriteString(managedKcPath)
-
-	// Add the unmanaged kubeconfig files at the end.
-	// Only print a file the first time it appears.
-	seenFiles := make(map[string]bool, 0)
-	for _, kcPath := range ksel.GetKubeconfigFilePaths() {
-		if ksel.IsManagedKubeconfigPath(kcPath) {
-			continue
-		}
-
-		if !seenFiles[kcPath] {
-			seenFiles[kcPath] = true
-			sb.WriteRune(filepath.ListSeparator)
-			sb.WriteString(kcPath)
-		}
+func printNewKubeconfigEnvVar(session *kubesel.Session) {
+	paths, err := session.KubeconfigPaths()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "kubesel error preparing kubeconfig files: %v\n", err)
 	}
 
-	// Print it.
-	fmt.Fprintf(os.Stdout, "%s\n", sb.String())
+	fmt.Fprintf(os.Stdout, "%s\n", strings.Join(paths, string(filepath.ListSeparator)))
 }
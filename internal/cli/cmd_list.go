@@ -29,6 +29,9 @@ var listCommand = cobra.Command{
 		With the table and column formats, the printed columns and
 		their order can be changed by by appending '=COL1,COL2' to
 		to the output format (e.g. '--output table=name,cluster').
+
+		Clusters and contexts can be filtered by their labels with
+		'--selector' (e.g. '-l env=prod,region!=eu').
 	`,
 
 	Args: cobra.NoArgs,
@@ -36,6 +39,8 @@ var listCommand = cobra.Command{
 
 var ListCommandOptions struct {
 	OutputFormat OutputFormat
+	Selector     LabelSelectorFlag
+	Watch        bool
 }
 
 func init() {
@@ -45,4 +50,17 @@ func init() {
 		"output", "o",
 		"output format",
 	)
+
+	listCommand.PersistentFlags().VarP(
+		&ListCommandOptions.Selector,
+		selectorFlagName, "l",
+		"only list items with matching labels (e.g. 'env=prod')",
+	)
+
+	listCommand.PersistentFlags().BoolVarP(
+		&ListCommandOptions.Watch,
+		"watch", "w",
+		false,
+		"print again when the kubeconfig files change",
+	)
 }
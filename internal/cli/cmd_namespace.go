@@ -3,7 +3,7 @@ package cli
 import (
 	"context"
 	"iter"
-	"strings"
+	"sync"
 	"time"
 
 	"github.com/eth-p/kubesel/pkg/kubesel"
@@ -44,44 +44,53 @@ func init() {
 		PropertyNamePlural:   "namespaces",
 		GetItemInfos:         namespaceInfoIter,
 		GetItemNames:         namespaceNames,
+		UsageKind:            kubesel.UsageNamespace,
 		Switch:               namespaceSwitchImpl,
+		GetActiveItem:        namespaceActive,
 	})
 }
 
+func namespaceActive(ksel *kubesel.Kubesel, managedKc *kubesel.ManagedKubeconfig) string {
+	return managedKc.GetNamespace()
+}
+
 func namespaceSwitchImpl(ksel *kubesel.Kubesel, managedKc *kubesel.ManagedKubeconfig, target string) error {
-	managedKc.SetNamespace(target)
-	return managedKc.Save()
+	state := managedKc.State()
+	state.Namespace = target
+	return managedKc.Apply(state)
 }
 
-func namespaceNames() ([]string, error) {
-	kctl, err := Kubectl()
+// namespaceNames returns the namespaces in the session's cluster. They are
+// only listed once, since switching namespaces can need them both to match
+// the query and to show the picker.
+var namespaceNames = sync.OnceValues(func() ([]string, error) {
+	return namespaceNamesInContext("")
+})
+
+// namespaceNamesInContext returns the namespaces in the cluster of a
+// kubeconfig context. If the context is empty, the current context is used.
+func namespaceNamesInContext(kubeContext string) ([]string, error) {
+	ksel, err := Kubesel()
 	if err != nil {
 		return nil, err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
-	defer cancel()
-
-	// Get the namespaces using kubectl.
-	output, err := kctl.Exec(ctx, []string{"get", "namespace", "--output=name", "--no-headers", "--server-print"})
+	session, err := ksel.GetSession()
 	if err != nil {
 		return nil, err
 	}
 
-	// Clean up the returned list.
-	namespaces := strings.Split(output, "\n")
-	for i, ns := range namespaces {
-		namespaces[i] = strings.TrimPrefix(strings.Trim(ns, " \t\r\n"), "namespace/")
-	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
 
-	return namespaces, nil
+	return session.GetNamespaces(ctx, kubeContext)
 }
 
 type namespaceInfo struct {
 	Name *string `yaml:"name" printer:"Name,order=1"`
 }
 
-func namespaceInfoIter() (iter.Seq[namespaceInfo], error) {
+func namespaceInfoIter(_ kubesel.LabelSelector) (iter.Seq[namespaceInfo], error) {
 	namespaces, err := namespaceNames()
 	if err != nil {
 		return nil, err
@@ -99,3 +108,19 @@ func namespaceInfoIter() (iter.Seq[namespaceInfo], error) {
 		}
 	}, nil
 }
+
+// completeNamespacesInContext returns shell completions for the namespaces
+// in the cluster of a kubeconfig context.
+func completeNamespacesInContext(kubeContext string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	namespaces, err := namespaceNamesInContext(kubeContext)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	items := make([]completionItem, 0, len(namespaces))
+	for _, namespace := range namespaces {
+		items = append(items, completionItem{name: namespace})
+	}
+
+	return rankCompletionItems(items, toComplete, kubesel.UsageNamespace, nil)
+}
@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/eth-p/kubesel/internal/printer"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var internalPreviewCommand = cobra.Command{
+	RunE: internalPreviewCommandMain,
+
+	Use:    "__preview property name",
+	Hidden: true,
+
+	Short: "Print the details of an item for the fzf preview window",
+	Example: `
+		kubesel __preview context my-context
+	`,
+
+	Args: cobra.ExactArgs(2),
+	Annotations: map[string]string{
+		annotationNoKubeconfigWarnings: "true",
+		annotationAllowInvalidConfig:   "true",
+	},
+
+	SilenceUsage: true,
+}
+
+func init() {
+	RootCommand.AddCommand(&internalPreviewCommand)
+}
+
+func internalPreviewCommandMain(cmd *cobra.Command, args []string) error {
+	prop, ok := managedProperties[args[0]]
+	if !ok || prop.GetItemInfos == nil {
+		return fmt.Errorf("unknown property: %s", args[0])
+	}
+
+	ksel, err := Kubesel()
+	if err != nil {
+		return err
+	}
+
+	itemTyp, err := printer.ItemTypeOf(prop.InfoStructType)
+	if err != nil {
+		return err
+	}
+
+	iter, err := prop.GetItemInfos(nil)
+	if err != nil {
+		return err
+	}
+
+	// Find the item by its name, which is the first column.
+	name := args[1]
+	nameField := itemTyp.Fields[0]
+	var info any
+	for item := range iter {
+		if nameField.Format(item) == name {
+			info = item
+			break
+		}
+	}
+
+	if info == nil {
+		return fmt.Errorf("unknown %s: %s", prop.PropertyNameSingular, name)
+	}
+
+	// Print every column, including the wide ones.
+	out := cmd.OutOrStdout()
+	printPreviewFields(out, itemTyp, info)
+
+	// Print the kubeconfig definition.
+	if prop.GetItemDefinition != nil {
+		if definition := prop.GetItemDefinition(ksel, name); definition != nil {
+			marshalled, err := yaml.Marshal(definition)
+			if err != nil {
+				return fmt.Errorf("marshalling %s: %w", prop.PropertyNameSingular, err)
+			}
+
+			fmt.Fprintln(out)
+			out.Write(marshalled) // nolint:errcheck
+		}
+	}
+
+	return nil
+}
+
+// printPreviewFields prints the fields of an item info struct as a list of
+// "Name: value" lines.
+func printPreviewFields(w io.Writer, itemTyp *printer.ItemType, info any) {
+	labelColor := ""
+	if GlobalOptions.Color {
+		labelColor = ansi.SGR(ansi.BoldAttr)
+	}
+
+	width := 0
+	for _, field := range itemTyp.Fields {
+		width = max(width, len(field.Name)+1)
+	}
+
+	for _, field := range itemTyp.Fields {
+		label := field.Name + ":"
+		fmt.Fprintf(w, "%s%s %s\n",
+			printer.ApplyColor(labelColor, label),
+			printer.MakePadding(label, width),
+			field.Format(info),
+		)
+	}
+}
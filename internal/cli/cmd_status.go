@@ -0,0 +1,214 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/eth-p/kubesel/pkg/kubeconfig/loader"
+	"github.com/eth-p/kubesel/pkg/kubesel"
+	"github.com/spf13/cobra"
+)
+
+var statusCommand = cobra.Command{
+	Use:     "status",
+	GroupID: CommandGroupInfo,
+
+	Short: "Show the current session and kubeconfig files",
+	Long: `
+		Show the cluster, user, and namespace of the current shell, and
+		the kubeconfig files that kubesel is reading. Any files that
+		could not be loaded are shown with the reason why.
+
+		Use '--short' to only print the cluster, user, and namespace
+		on a single line, which is suitable for a shell prompt. If
+		a user is being impersonated, it is shown after them.
+	`,
+	Example: `
+		kubesel status
+		kubesel status --watch  # update when the kubeconfig files change
+		kubesel status --short  # cluster/user/namespace
+	`,
+
+	Annotations: map[string]string{
+		annotationNoKubeconfigWarnings: "true",
+		annotationNoCredentialWarnings: "true",
+	},
+
+	Args: cobra.NoArgs,
+	RunE: statusCommandMain,
+}
+
+var StatusCommandOptions struct {
+	Watch bool
+	Short bool
+}
+
+func init() {
+	RootCommand.AddCommand(&statusCommand)
+	statusCommand.Flags().BoolVarP(
+		&StatusCommandOptions.Watch,
+		"watch", "w",
+		false,
+		"print again when the kubeconfig files change",
+	)
+
+	statusCommand.Flags().BoolVarP(
+		&StatusCommandOptions.Short,
+		"short", "s",
+		false,
+		"only print the session on a single line",
+	)
+}
+
+func statusCommandMain(cmd *cobra.Command, args []string) error {
+	ksel, err := Kubesel()
+	if err != nil {
+		return err
+	}
+
+	return runWatchable(cmd, StatusCommandOptions.Watch, func(w io.Writer) error {
+		if StatusCommandOptions.Short {
+			printShortStatus(ksel, w)
+			return nil
+		}
+
+		printStatus(ksel, w)
+		return nil
+	})
+}
+
+// printStatus prints the current session and the kubeconfig files.
+func printStatus(ksel *kubesel.Kubesel, w io.Writer) {
+	// Print the session.
+	managedKc, err := ksel.GetManagedKubeconfig()
+	switch {
+	case errors.Is(err, kubesel.ErrUnmanaged):
+		fmt.Fprintf(w, "Session: none (kubesel is not initialized)\n")
+	case err != nil:
+		fmt.Fprintf(w, "Session: invalid (%v)\n", err)
+	default:
+		fmt.Fprintf(w, "Session: %s\n", managedKc.Path())
+		fmt.Fprintf(w, "Cluster: %s%s\n", managedKc.GetClusterName(), describeClusterOverrides(managedKc.GetClusterOverrides()))
+		fmt.Fprintf(w, "User: %s\n", managedKc.GetAuthInfoName())
+		if impersonation := describeImpersonation(managedKc.GetAuthInfoOverrides()); impersonation != "" {
+			fmt.Fprintf(w, "Impersonating: %s\n", impersonation)
+		}
+
+		fmt.Fprintf(w, "Namespace: %s\n", managedKc.GetNamespace())
+		if warning := describeSessionCredentials(ksel, time.Now()); warning != "" {
+			fmt.Fprintf(w, "Warning: %s\n", warning)
+		}
+	}
+
+	// Print the kubeconfig files.
+	loadErrs := make(map[string]*loader.LoadError)
+	for _, loadErr := range ksel.GetKubeconfigLoadErrors() {
+		loadErrs[loadErr.Path] = loadErr
+	}
+
+	fmt.Fprintf(w, "Kubeconfig files:\n")
+	for _, path := range ksel.GetKubeconfigFilePaths() {
+		printKubeconfigFileStatus(w, ksel, path, loadErrs[path])
+	}
+}
+
+// describeClusterOverrides returns the session's cluster overrides as a
+// parenthesized suffix, or an empty string if there are none.
+func describeClusterOverrides(overrides kubesel.ClusterOverrides) string {
+	var parts []string
+	if overrides.ProxyURL != "" {
+		parts = append(parts, "proxy "+overrides.ProxyURL)
+	}
+
+	if overrides.InsecureSkipTLSVerify {
+		parts = append(parts, "insecure")
+	}
+
+	return parenthesize(parts)
+}
+
+// printShortStatus prints the cluster, user, and namespace of the current
+// session on a single line. Nothing is printed without a session.
+func printShortStatus(ksel *kubesel.Kubesel, w io.Writer) {
+	managedKc, err := ksel.GetManagedKubeconfig()
+	if err != nil {
+		return
+	}
+
+	line := strings.Join([]string{
+		managedKc.GetClusterName(),
+		managedKc.GetAuthInfoName(),
+		managedKc.GetNamespace(),
+	}, "/")
+
+	if overrides := managedKc.GetAuthInfoOverrides(); overrides.As != "" {
+		line += " as " + overrides.As
+	}
+
+	fmt.Fprintln(w, line)
+}
+
+// describeImpersonation returns the user, groups, and extra information being
+// impersonated, or an empty string if there is no impersonation.
+func describeImpersonation(overrides kubesel.AuthInfoOverrides) string {
+	var parts []string
+	if len(overrides.AsGroups) > 0 {
+		parts = append(parts, "groups "+strings.Join(overrides.AsGroups, ", "))
+	}
+
+	extraKeys := slices.Sorted(maps.Keys(overrides.AsUserExtra))
+	for _, key := range extraKeys {
+		parts = append(parts, fmt.Sprintf("%s=%s", key, strings.Join(overrides.AsUserExtra[key], ",")))
+	}
+
+	if overrides.As == "" {
+		return strings.TrimPrefix(parenthesize(parts), " ")
+	}
+
+	return overrides.As + parenthesize(parts)
+}
+
+func parenthesize(parts []string) string {
+	if len(parts) == 0 {
+		return ""
+	}
+
+	return " (" + strings.Join(parts, "; ") + ")"
+}
+
+// printKubeconfigFileStatus prints a line describing a kubeconfig file,
+// followed by where it came from and the errors encountered while loading it.
+func printKubeconfigFileStatus(w io.Writer, ksel *kubesel.Kubesel, path string, loadErr *loader.LoadError) {
+	switch {
+	case loadErr != nil:
+		fmt.Fprintf(w, " - %s (failed to load)\n", path)
+	case !fileExists(path):
+		fmt.Fprintf(w, " - %s (does not exist)\n", path)
+	case ksel.IsEncryptedFilePath(path):
+		fmt.Fprintf(w, " - %s (encrypted)\n", path)
+	default:
+		fmt.Fprintf(w, " - %s\n", path)
+	}
+
+	if command, ok := ksel.GetKubeconfigCommandForPath(path); ok {
+		fmt.Fprintf(w, "     output of: %s\n", command)
+	}
+
+	if loadErr != nil {
+		for _, err := range loadErr.Errors {
+			fmt.Fprintf(w, "     %v\n", err)
+		}
+	}
+}
+
+// fileExists returns true if there is a file at the given path.
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
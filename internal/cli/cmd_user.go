@@ -4,10 +4,14 @@ import (
 	"iter"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/eth-p/kubesel/pkg/kubeconfig"
+	"github.com/eth-p/kubesel/pkg/kubeconfig/kcutils"
+	"github.com/eth-p/kubesel/pkg/kubeconfig/loader"
 	"github.com/eth-p/kubesel/pkg/kubesel"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var userCommand = cobra.Command{
@@ -28,17 +32,40 @@ var userCommand = cobra.Command{
 		name. If no user is specified or if the specified name
 		fuzzily matches multiple users, a fzf picker will be
 		opened.
+
+		Use '--as' to impersonate another user in the current shell
+		only, and '--as-group' or '--as-extra' to also impersonate
+		groups or extra information about the user. The
+		kubeconfig files are not changed. If no user is specified,
+		the current user is changed. Changing to a user without them
+		stops the impersonation.
 	`,
 	Example: `
 		kubesel cluster my.cluster.example  # full name
 		kubesel cluster myclstr             # fuzzy match
 		kubesel cluster                     # fzf picker
+		kubesel user --as=admin             # current user, impersonating admin
+
+		# Edit users.
+		kubesel user create my-user --token=abcdef
+		kubesel user rename my-user better-name
+		kubesel user delete better-name
 	`,
 
 	PreRun: tryQuickGC,
 }
 
 var UserCommandOptions struct {
+	impersonationOptions
+	As string
+}
+
+var UserCreateCommandOptions struct {
+	Token             string
+	Username          string
+	Password          string
+	ClientCertificate string
+	ClientKey         string
 }
 
 func init() {
@@ -47,14 +74,129 @@ func init() {
 		PropertyNameSingular: "user",
 		PropertyNamePlural:   "users",
 		GetItemInfos:         userInfoIter,
+		DescribeItem:         describeUser,
 		GetItemNames:         userNames,
+		UsageKind:            kubesel.UsageUser,
 		Switch:               userSwitchImpl,
+		GetActiveItem:        userActive,
+		GetItemDefinition:    userDefinition,
+		Editor: &managedPropertyEditor{
+			GetItemSources:    userSources,
+			Create:            userCreateImpl,
+			CreateFlags:       userCreateFlags,
+			Rename:            kcutils.RenameAuthInfo,
+			FindReferences:    kcutils.FindAuthInfoReferences,
+			ReplaceReferences: kcutils.ReplaceAuthInfoReferences,
+			RenameActive:      userRenameActiveImpl,
+			Delete:            kcutils.RemoveAuthInfo,
+		},
 	})
+
+	userCommand.Flags().StringVar(
+		&UserCommandOptions.As,
+		"as",
+		"",
+		"impersonate this user in the current shell",
+	)
+
+	impersonationFlags(userCommand.Flags(), &UserCommandOptions.impersonationOptions)
+
+	// Change the current user if only the overrides are given.
+	switchRunE := userCommand.RunE
+	userCommand.RunE = func(cmd *cobra.Command, args []string) error {
+		overrides, err := userOverridesFromFlags()
+		if err != nil {
+			return err
+		}
+
+		if len(args) > 0 || overrides.IsZero() {
+			return switchRunE(cmd, args)
+		}
+
+		ksel, err := Kubesel()
+		if err != nil {
+			return err
+		}
+
+		managedKc, err := ksel.GetManagedKubeconfig()
+		if err != nil {
+			return err
+		}
+
+		return userSwitchImpl(ksel, managedKc, managedKc.GetAuthInfoName())
+	}
+}
+
+func userOverridesFromFlags() (kubesel.AuthInfoOverrides, error) {
+	opts := &UserCommandOptions
+	return opts.authInfoOverrides(opts.As)
 }
 
 func userSwitchImpl(ksel *kubesel.Kubesel, managedKc *kubesel.ManagedKubeconfig, target string) error {
-	managedKc.SetAuthInfoName(target)
-	return managedKc.Save()
+	overrides, err := userOverridesFromFlags()
+	if err != nil {
+		return err
+	}
+
+	state := managedKc.State()
+	state.AuthInfoName = target
+	state.AuthInfoOverrides = overrides
+	return managedKc.Apply(state)
+}
+
+func userActive(ksel *kubesel.Kubesel, managedKc *kubesel.ManagedKubeconfig) string {
+	return managedKc.GetAuthInfoName()
+}
+
+// userDefinition returns the user with its credentials redacted.
+func userDefinition(ksel *kubesel.Kubesel, name string) any {
+	kcAuthInfo := kcutils.FindAuthInfo(name, ksel.GetMergedKubeconfig())
+	if kcAuthInfo == nil {
+		return nil
+	}
+
+	kcAuthInfo = kcAuthInfo.Clone()
+	kcutils.RedactAuthInfo(kcAuthInfo)
+	return kcAuthInfo
+}
+
+func userSources(ksel *kubesel.Kubesel) loader.NamedItemSources {
+	return ksel.GetProvenance().AuthInfos
+}
+
+func userCreateFlags(flags *pflag.FlagSet) {
+	opts := &UserCreateCommandOptions
+	flags.StringVar(&opts.Token, "token", "", "bearer token for authentication")
+	flags.StringVar(&opts.Username, "username", "", "username for basic authentication")
+	flags.StringVar(&opts.Password, "password", "", "password for basic authentication")
+	flags.StringVar(&opts.ClientCertificate, "client-certificate", "", "path to a client certificate")
+	flags.StringVar(&opts.ClientKey, "client-key", "", "path to a client key")
+}
+
+func userCreateImpl(name string, kc *kubeconfig.Config) {
+	opts := &UserCreateCommandOptions
+	kc.AuthInfos = append(kc.AuthInfos, kubeconfig.NamedAuthInfo{
+		Name: &name,
+		User: &kubeconfig.AuthInfo{
+			Token:                 nilIfEmpty(opts.Token),
+			Username:              nilIfEmpty(opts.Username),
+			Password:              nilIfEmpty(opts.Password),
+			ClientCertificateFile: nilIfEmpty(expandTilde(opts.ClientCertificate)),
+			ClientKeyFile:         nilIfEmpty(expandTilde(opts.ClientKey)),
+		},
+	})
+}
+
+// userRenameActiveImpl switches the managed kubeconfig to the renamed
+// user, keeping its overrides.
+func userRenameActiveImpl(managedKc *kubesel.ManagedKubeconfig, from, to string) error {
+	state := managedKc.State()
+	if state.AuthInfoName != from {
+		return nil
+	}
+
+	state.AuthInfoName = to
+	return managedKc.Apply(state)
 }
 
 func userNames() ([]string, error) {
@@ -69,16 +211,30 @@ func userNames() ([]string, error) {
 type userInfo struct {
 	Name         *string `yaml:"name" printer:"Name,order=0"`
 	AuthProvider string  `yaml:"auth-provider" printer:"Auth Provider,order=1"`
+	Expires      string  `yaml:"expires" printer:"Expires,order=2,wide"`
+	Source       *string `yaml:"source" printer:"Source,order=3,wide"`
+	Shadowed     string  `yaml:"shadowed" printer:"Shadowed,order=4,wide"`
 }
 
-func userInfoIter() (iter.Seq[userInfo], error) {
-	kubesel, err := Kubesel()
+// describeUser describes a user by its auth provider.
+func describeUser(info userInfo) string {
+	return info.AuthProvider
+}
+
+func userInfoIter(_ kubesel.LabelSelector) (iter.Seq[userInfo], error) {
+	ksel, err := Kubesel()
 	if err != nil {
 		return nil, err
 	}
 
+	sources := ksel.GetProvenance().AuthInfos
+	now := time.Now()
 	return func(yield func(userInfo) bool) {
-		for _, kcNamedUser := range kubesel.GetMergedKubeconfig().AuthInfos {
+		for _, kcNamedUser := range ksel.GetMergedKubeconfig().AuthInfos {
+			if kubesel.IsManagedAuthInfo(&kcNamedUser) {
+				continue
+			}
+
 			kcUser := kcNamedUser.User
 			if kcUser == nil {
 				kcUser = &kubeconfig.AuthInfo{}
@@ -87,6 +243,9 @@ func userInfoIter() (iter.Seq[userInfo], error) {
 			item := userInfo{
 				Name:         kcNamedUser.Name,
 				AuthProvider: summarizeAuthProvider(kcUser),
+				Expires:      summarizeExpiry(ksel, kcNamedUser.Name, now),
+				Source:       sourceOf(sources, kcNamedUser.Name),
+				Shadowed:     shadowedSourcesOf(sources, kcNamedUser.Name),
 			}
 
 			if !yield(item) {
@@ -96,6 +255,29 @@ func userInfoIter() (iter.Seq[userInfo], error) {
 	}, nil
 }
 
+// summarizeExpiry describes when the first of a user's credentials expires.
+func summarizeExpiry(ksel *kubesel.Kubesel, name *string, now time.Time) string {
+	if name == nil {
+		return ""
+	}
+
+	creds := ksel.GetUserCredentials(*name)
+	if creds == nil {
+		return ""
+	}
+
+	if creds.Err != nil {
+		return "unknown"
+	}
+
+	earliest, ok := creds.Earliest()
+	if !ok {
+		return ""
+	}
+
+	return formatExpiry(earliest.Expires, now)
+}
+
 func summarizeAuthProvider(kcUser *kubeconfig.AuthInfo) string {
 	if kcUser == nil {
 		return ""
@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/eth-p/kubesel/internal/printer"
+	"github.com/eth-p/kubesel/pkg/kubesel"
+	"github.com/spf13/cobra"
+)
+
+var userCheckCommand = cobra.Command{
+	Use:   "check",
+	Short: "Check for expired user credentials",
+	Long: `
+		Check when the credentials of every user in the kubeconfig
+		files expire, including users shadowed by a user with the
+		same name in an earlier file.
+
+		Client certificates, and tokens and OIDC id-tokens that are
+		JWTs are checked. OIDC id-tokens with a refresh token are
+		renewed automatically, so they are not reported.
+
+		Only users with credentials that have expired or will expire
+		within a day are shown, unless '--all' is used. If any have
+		expired, the command fails.
+	`,
+	Example: `
+		kubesel user check
+		kubesel user check --all
+	`,
+
+	Annotations: map[string]string{
+		annotationNoCredentialWarnings: "true",
+	},
+
+	Args: cobra.NoArgs,
+	RunE: userCheckCommandMain,
+}
+
+var UserCheckCommandOptions struct {
+	All          bool
+	OutputFormat OutputFormat
+}
+
+func init() {
+	userCommand.AddCommand(&userCheckCommand)
+	userCheckCommand.Flags().BoolVarP(
+		&UserCheckCommandOptions.All,
+		"all", "a",
+		false,
+		"show every user",
+	)
+
+	userCheckCommand.Flags().VarP(
+		&UserCheckCommandOptions.OutputFormat,
+		"output", "o",
+		"output format",
+	)
+}
+
+type userCheckInfo struct {
+	Status     string `yaml:"status" printer:"Status,order=0"`
+	Name       string `yaml:"name" printer:"Name,order=1"`
+	Credential string `yaml:"credential" printer:"Credential,order=2"`
+	Expires    string `yaml:"expires" printer:"Expires,order=3"`
+	Source     string `yaml:"source" printer:"Source,order=4"`
+}
+
+func userCheckCommandMain(cmd *cobra.Command, args []string) error {
+	opts := &UserCheckCommandOptions
+	ksel, err := Kubesel()
+	if err != nil {
+		return err
+	}
+
+	// Check the users.
+	now := time.Now()
+	expired := 0
+	var items []userCheckInfo
+	for _, creds := range ksel.CheckUserCredentials() {
+		item := userCheckInfo{
+			Name:   creds.Name,
+			Source: creds.Path,
+		}
+
+		earliest, hasExpiry := creds.Earliest()
+		switch {
+		case creds.Err != nil:
+			item.Status = "error"
+			item.Expires = creds.Err.Error()
+		case creds.IsExpired(now):
+			item.Status = "expired"
+			expired++
+		case creds.IsExpiringSoon(now):
+			item.Status = "expiring"
+		case !opts.All:
+			continue
+		case hasExpiry:
+			item.Status = "ok"
+		default:
+			item.Status = "no expiry"
+		}
+
+		if hasExpiry && creds.Err == nil {
+			item.Credential = earliest.Credential
+			item.Expires = formatExpiry(earliest.Expires, now)
+		}
+
+		items = append(items, item)
+	}
+
+	// Print a message instead of an empty table.
+	// Other formats print nothing, so they stay easy to parse.
+	if len(items) == 0 && opts.OutputFormat.IsTable() {
+		message := "no expiring credentials"
+		if opts.All {
+			message = "no users"
+		}
+
+		fmt.Fprintln(cmd.OutOrStdout(), message)
+		return nil
+	}
+
+	itemTyp, err := printer.ItemTypeOf(reflect.TypeFor[userCheckInfo]())
+	if err != nil {
+		return err
+	}
+
+	opts.OutputFormat.DefaultIfUnset()
+	itemPrinter, err := opts.OutputFormat.newPrinter(*itemTyp, cmd.OutOrStdout())
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		itemPrinter.Add(item)
+	}
+
+	itemPrinter.Close()
+	if expired > 0 {
+		return fmt.Errorf("%w: %d %s", kubesel.ErrCredentialsExpired, expired, pluralize(expired, "user", "users"))
+	}
+
+	return nil
+}
+
+func pluralize(n int, singular string, plural string) string {
+	if n == 1 {
+		return singular
+	}
+
+	return plural
+}
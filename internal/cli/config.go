@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/eth-p/kubesel/internal/fuzzy"
+	"github.com/eth-p/kubesel/pkg/kubesel"
+	"github.com/spf13/cobra"
+)
+
+// applyConfig loads the kubesel config file and uses its values as the
+// defaults for the command's flags. Flags given on the command line take
+// priority over the config file.
+func applyConfig(cmd *cobra.Command, args []string) error {
+	if _, ok := cmd.Annotations[annotationNoConfig]; ok || cmd == appliedConfigTo {
+		return nil
+	}
+
+	config, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	// The values are set without going through the FlagSet, so the flags
+	// are not marked as changed. Commands can still tell if a flag was given
+	// on the command line with cmd.Flags().Changed.
+	var errs []error
+	setFlagDefault := func(flagName string, field string, values ...string) {
+		flag := cmd.Flags().Lookup(flagName)
+		if flag == nil || flag.Changed || len(values) == 0 {
+			return
+		}
+
+		for _, value := range values {
+			if err := flag.Value.Set(value); err != nil {
+				errs = append(errs, &kubesel.ConfigFieldError{
+					Field:   field,
+					Message: fmt.Sprintf("%q is not valid for --%s", value, flagName),
+				})
+			}
+		}
+
+		flag.DefValue = flag.Value.String()
+	}
+
+	switch config.Color {
+	case "always":
+		setFlagDefault(colorFlagName, "color", "true")
+	case "never":
+		setFlagDefault(colorFlagName, "color", "false")
+	}
+
+	if config.List.Output != "" {
+		setFlagDefault("output", "list.output", config.List.Output)
+	}
+
+	setFlagDefault("add-kubeconfigs", "init.add-kubeconfigs", config.Init.AddKubeconfigs...)
+	setFlagDefault("add-kubeconfig-dirs", "init.add-kubeconfig-dirs", config.Init.AddKubeconfigDirs...)
+	setFlagDefault("add-kubeconfig-commands", "init.add-kubeconfig-commands", config.Init.AddKubeconfigCommands...)
+	if ttl := config.Init.KubeconfigCommandTTL; ttl != 0 {
+		setFlagDefault("kubeconfig-command-ttl", "init.kubeconfig-command-ttl", ttl.String())
+	}
+
+	appliedConfigTo = cmd
+	if len(errs) > 0 {
+		return &kubesel.ConfigError{Path: kubesel.DefaultConfigFile(), Errors: errs}
+	}
+
+	return nil
+}
+
+// appliedConfigTo is the command that [applyConfig] was last used on. Since
+// the values of list flags are appended to, the config is only applied once.
+var appliedConfigTo *cobra.Command
+
+// loadConfig loads the kubesel config file.
+//
+// If the config file is invalid and the command is allowed to run without it,
+// a warning is printed and the [kubesel.DefaultConfig] is used instead. Shell
+// completions also use the default config, but don't print the warning.
+func loadConfig(cmd *cobra.Command) (*kubesel.Config, error) {
+	config, err := kubesel.LoadConfig()
+	if err == nil || cmd == nil {
+		return config, err
+	}
+
+	var configErr *kubesel.ConfigError
+	if !errors.As(err, &configErr) {
+		return nil, err
+	}
+
+	switch cmd.Name() {
+	case cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+		return kubesel.DefaultConfig(), nil
+	}
+
+	if _, ok := cmd.Annotations[annotationAllowInvalidConfig]; !ok {
+		return nil, err
+	}
+
+	printConfigWarning.Do(func() {
+		errorPrinter().PrintConfigWarning(cmd.ErrOrStderr(), cmd, configErr)
+	})
+
+	return kubesel.DefaultConfig(), nil
+}
+
+// printConfigWarning makes sure the warning about an invalid config file is
+// only printed once.
+var printConfigWarning sync.Once
+
+// pickOptions returns the [fuzzy.PickOptions] for picking an item with fzf,
+// using the fzf options from the kubesel config file. If the kind is not
+// empty, items are ranked by how often and recently they were used.
+func pickOptions(query string, kind kubesel.UsageKind, aliases map[string]string) fuzzy.PickOptions {
+	opts := fuzzy.PickOptions{
+		Query:   query,
+		Aliases: aliases,
+	}
+
+	if config, err := kubesel.LoadConfig(); err == nil {
+		opts.FzfArgs = config.Fzf.Options
+	}
+
+	if kind != "" {
+		opts.Boost = frecencyBoost(kind, aliases)
+	}
+
+	return opts
+}
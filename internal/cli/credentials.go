@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/eth-p/kubesel/pkg/kubesel"
+	"github.com/spf13/cobra"
+)
+
+// printCredentialWarnings prints a warning if the credentials of the current
+// session's user have expired or will expire soon.
+//
+// This only happens when the command already loaded the kubeconfig files.
+func printCredentialWarnings(w io.Writer, cmd *cobra.Command) {
+	ksel := createdKubesel.Load()
+	if ksel == nil || !ksel.HasLoadedKubeconfigs() || cmd == nil || cmd.Hidden {
+		return
+	}
+
+	if _, ok := cmd.Annotations[annotationNoCredentialWarnings]; ok {
+		return
+	}
+
+	if warning := describeSessionCredentials(ksel, time.Now()); warning != "" {
+		errorPrinter().PrintWarning(w, cmd, warning)
+	}
+}
+
+// describeSessionCredentials describes when the credentials of the current
+// session's user expire. An empty string is returned if they aren't expiring
+// soon.
+func describeSessionCredentials(ksel *kubesel.Kubesel, now time.Time) string {
+	managedKc, err := ksel.GetManagedKubeconfig()
+	if err != nil || managedKc.GetAuthInfoName() == "" {
+		return ""
+	}
+
+	creds := ksel.GetUserCredentials(managedKc.GetAuthInfoName())
+	if creds == nil || !creds.IsExpiringSoon(now) {
+		return ""
+	}
+
+	earliest, _ := creds.Earliest()
+	return fmt.Sprintf("the %s of user %q %s",
+		earliest.Credential,
+		creds.Name,
+		describeExpiry(earliest.Expires, now),
+	)
+}
+
+// describeExpiry describes when something expires relative to now, such as
+// "expires in 3h" or "expired 2d ago".
+func describeExpiry(expires time.Time, now time.Time) string {
+	if expires.After(now) {
+		return "expires in " + shortDuration(expires.Sub(now))
+	}
+
+	return "expired " + shortDuration(now.Sub(expires)) + " ago"
+}
+
+// formatExpiry formats an expiry time as a date followed by how long until
+// it expires.
+func formatExpiry(expires time.Time, now time.Time) string {
+	return fmt.Sprintf("%s (%s)", expires.Local().Format(time.DateTime), describeExpiry(expires, now))
+}
+
+// shortDuration formats a duration with only its largest unit.
+func shortDuration(d time.Duration) string {
+	switch {
+	case d >= 48*time.Hour:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	default:
+		return "<1m"
+	}
+}
@@ -127,6 +127,13 @@ func (f *OutputFormat) DefaultIfUnset() {
 	}
 }
 
+// IsTable returns true if the items are displayed in a table. Unlike the other
+// formats, tables are only meant to be read by people rather than scripts.
+func (f *OutputFormat) IsTable() bool {
+	f.DefaultIfUnset()
+	return f.name == "table"
+}
+
 // String implements [pflag.Value].
 func (f *OutputFormat) String() string {
 	f.DefaultIfUnset()
@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"math"
+
+	"github.com/eth-p/kubesel/pkg/kubesel"
+)
+
+// frecencyBoostScale scales the frecency of items into a boost for their
+// fuzzy match scores. A boost grows logarithmically, and is capped at
+// maxFrecencyBoost, so a frequently-used item doesn't outrank items that are
+// much better matches for the query.
+const (
+	frecencyBoostScale = 8
+	maxFrecencyBoost   = 24
+)
+
+// frecencyBoost returns a function that boosts items by how often and
+// recently they were used. Aliases are boosted like the items they refer to.
+func frecencyBoost(kind kubesel.UsageKind, aliases map[string]string) func(name string) int {
+	ksel, err := Kubesel()
+	if err != nil {
+		return nil
+	}
+
+	return frecencyBoostFrom(ksel.GetFrecency(kind), aliases)
+}
+
+// frecencyBoostFrom returns a function that boosts items by their frecency
+// scores. If there are no scores, nil is returned.
+func frecencyBoostFrom(frecency map[string]float64, aliases map[string]string) func(name string) int {
+	if len(frecency) == 0 {
+		return nil
+	}
+
+	return func(name string) int {
+		if target, ok := aliases[name]; ok {
+			name = target
+		}
+
+		return min(maxFrecencyBoost, int(frecencyBoostScale*math.Log2(1+frecency[name])))
+	}
+}
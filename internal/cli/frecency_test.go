@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrecencyBoost(t *testing.T) {
+	frecency := map[string]float64{
+		"rarely-used": 1,
+		"often-used":  3,
+		"always-used": 1e6,
+	}
+
+	aliases := map[string]string{
+		"alias": "often-used",
+	}
+
+	testcases := map[string]struct {
+		Name     string
+		Expected int
+	}{
+		"Unused":                       {Name: "unused", Expected: 0},
+		"Grows logarithmically":        {Name: "rarely-used", Expected: frecencyBoostScale},
+		"Grows with use":               {Name: "often-used", Expected: 2 * frecencyBoostScale},
+		"Is capped":                    {Name: "always-used", Expected: maxFrecencyBoost},
+		"Aliases boosted like targets": {Name: "alias", Expected: 2 * frecencyBoostScale},
+	}
+
+	boost := frecencyBoostFrom(frecency, aliases)
+	require.NotNil(t, boost)
+
+	t.Parallel()
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tc.Expected, boost(tc.Name))
+		})
+	}
+}
+
+func TestFrecencyBoostWithoutUsage(t *testing.T) {
+	t.Parallel()
+	require.Nil(t, frecencyBoostFrom(nil, nil), "nothing is boosted")
+}
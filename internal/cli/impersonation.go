@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/eth-p/kubesel/pkg/kubesel"
+	"github.com/spf13/pflag"
+)
+
+// impersonationOptions are the flags for impersonating groups and extra
+// information, which are shared by the commands that impersonate a user.
+type impersonationOptions struct {
+	Groups []string
+	Extra  []string
+}
+
+// impersonationFlags adds the `--as-group` and `--as-extra` flags.
+func impersonationFlags(flags *pflag.FlagSet, opts *impersonationOptions) {
+	flags.StringArrayVar(
+		&opts.Groups,
+		"as-group",
+		nil,
+		"impersonate this group in the current shell (can be repeated)",
+	)
+
+	flags.StringArrayVar(
+		&opts.Extra,
+		"as-extra",
+		nil,
+		"extra information about the impersonated user, as key=value (can be repeated)",
+	)
+}
+
+// IsZero returns true if no groups or extra information are impersonated.
+func (o *impersonationOptions) IsZero() bool {
+	return len(o.Groups) == 0 && len(o.Extra) == 0
+}
+
+// authInfoOverrides returns the [kubesel.AuthInfoOverrides] that impersonate
+// the user with the groups and extra information from the flags.
+func (o *impersonationOptions) authInfoOverrides(as string) (kubesel.AuthInfoOverrides, error) {
+	extra, err := parseUserExtra(o.Extra)
+	if err != nil {
+		return kubesel.AuthInfoOverrides{}, err
+	}
+
+	return kubesel.AuthInfoOverrides{
+		As:          as,
+		AsGroups:    o.Groups,
+		AsUserExtra: extra,
+	}, nil
+}
+
+// parseUserExtra parses `key=value` pairs into the extra information about an
+// impersonated user. A key can be given more than once.
+func parseUserExtra(pairs []string) (map[string][]string, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+
+	extra := make(map[string][]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid extra %q: expected key=value", pair)
+		}
+
+		extra[key] = append(extra[key], value)
+	}
+
+	return extra, nil
+}
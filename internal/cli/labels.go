@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/eth-p/kubesel/pkg/kubesel"
+	"github.com/spf13/cobra"
+)
+
+// selectorFlagName is the name of the flag used to filter items by their
+// labels.
+const selectorFlagName = "selector"
+
+// LabelSelectorFlag is a [pflag.Value] for a [kubesel.LabelSelector].
+type LabelSelectorFlag struct {
+	kubesel.LabelSelector
+}
+
+func (f *LabelSelectorFlag) Set(value string) error {
+	selector, err := kubesel.ParseLabelSelector(value)
+	if err != nil {
+		return err
+	}
+
+	f.LabelSelector = append(f.LabelSelector, selector...)
+	return nil
+}
+
+func (f *LabelSelectorFlag) Type() string {
+	return "selector"
+}
+
+// addSelectorFlag adds the `--selector` flag to a command.
+func addSelectorFlag(cmd *cobra.Command, target *LabelSelectorFlag, usage string) {
+	cmd.Flags().VarP(target, selectorFlagName, "l", usage)
+}
+
+// selectorFromFlags returns the label selector from a command's
+// `--selector` flag, or an empty selector if it doesn't have one.
+func selectorFromFlags(cmd *cobra.Command) kubesel.LabelSelector {
+	flag := cmd.Flags().Lookup(selectorFlagName)
+	if flag == nil {
+		return nil
+	}
+
+	selectorFlag, ok := flag.Value.(*LabelSelectorFlag)
+	if !ok {
+		return nil
+	}
+
+	return selectorFlag.LabelSelector
+}
+
+// filterItemsBySelector returns the names of the items whose labels match
+// the selector. Aliases are filtered to the ones whose items are kept.
+//
+// Like the list command, items with labels that can't be read are skipped.
+// If cmd is not nil, a warning about them is printed.
+func filterItemsBySelector(
+	cmd *cobra.Command,
+	prop *managedProperty[any],
+	selector kubesel.LabelSelector,
+	names []string,
+	aliases map[string]string,
+) ([]string, map[string]string, error) {
+	if len(selector) == 0 {
+		return names, aliases, nil
+	}
+
+	if prop.GetItemLabels == nil {
+		return nil, nil, fmt.Errorf("%s do not have labels", prop.PropertyNamePlural)
+	}
+
+	ksel, err := Kubesel()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	matching := make([]string, 0, len(names))
+	var invalid []string
+	for _, name := range names {
+		labels, err := prop.GetItemLabels(ksel, name)
+		if err != nil {
+			invalid = append(invalid, name)
+			continue
+		}
+
+		if selector.Matches(labels) {
+			matching = append(matching, name)
+		}
+	}
+
+	if len(invalid) > 0 && cmd != nil {
+		noun := pluralize(len(invalid), prop.PropertyNameSingular, prop.PropertyNamePlural)
+		errorPrinter().PrintWarning(cmd.ErrOrStderr(), cmd, fmt.Sprintf(
+			"skipped %s with invalid labels: %s", noun, strings.Join(invalid, ", "),
+		))
+	}
+
+	matchingAliases := maps.Clone(aliases)
+	maps.DeleteFunc(matchingAliases, func(alias, target string) bool {
+		return !slices.Contains(matching, target)
+	})
+
+	return matching, matchingAliases, nil
+}
+
+// labelsColumn returns the labels of an item as shown in the `Labels`
+// column, and whether they match the selector. Items with labels that can't
+// be read only match an empty selector.
+func labelsColumn(
+	name *string,
+	getLabels func(name string) (kubesel.Labels, error),
+	selector kubesel.LabelSelector,
+) (string, bool) {
+	if name == nil {
+		return "", selector.Matches(kubesel.Labels{})
+	}
+
+	labels, err := getLabels(*name)
+	if err != nil {
+		return "(invalid)", len(selector) == 0
+	}
+
+	return labels.String(), selector.Matches(labels)
+}
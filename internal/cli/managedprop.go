@@ -8,6 +8,10 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// managedProperties are the managed properties created by
+// [createManagedPropertyCommands], by their singular name.
+var managedProperties = make(map[string]*managedProperty[any])
+
 // managedProperty describes a kubeconfig property that is managed by kubesel.
 type managedProperty[I any] struct {
 	PropertyNameSingular string
@@ -15,10 +19,44 @@ type managedProperty[I any] struct {
 	GetItemInfos         itemInfoGenerator[I]
 	GetItemNames         func() ([]string, error)
 
+	// GetItemAliases returns alternate names for items, as a map of the
+	// alias to the item name. Aliases are accepted when switching items.
+	// This is optional.
+	GetItemAliases func() (map[string]string, error)
+
+	// GetItemLabels returns the labels of an item, which are used to filter
+	// items with the `--selector` flag.
+	// This is optional.
+	GetItemLabels func(ksel *kubesel.Kubesel, name string) (kubesel.Labels, error)
+
+	// DescribeItem returns a short description of an item's info, which is
+	// shown next to the item in shell completions.
+	// This is optional.
+	DescribeItem func(info I) string
+
+	// UsageKind is the kind used to record when items are switched to.
+	// Items that were used often and recently are ranked higher when fuzzy
+	// matching. This is optional.
+	UsageKind kubesel.UsageKind
+
+	// GetActiveItem returns the name of the item currently used by the
+	// session, which is highlighted in the picker.
+	// This is optional.
+	GetActiveItem func(ksel *kubesel.Kubesel, managedKc *kubesel.ManagedKubeconfig) string
+
+	// GetItemDefinition returns the kubeconfig definition of an item, which
+	// is shown in the picker's preview. Secrets must be redacted.
+	// This is optional.
+	GetItemDefinition func(ksel *kubesel.Kubesel, name string) any
+
 	// Switch changes the active item of this managed property.
 	// (e.g. switch to a different cluster or context)
 	Switch func(ksel *kubesel.Kubesel, managedKc *kubesel.ManagedKubeconfig, target string) error
 
+	// Editor describes how to create, rename, and delete items.
+	// If nil, the editing subcommands will not be created.
+	Editor *managedPropertyEditor
+
 	// Set by createManagedPropertyCommands:
 
 	Aliases        []string
@@ -26,6 +64,13 @@ type managedProperty[I any] struct {
 }
 
 func (p *managedProperty[I]) upcast() *managedProperty[any] {
+	var describeItem func(info any) string
+	if p.DescribeItem != nil {
+		describeItem = func(info any) string {
+			return p.DescribeItem(info.(I))
+		}
+	}
+
 	return &managedProperty[any]{
 		PropertyNameSingular: p.PropertyNameSingular,
 		PropertyNamePlural:   p.PropertyNamePlural,
@@ -33,7 +78,14 @@ func (p *managedProperty[I]) upcast() *managedProperty[any] {
 		InfoStructType:       p.InfoStructType,
 		GetItemInfos:         p.GetItemInfos.upcast(),
 		GetItemNames:         p.GetItemNames,
+		GetItemAliases:       p.GetItemAliases,
+		GetItemLabels:        p.GetItemLabels,
+		DescribeItem:         describeItem,
+		UsageKind:            p.UsageKind,
+		GetActiveItem:        p.GetActiveItem,
+		GetItemDefinition:    p.GetItemDefinition,
 		Switch:               p.Switch,
+		Editor:               p.Editor,
 	}
 }
 
@@ -42,10 +94,14 @@ func (p *managedProperty[I]) upcast() *managedProperty[any] {
 //
 // The following subcommands are generated:
 //   - `kubesel list <prop>`
+//   - `kubesel <prop> create` (if the property has an editor)
+//   - `kubesel <prop> rename` (if the property has an editor)
+//   - `kubesel <prop> delete` (if the property has an editor)
 //
 // The following flags are added to the provided command:
 //   - `--list`
 //   - `--exact`
+//   - `--selector` (if the property has labels)
 func createManagedPropertyCommands[I any](cmd *cobra.Command, prop managedProperty[I]) {
 	prop.InfoStructType = reflect.TypeFor[I]()
 	if prop.Aliases == nil {
@@ -53,8 +109,10 @@ func createManagedPropertyCommands[I any](cmd *cobra.Command, prop managedProper
 	}
 
 	upProp := prop.upcast() // I -> any
+	managedProperties[prop.PropertyNameSingular] = upProp
 	createManagedPropertySwitchCommand(cmd, upProp)
 	createManagedPropertyListSubcommand(cmd, upProp)
+	createManagedPropertyEditSubcommands(cmd, upProp)
 }
 
 // createCommandNameAndAliases creates a name and aliases for a [cobra.Command].
@@ -90,11 +148,14 @@ func createCommandNameAndAliases(name string, prop *managedProperty[any]) (strin
 
 // itemInfoGenerator is a function that creates an iterator over some type.
 // This is used to get the items displayed by the `kubesel list` subcommand.
-type itemInfoGenerator[I any] func() (iter.Seq[I], error)
+//
+// Items with labels that don't match the selector are skipped. Items without
+// labels ignore it.
+type itemInfoGenerator[I any] func(selector kubesel.LabelSelector) (iter.Seq[I], error)
 
 func (g *itemInfoGenerator[I]) upcast() itemInfoGenerator[any] {
-	return func() (iter.Seq[any], error) {
-		realGenerator, err := (*g)()
+	return func(selector kubesel.LabelSelector) (iter.Seq[any], error) {
+		realGenerator, err := (*g)(selector)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,389 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/eth-p/kubesel/pkg/kubeconfig"
+	"github.com/eth-p/kubesel/pkg/kubeconfig/loader"
+	"github.com/eth-p/kubesel/pkg/kubesel"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// managedPropertyEditor describes how to edit the kubeconfig items that back
+// a managed property.
+type managedPropertyEditor struct {
+	// GetItemSources returns the provenance of the property's items.
+	GetItemSources func(ksel *kubesel.Kubesel) loader.NamedItemSources
+
+	// Create adds a new item to the kubeconfig.
+	Create func(name string, kc *kubeconfig.Config)
+
+	// CreateFlags adds the flags used by Create to the `create` subcommand.
+	CreateFlags func(flags *pflag.FlagSet)
+
+	// Rename renames an item in the kubeconfig, returning false if the
+	// kubeconfig does not contain it.
+	Rename func(from, to string, kc *kubeconfig.Config) bool
+
+	// FindReferences returns the names of the contexts which refer to an
+	// item, and ReplaceReferences changes them to refer to a renamed item.
+	// These are optional.
+	FindReferences    func(name string, kc *kubeconfig.Config) []string
+	ReplaceReferences func(from, to string, kc *kubeconfig.Config) int
+
+	// RenameActive updates and saves the managed kubeconfig after an item is
+	// renamed, if it was using the item. This is optional.
+	RenameActive func(managedKc *kubesel.ManagedKubeconfig, from, to string) error
+
+	// Delete removes an item from the kubeconfig, returning false if the
+	// kubeconfig does not contain it.
+	Delete func(name string, kc *kubeconfig.Config) bool
+
+	// IsProtected returns true if the item cannot be renamed or deleted.
+	// This is optional.
+	IsProtected func(ksel *kubesel.Kubesel, name string) bool
+}
+
+// createManagedPropertyEditSubcommands creates subcommands for editing the
+// kubeconfig items of a managed property.
+//
+// Items are always edited inside the kubeconfig file that defines them, as
+// determined by the merge [loader.Provenance]. Other kubeconfig files are only
+// changed to update the contexts referring to a renamed item.
+//
+// The following subcommands are generated:
+//   - `kubesel <prop> create`
+//   - `kubesel <prop> rename`
+//   - `kubesel <prop> delete`
+func createManagedPropertyEditSubcommands(cmd *cobra.Command, prop *managedProperty[any]) {
+	if prop.Editor == nil {
+		return
+	}
+
+	singularName := prop.PropertyNameSingular
+	nameCompletion := createManagedPropertyCompletionFunc(prop)
+
+	// kubesel <prop> create
+	createCmd := &cobra.Command{
+		Use:   "create name",
+		Short: "Create a new " + singularName,
+		Long: fmt.Sprintf(`
+			Create a new %[1]s.
+
+			The %[1]s will be added to the first kubeconfig file that
+			exists, unless a different file is specified with --file.
+		`, singularName),
+
+		Args: cobra.ExactArgs(1),
+	}
+
+	var createInFile string
+	createCmd.Flags().StringVar(&createInFile, "file", "", "kubeconfig file to add the "+singularName+" to")
+	if prop.Editor.CreateFlags != nil {
+		prop.Editor.CreateFlags(createCmd.Flags())
+	}
+
+	createCmd.RunE = func(cmd *cobra.Command, args []string) error {
+		return managedPropertyCreateMain(prop, cmd, args[0], createInFile)
+	}
+
+	// kubesel <prop> rename
+	renameCmd := &cobra.Command{
+		Use:   "rename old-name new-name",
+		Short: "Rename a " + singularName,
+		Long: fmt.Sprintf(`
+			Rename a %[1]s inside the kubeconfig file that defines it.
+
+			The %[1]s name must be an exact match. Contexts referring to
+			the %[1]s are updated in every kubeconfig file that can be
+			edited.
+		`, singularName),
+
+		Args: cobra.ExactArgs(2),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+			if len(args) > 0 || nameCompletion == nil {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+
+			return nameCompletion(cmd, args, toComplete)
+		},
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return managedPropertyRenameMain(prop, cmd, args[0], args[1])
+		},
+	}
+
+	// kubesel <prop> delete
+	deleteCmd := &cobra.Command{
+		Use:   "delete name",
+		Short: "Delete a " + singularName,
+		Long: fmt.Sprintf(`
+			Delete a %[1]s from the kubeconfig file that defines it.
+
+			The %[1]s name must be an exact match. If other kubeconfig
+			files also define a %[1]s with the same name, the next
+			one will take its place.
+		`, singularName),
+
+		Args: cobra.ExactArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+			if len(args) > 0 || nameCompletion == nil {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+
+			return nameCompletion(cmd, args, toComplete)
+		},
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return managedPropertyDeleteMain(prop, cmd, args[0])
+		},
+	}
+
+	cmd.AddCommand(createCmd, renameCmd, deleteCmd)
+}
+
+func managedPropertyCreateMain(prop *managedProperty[any], cmd *cobra.Command, name string, file string) error {
+	ksel, err := Kubesel()
+	if err != nil {
+		return err
+	}
+
+	// Ensure it doesn't already exist.
+	available, err := prop.GetItemNames()
+	if err != nil {
+		return err
+	}
+
+	if slices.Contains(available, name) {
+		return fmt.Errorf("%s already exists: %v", prop.PropertyNameSingular, name)
+	}
+
+	// Pick the file to add it to.
+	if file == "" {
+		file, err = ksel.GetKubeconfigFilePathForNewItems()
+		if err != nil {
+			return err
+		}
+	} else {
+		file = expandTilde(file)
+	}
+
+	// Add it.
+	err = ksel.EditKubeconfigFile(file, func(kc *kubeconfig.Config) error {
+		prop.Editor.Create(name, kc)
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Created %s %q in %s\n", prop.PropertyNameSingular, name, file)
+	return nil
+}
+
+func managedPropertyRenameMain(prop *managedProperty[any], cmd *cobra.Command, from string, to string) error {
+	ksel, err := Kubesel()
+	if err != nil {
+		return err
+	}
+
+	// Ensure the old name exists and the new one doesn't.
+	available, err := prop.GetItemNames()
+	if err != nil {
+		return err
+	}
+
+	if !slices.Contains(available, from) {
+		return fmt.Errorf("unknown %s: %v", prop.PropertyNameSingular, from)
+	}
+
+	if err := checkNotProtected(ksel, prop, from); err != nil {
+		return err
+	}
+
+	if slices.Contains(available, to) {
+		return fmt.Errorf("%s already exists: %v", prop.PropertyNameSingular, to)
+	}
+
+	// Rename it inside the file that defines it.
+	file, err := findDefiningFile(ksel, prop, from)
+	if err != nil {
+		return err
+	}
+
+	err = ksel.EditKubeconfigFile(file, func(kc *kubeconfig.Config) error {
+		if !prop.Editor.Rename(from, to, kc) {
+			return fmt.Errorf("%s %q is no longer in %s", prop.PropertyNameSingular, from, file)
+		}
+
+		if prop.Editor.ReplaceReferences != nil {
+			prop.Editor.ReplaceReferences(from, to, kc)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Renamed %s %q to %q in %s\n", prop.PropertyNameSingular, from, to, file)
+	if prop.Editor.FindReferences != nil && prop.Editor.ReplaceReferences != nil {
+		replaceReferencesInOtherFiles(ksel, prop, cmd, file, from, to)
+	}
+
+	// Update the current shell if it was using the renamed item.
+	// The files are reloaded first, since the overrides are applied to a
+	// copy of the renamed item.
+	if prop.Editor.RenameActive != nil {
+		if err := ksel.Reload(); err != nil {
+			return err
+		}
+
+		managedKc, err := ksel.GetManagedKubeconfig()
+		if err == nil {
+			return prop.Editor.RenameActive(managedKc, from, to)
+		}
+	}
+
+	return nil
+}
+
+func managedPropertyDeleteMain(prop *managedProperty[any], cmd *cobra.Command, name string) error {
+	ksel, err := Kubesel()
+	if err != nil {
+		return err
+	}
+
+	// Ensure it exists.
+	available, err := prop.GetItemNames()
+	if err != nil {
+		return err
+	}
+
+	if !slices.Contains(available, name) {
+		return fmt.Errorf("unknown %s: %v", prop.PropertyNameSingular, name)
+	}
+
+	if err := checkNotProtected(ksel, prop, name); err != nil {
+		return err
+	}
+
+	// Delete it from the file that defines it.
+	file, err := findDefiningFile(ksel, prop, name)
+	if err != nil {
+		return err
+	}
+
+	err = ksel.EditKubeconfigFile(file, func(kc *kubeconfig.Config) error {
+		if !prop.Editor.Delete(name, kc) {
+			return fmt.Errorf("%s %q is no longer in %s", prop.PropertyNameSingular, name, file)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	w := cmd.OutOrStdout()
+	fmt.Fprintf(w, "Deleted %s %q from %s\n", prop.PropertyNameSingular, name, file)
+
+	// Let the user know if a shadowed definition will take its place.
+	for _, shadowed := range prop.Editor.GetItemSources(ksel).ShadowedIn(name) {
+		if shadowed.Path != file {
+			fmt.Fprintf(w, "The %s %q from %s will be used instead\n", prop.PropertyNameSingular, name, shadowed.Path)
+			break
+		}
+	}
+
+	return nil
+}
+
+// checkNotProtected returns an error if the item is protected by the kubesel
+// config file.
+func checkNotProtected(ksel *kubesel.Kubesel, prop *managedProperty[any], name string) error {
+	if prop.Editor.IsProtected != nil && prop.Editor.IsProtected(ksel, name) {
+		return fmt.Errorf("%s %q is protected by the kubesel config file", prop.PropertyNameSingular, name)
+	}
+
+	return nil
+}
+
+// findDefiningFile returns the path of the kubeconfig file which defines the
+// item used by kubectl.
+func findDefiningFile(ksel *kubesel.Kubesel, prop *managedProperty[any], name string) (string, error) {
+	kc := prop.Editor.GetItemSources(ksel).DefinedBy(name)
+	if kc == nil {
+		return "", fmt.Errorf("cannot find the kubeconfig file defining %s %q", prop.PropertyNameSingular, name)
+	}
+
+	return kc.Path, nil
+}
+
+// replaceReferencesInOtherFiles updates the contexts referring to a renamed
+// item inside the kubeconfig files other than the one that defined it.
+//
+// Files that cannot be edited are left as-is, and a warning is printed for
+// each of their contexts which still refer to the old name. The error from
+// [kubesel.Kubesel.EditKubeconfigFile] already names the file.
+func replaceReferencesInOtherFiles(
+	ksel *kubesel.Kubesel,
+	prop *managedProperty[any],
+	cmd *cobra.Command,
+	definingFile string,
+	from, to string,
+) {
+	// Only files with contexts can refer to the item.
+	loaded := make(map[string]*loader.LoadedKubeconfig)
+	for _, sources := range ksel.GetProvenance().Contexts {
+		for _, kc := range sources {
+			loaded[kc.Path] = kc
+		}
+	}
+
+	for _, path := range ksel.GetKubeconfigFilePaths() {
+		kc, ok := loaded[path]
+		if !ok || path == definingFile || ksel.IsManagedKubeconfigPath(path) {
+			continue
+		}
+
+		references := prop.Editor.FindReferences(from, &kc.Config)
+		if len(references) == 0 {
+			continue
+		}
+
+		err := ksel.EditKubeconfigFile(path, func(kc *kubeconfig.Config) error {
+			prop.Editor.ReplaceReferences(from, to, kc)
+			return nil
+		})
+
+		if err != nil {
+			for _, context := range references {
+				errorPrinter().PrintWarning(cmd.ErrOrStderr(), cmd, fmt.Sprintf(
+					"context %q still refers to %s %q: %v",
+					context, prop.PropertyNameSingular, from, err,
+				))
+			}
+
+			continue
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Updated %d %s in %s\n",
+			len(references), pluralize(len(references), "context", "contexts"), path)
+	}
+}
+
+// nilIfEmpty returns a pointer to the string, or nil if the string is empty.
+// This is used to leave unset flags out of created kubeconfig items.
+func nilIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+
+	return &s
+}
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/eth-p/kubesel/internal/printer"
@@ -52,6 +53,18 @@ func createManagedPropertyListSubcommand(cmd *cobra.Command, prop *managedProper
 }
 
 func managedPropertyListSubcommandMain(prop *managedProperty[any], cmd *cobra.Command, args []string) error {
+	return runWatchable(cmd, ListCommandOptions.Watch, func(w io.Writer) error {
+		return printManagedPropertyList(prop, w)
+	})
+}
+
+// printManagedPropertyList prints the items of a managed property using the
+// printer for the `--output` flag.
+func printManagedPropertyList(prop *managedProperty[any], w io.Writer) error {
+	if len(ListCommandOptions.Selector.LabelSelector) > 0 && prop.GetItemLabels == nil {
+		return fmt.Errorf("%s do not have labels", prop.PropertyNamePlural)
+	}
+
 	itemTyp, err := printer.ItemTypeOf(prop.InfoStructType)
 	if err != nil {
 		return err
@@ -61,7 +74,7 @@ func managedPropertyListSubcommandMain(prop *managedProperty[any], cmd *cobra.Co
 	ListCommandOptions.OutputFormat.DefaultIfUnset()
 	printer, err := ListCommandOptions.OutputFormat.newPrinter(
 		*itemTyp,
-		cmd.OutOrStdout(),
+		w,
 	)
 
 	if err != nil {
@@ -69,7 +82,7 @@ func managedPropertyListSubcommandMain(prop *managedProperty[any], cmd *cobra.Co
 	}
 
 	// Start iterating the items.
-	iter, err := prop.GetItemInfos()
+	iter, err := prop.GetItemInfos(ListCommandOptions.Selector.LabelSelector)
 	if err != nil {
 		return fmt.Errorf("cannot list %s: %w", prop.PropertyNamePlural, err)
 	}
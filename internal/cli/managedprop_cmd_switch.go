@@ -26,6 +26,12 @@ func createManagedPropertySwitchCommand(cmd *cobra.Command, prop *managedPropert
 		prop.PropertyNameSingular+" must be exact match",
 	)
 
+	if prop.GetItemLabels != nil {
+		addSelectorFlag(cmd, &LabelSelectorFlag{},
+			"only pick from "+prop.PropertyNamePlural+" with matching labels",
+		)
+	}
+
 	// Command.
 	cmd.Args = cobra.RangeArgs(0, 1)
 	cmd.ValidArgsFunction = createManagedPropertyCompletionFunc(prop)
@@ -41,12 +47,22 @@ func createManagedPropertySwitchCommand(cmd *cobra.Command, prop *managedPropert
 			return err
 		}
 
-		// Get the available item names.
+		// Get the available item names and aliases.
 		available, err := prop.GetItemNames()
 		if err != nil {
 			return err
 		}
 
+		aliases, err := getItemAliases(prop)
+		if err != nil {
+			return err
+		}
+
+		available, aliases, err = filterItemsBySelector(cmd, prop, selectorFromFlags(cmd), available, aliases)
+		if err != nil {
+			return err
+		}
+
 		// Fuzzy match/pick based on the query (or lack thereof)
 		var desired string
 		query := ""
@@ -57,7 +73,9 @@ func createManagedPropertySwitchCommand(cmd *cobra.Command, prop *managedPropert
 		if mustExactMatch {
 			desired = query
 		} else {
-			desired, err = fuzzy.MatchOneOrPick(available, query)
+			opts := pickOptions(query, prop.UsageKind, aliases)
+			addPickerColumns(&opts, prop, ksel)
+			desired, err = fuzzy.MatchOneOrPick(available, opts)
 			if err != nil {
 				return err
 			}
@@ -67,12 +85,37 @@ func createManagedPropertySwitchCommand(cmd *cobra.Command, prop *managedPropert
 			return fmt.Errorf("no %s specified", prop.PropertyNameSingular)
 		}
 
+		if target, ok := aliases[desired]; ok {
+			desired = target
+		}
+
 		// Safeguard.
 		if !slices.Contains(available, desired) {
 			return fmt.Errorf("unknown %s: %v", prop.PropertyNamePlural, desired)
 		}
 
 		// Switch.
-		return prop.Switch(ksel, managedKc, desired)
+		err = prop.Switch(ksel, managedKc, desired)
+		if err != nil {
+			return err
+		}
+
+		// Remember it for ranking the items next time.
+		// This is best-effort, since the switch already happened.
+		if prop.UsageKind != "" {
+			_ = ksel.RecordUsage(prop.UsageKind, desired)
+		}
+
+		return nil
 	}
 }
+
+// getItemAliases returns the aliases of a managed property's items, or an
+// empty map if the property doesn't have aliases.
+func getItemAliases(prop *managedProperty[any]) (map[string]string, error) {
+	if prop.GetItemAliases == nil {
+		return map[string]string{}, nil
+	}
+
+	return prop.GetItemAliases()
+}
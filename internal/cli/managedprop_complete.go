@@ -2,9 +2,10 @@ package cli
 
 import (
 	"slices"
-	"strings"
 
 	"github.com/eth-p/kubesel/internal/fuzzy"
+	"github.com/eth-p/kubesel/internal/printer"
+	"github.com/eth-p/kubesel/pkg/kubesel"
 	"github.com/spf13/cobra"
 )
 
@@ -21,75 +22,144 @@ func createManagedPropertyCompletionFunc(prop *managedProperty[any]) cobra.Compl
 	}
 
 	return func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
-		items, err := getCompletionItemsFromNames(prop)
+		items, err := getCompletionItemsFromNames(prop, selectorFromFlags(cmd))
 		if err != nil {
 			return nil, cobra.ShellCompDirectiveError
 		}
 
-		// Sort the items.
-		slices.SortFunc(items, func(a, b completionItem) int {
-			return strings.Compare(a.name, b.name)
-		})
-
-		// Filter out the returned options by fuzzy matching.
-		if toComplete != "" {
-			items = filterCompletionItemsByFuzzy(items, toComplete)
-		}
-
-		// Return the matching names.
-		cobraComps := make([]cobra.Completion, len(items))
-		for i, item := range items {
-			cobraComps[i] = item.asCobraCompletion()
-		}
-
-		return cobraComps, cobra.ShellCompDirectiveNoFileComp
+		aliases, _ := getItemAliases(prop)
+		return rankCompletionItems(items, toComplete, prop.UsageKind, aliases)
 	}
 }
 
-func filterCompletionItemsByFuzzy(items []completionItem, query string) []completionItem {
-	matches := fuzzy.MatchesFunc(items, query, func(ci completionItem) string {
-		return ci.name
-	})
-
-	// Sort the matches by score.
-	slices.SortFunc(matches, func(a, b fuzzy.MatchResult[completionItem]) int {
-		if a.Score != b.Score {
-			return b.Score - a.Score
+// rankCompletionItems sorts the completion items and filters them by fuzzy
+// matching. If the kind is not empty, the items used often and recently are
+// ranked higher.
+func rankCompletionItems(
+	items []completionItem,
+	toComplete string,
+	kind kubesel.UsageKind,
+	aliases map[string]string,
+) ([]cobra.Completion, cobra.ShellCompDirective) {
+	boost := func(*completionItem) int { return 0 }
+	directive := cobra.ShellCompDirectiveNoFileComp
+	if kind != "" {
+		if frecencyBoost := frecencyBoost(kind, aliases); frecencyBoost != nil {
+			boost = func(ci *completionItem) int { return frecencyBoost(ci.name) }
+			directive |= cobra.ShellCompDirectiveKeepOrder
 		}
+	}
 
-		return strings.Compare(a.Item.name, b.Item.name)
+	// Sort the items.
+	slices.SortFunc(items, func(a, b completionItem) int {
+		return fuzzy.CompareBoostThenName(boost(&a), boost(&b), a.name, b.name)
 	})
 
-	// Return the original completion items.
-	result := make([]completionItem, len(matches))
-	for i, match := range matches {
-		result[i] = match.Item
+	// Filter out the returned options by fuzzy matching.
+	if toComplete != "" {
+		items = filterCompletionItemsByFuzzy(items, toComplete, boost)
+	}
+
+	// Return the matching names.
+	cobraComps := make([]cobra.Completion, len(items))
+	for i, item := range items {
+		cobraComps[i] = item.asCobraCompletion()
 	}
 
-	return result
+	return cobraComps, directive
+}
+
+// filterCompletionItemsByFuzzy returns the completion items matching the
+// query, sorted by their fuzzy match score plus their boost.
+func filterCompletionItemsByFuzzy(items []completionItem, query string, boost func(*completionItem) int) []completionItem {
+	return fuzzy.SortedMatchesFunc(items, query,
+		func(ci *completionItem) string { return ci.name },
+		boost,
+		func(a, b fuzzy.MatchResult[completionItem]) int {
+			return fuzzy.CompareBoostThenName(a.Score, b.Score, a.Item.name, b.Item.name)
+		},
+	)
 }
 
 type completionItem struct {
-	name string
-	// TODO: descriptions
+	name        string
+	description string
 }
 
 func (c completionItem) asCobraCompletion() string {
-	return c.name
+	if c.description == "" {
+		return c.name
+	}
+
+	return cobra.CompletionWithDesc(c.name, c.description)
 }
 
 // getCompletionItemsFromNames returns completion items by using the
 // [managedProperty.GetItemNames] function to fetch the list of valid names.
-func getCompletionItemsFromNames(prop *managedProperty[any]) ([]completionItem, error) {
+// The [managedProperty.GetItemAliases] are included too. Only items with
+// labels matching the selector are returned.
+func getCompletionItemsFromNames(prop *managedProperty[any], selector kubesel.LabelSelector) ([]completionItem, error) {
 	names, err := prop.GetItemNames()
 	if err != nil {
 		return nil, err
 	}
 
-	completions := make([]completionItem, len(names))
-	for i, name := range names {
-		completions[i].name = name
+	aliases, err := getItemAliases(prop)
+	if err != nil {
+		return nil, err
+	}
+
+	names, aliases, err = filterItemsBySelector(nil, prop, selector, names, aliases)
+	if err != nil {
+		return nil, err
+	}
+
+	descriptions := getItemDescriptions(prop)
+	completions := make([]completionItem, 0, len(names)+len(aliases))
+	for _, name := range names {
+		completions = append(completions, completionItem{
+			name:        name,
+			description: descriptions[name],
+		})
+	}
+
+	for alias, target := range aliases {
+		completions = append(completions, completionItem{
+			name:        alias,
+			description: "alias for " + target,
+		})
 	}
 
 	return completions, nil
 }
+
+// getItemDescriptions returns the descriptions of a managed property's
+// items, by their name. This uses the [managedProperty.DescribeItem]
+// function on the items from [managedProperty.GetItemInfos].
+//
+// Descriptions are only nice-to-have, so nothing is returned if they can't
+// be created.
+func getItemDescriptions(prop *managedProperty[any]) map[string]string {
+	if prop.DescribeItem == nil || prop.GetItemInfos == nil {
+		return nil
+	}
+
+	itemTyp, err := printer.ItemTypeOf(prop.InfoStructType)
+	if err != nil {
+		return nil
+	}
+
+	iter, err := prop.GetItemInfos(nil)
+	if err != nil {
+		return nil
+	}
+
+	// The first column is the item's name.
+	nameField := itemTyp.Fields[0]
+	descriptions := make(map[string]string)
+	for info := range iter {
+		descriptions[nameField.Format(info)] = prop.DescribeItem(info)
+	}
+
+	return descriptions
+}
@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"os"
+	"strings"
+
+	"al.essio.dev/pkg/shellescape"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/eth-p/kubesel/internal/fuzzy"
+	"github.com/eth-p/kubesel/internal/printer"
+	"github.com/eth-p/kubesel/pkg/kubesel"
+)
+
+const (
+	pickerActiveMarker   = "* "
+	pickerInactiveMarker = "  "
+)
+
+// addPickerColumns changes the picker to show items with the same columns
+// as `kubesel list`, and to preview the highlighted item with the hidden
+// `kubesel __preview` command. The item currently used by the session is
+// marked and highlighted.
+//
+// The columns are only found if the picker is opened. If they can't be
+// determined, the items are shown as-is.
+func addPickerColumns(opts *fuzzy.PickOptions, prop *managedProperty[any], ksel *kubesel.Kubesel) {
+	if prop == nil || prop.GetItemInfos == nil {
+		return
+	}
+
+	opts.Columns = func() (string, map[string]string) {
+		return pickerColumns(prop, ksel)
+	}
+
+	// The preview is run by fzf while kubesel is still running, so the
+	// path of the executable can be used. The name kubesel was run as
+	// might not be something fzf's shell can find.
+	executable, err := os.Executable()
+	if err != nil {
+		return
+	}
+
+	// The preview isn't printed to a terminal, so colors need to be
+	// enabled explicitly.
+	previewCmd := []string{executable, internalPreviewCommand.Name(), prop.PropertyNameSingular}
+	if GlobalOptions.Color {
+		previewCmd = append(previewCmd, "--"+colorFlagName+"=true")
+	}
+
+	opts.Preview = shellescape.QuoteCommand(previewCmd) + " {1}"
+}
+
+// pickerColumns returns the header and the line for each item of a managed
+// property, formatted like the `kubesel list` table.
+func pickerColumns(prop *managedProperty[any], ksel *kubesel.Kubesel) (string, map[string]string) {
+	itemTyp, err := printer.ItemTypeOf(prop.InfoStructType)
+	if err != nil {
+		return "", nil
+	}
+
+	iter, err := prop.GetItemInfos(nil)
+	if err != nil {
+		return "", nil
+	}
+
+	// Format the items as rows.
+	var infos []any
+	for info := range iter {
+		infos = append(infos, info)
+	}
+
+	tableOpts := printer.TableOptions{
+		ColumnSeparator: "  ",
+		HeaderTransform: strings.ToUpper,
+	}
+
+	if GlobalOptions.Color {
+		tableOpts.HeaderColor = ansi.SGR(ansi.BoldAttr)
+	}
+
+	header, rows, err := printer.Rows(*itemTyp, infos, tableOpts)
+	if err != nil {
+		return "", nil
+	}
+
+	// Find the active item.
+	var active string
+	if prop.GetActiveItem != nil {
+		if managedKc, err := ksel.GetManagedKubeconfig(); err == nil {
+			active = prop.GetActiveItem(ksel, managedKc)
+		}
+	}
+
+	// The first column is the item's name.
+	nameField := itemTyp.Fields[0]
+	lines := make(map[string]string, len(rows))
+	for i, row := range rows {
+		name := nameField.Format(infos[i])
+		if name == "" || name != active {
+			lines[name] = pickerInactiveMarker + row
+			continue
+		}
+
+		line := pickerActiveMarker + row
+		if GlobalOptions.Color {
+			line = printer.ApplyColor(ansi.SGR(ansi.BoldAttr, ansi.GreenForegroundColorAttr), line)
+		}
+
+		lines[name] = line
+	}
+
+	return pickerInactiveMarker + header, lines
+}
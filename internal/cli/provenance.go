@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"strings"
+
+	"github.com/eth-p/kubesel/pkg/kubeconfig/loader"
+)
+
+// sourceOf returns the path of the kubeconfig file which defined the named
+// item, or nil if it is unknown.
+func sourceOf(sources loader.NamedItemSources, name *string) *string {
+	if name == nil {
+		return nil
+	}
+
+	kc := sources.DefinedBy(*name)
+	if kc == nil {
+		return nil
+	}
+
+	return &kc.Path
+}
+
+// shadowedSourcesOf returns a comma-separated list of kubeconfig files whose
+// definitions of the named item were shadowed by an earlier file.
+func shadowedSourcesOf(sources loader.NamedItemSources, name *string) string {
+	if name == nil {
+		return ""
+	}
+
+	shadowed := sources.ShadowedIn(*name)
+	paths := make([]string, len(shadowed))
+	for i, kc := range shadowed {
+		paths[i] = kc.Path
+	}
+
+	return strings.Join(paths, ", ")
+}
@@ -20,6 +20,9 @@ func Run(args []string) (int, error) {
 	cmd, err := RootCommand.ExecuteC()
 	defer gcWait.Wait()
 
+	updateSourcesFile()
+	printKubeconfigWarnings(RootCommand.ErrOrStderr(), cmd)
+	printCredentialWarnings(RootCommand.ErrOrStderr(), cmd)
 	if err != nil {
 		if errors.Is(err, fuzzy.ErrUserCancelled) {
 			return ExitCodeCancelled, err
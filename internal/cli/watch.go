@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/spf13/cobra"
+)
+
+// runWatchable prints the output of a command.
+//
+// If watch is true, the output is printed again whenever the kubeconfig
+// files change. When printing to a terminal, the screen is cleared first so
+// the output is redrawn in place. This does not return until kubesel is
+// stopped or the kubeconfig files can no longer be watched.
+func runWatchable(cmd *cobra.Command, watch bool, render func(w io.Writer) error) error {
+	out := cmd.OutOrStdout()
+	if !watch {
+		return render(out)
+	}
+
+	ksel, err := Kubesel()
+	if err != nil {
+		return err
+	}
+
+	redraw := func() {
+		var buf bytes.Buffer
+		if err := render(&buf); err != nil {
+			errorPrinter().PrintCommandError(&buf, cmd, err)
+		}
+
+		printKubeconfigWarnings(&buf, cmd)
+
+		if GlobalOptions.OutputIsTTY {
+			_, _ = io.WriteString(out, ansi.CursorHomePosition+ansi.EraseEntireScreen)
+		}
+
+		_, _ = out.Write(buf.Bytes())
+	}
+
+	redraw()
+	return ksel.Watch(cmd.Context(), redraw)
+}
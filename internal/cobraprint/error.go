@@ -7,6 +7,7 @@ import (
 
 	"github.com/eth-p/kubesel/internal/cobraerr"
 	tc "github.com/eth-p/kubesel/internal/textcomponent"
+	"github.com/eth-p/kubesel/pkg/kubeconfig/loader"
 	"github.com/eth-p/kubesel/pkg/kubesel"
 	"github.com/spf13/cobra"
 )
@@ -18,6 +19,7 @@ type ErrorPrinterOptions struct {
 	ErrorCommandColor string
 	ErrorTextColor    string
 	TipColor          string
+	WarningColor      string
 }
 
 // ErrorPrinter is a utility for pretty-printing the error returned by
@@ -52,6 +54,81 @@ func (p *ErrorPrinter) PrintCommandError(w io.Writer, cmd *cobra.Command, err er
 	_, _ = io.WriteString(w, renderer.String())
 }
 
+// PrintKubeconfigWarnings prints a warning about kubeconfig files that could
+// not be loaded. Nothing is printed if there are no errors.
+func (p *ErrorPrinter) PrintKubeconfigWarnings(w io.Writer, cmd *cobra.Command, loadErrs []*loader.LoadError) {
+	if len(loadErrs) == 0 {
+		return
+	}
+
+	var root tc.Sequence
+	print := errorPrintContext{
+		opts:   &p.opts,
+		cmd:    cmd,
+		output: &root,
+	}
+
+	print.appendCommandName() // `kubesel subcmd: `
+	print.output.Append(&tc.Text{
+		Color: p.opts.WarningColor,
+		Text:  fmt.Sprintf("warning: %s\n", describeKubeconfigLoadErrors(loadErrs)),
+	})
+
+	print.withBlockquote(p.opts.WarningColor, "").
+		appendKubeconfigLoadErrors(loadErrs)
+
+	// Render the text components.
+	renderer := tc.NewRenderer()
+	renderer.Render(print.output)
+	_, _ = io.WriteString(w, renderer.String())
+}
+
+// PrintConfigWarning prints a warning about an invalid kubesel config file
+// which is being ignored.
+func (p *ErrorPrinter) PrintConfigWarning(w io.Writer, cmd *cobra.Command, configErr *kubesel.ConfigError) {
+	var root tc.Sequence
+	print := errorPrintContext{
+		opts:   &p.opts,
+		cmd:    cmd,
+		output: &root,
+	}
+
+	print.appendCommandName() // `kubesel subcmd: `
+	print.output.Append(&tc.Text{
+		Color: p.opts.WarningColor,
+		Text:  "warning: invalid config file, using the defaults\n",
+	})
+
+	print.withBlockquote(p.opts.WarningColor, "").
+		appendFileErrors(configErr.Path, configErr.Errors)
+
+	// Render the text components.
+	renderer := tc.NewRenderer()
+	renderer.Render(print.output)
+	_, _ = io.WriteString(w, renderer.String())
+}
+
+// PrintWarning prints a single-line warning.
+func (p *ErrorPrinter) PrintWarning(w io.Writer, cmd *cobra.Command, message string) {
+	var root tc.Sequence
+	print := errorPrintContext{
+		opts:   &p.opts,
+		cmd:    cmd,
+		output: &root,
+	}
+
+	print.appendCommandName() // `kubesel subcmd: `
+	print.output.Append(&tc.Text{
+		Color: p.opts.WarningColor,
+		Text:  fmt.Sprintf("warning: %s\n", message),
+	})
+
+	// Render the text components.
+	renderer := tc.NewRenderer()
+	renderer.Render(print.output)
+	_, _ = io.WriteString(w, renderer.String())
+}
+
 // errorPrintContext contains the context of an error printer.
 //
 // The context may be derived to do things such as changing the
@@ -154,6 +231,47 @@ func (p errorPrintContext) appendError() {
 		return
 	}
 
+	if errors.Is(p.err, kubesel.ErrCredentialsExpired) {
+		p.appendErrorText(p.err.Error() + "\n")
+		return
+	}
+
+	var configErr *kubesel.ConfigError
+	if errors.As(p.err, &configErr) {
+		p.appendErrorText("invalid config file\n")
+		p.withBlockquote(p.opts.ErrorTextColor, "").
+			appendFileErrors(configErr.Path, configErr.Errors)
+		return
+	}
+
+	if errors.Is(p.err, kubesel.ErrCannotOverrideUser) || errors.Is(p.err, kubesel.ErrReservedName) {
+		p.appendErrorText("cannot change the session\n")
+		p.withBlockquote(p.opts.ErrorTextColor, "").
+			appendText(p.err.Error())
+		return
+	}
+
+	var stateErr *kubesel.StateError
+	if errors.As(p.err, &stateErr) {
+		p.appendErrorText("cannot change the session\n")
+		quote := p.withBlockquote(p.opts.ErrorTextColor, "")
+		for i, err := range stateErr.Errors {
+			if i > 0 {
+				quote.output.Append(tc.Newline)
+			}
+
+			quote.appendText(err.Error())
+		}
+		return
+	}
+
+	if loadErrs := findKubeconfigLoadErrors(p.err); len(loadErrs) > 0 {
+		p.appendErrorText(describeKubeconfigLoadErrors(loadErrs) + "\n")
+		p.withBlockquote(p.opts.ErrorTextColor, "").
+			appendKubeconfigLoadErrors(loadErrs)
+		return
+	}
+
 	// Unknown error.
 	p.appendErrorText("unexpected error\n")
 	p.withBlockquote(p.opts.ErrorTextColor, "").
@@ -208,6 +326,32 @@ func (p errorPrintContext) appendUnknownCommandError(err *cobraerr.UnknownComman
 	}
 }
 
+func (p errorPrintContext) appendKubeconfigLoadErrors(loadErrs []*loader.LoadError) {
+	for _, loadErr := range loadErrs {
+		p.appendFileErrors(loadErr.Path, loadErr.Errors)
+	}
+}
+
+// appendFileErrors appends the path of a file, followed by its errors
+// indented below it.
+func (p errorPrintContext) appendFileErrors(path string, errs []error) {
+	p.output.Append(
+		&tc.Text{Text: path},
+		tc.Newline,
+	)
+
+	indented := p.withIndent()
+	for i, err := range errs {
+		if i > 0 {
+			indented.output.Append(tc.Newline)
+		}
+
+		indented.output.Append(&tc.Text{Text: err.Error()})
+	}
+
+	p.output.Append(tc.Newline)
+}
+
 func (p errorPrintContext) appendCommandSuggestions(suggestions []string) {
 	for _, suggestion := range suggestions {
 		p.output.Append(
@@ -227,3 +371,33 @@ func (p errorPrintContext) appendErrorText(text string) {
 		Text:  text,
 	})
 }
+
+// describeKubeconfigLoadErrors returns a summary of the kubeconfig files that
+// could not be loaded.
+func describeKubeconfigLoadErrors(loadErrs []*loader.LoadError) string {
+	if len(loadErrs) == 1 {
+		return "could not load a kubeconfig file"
+	}
+
+	return fmt.Sprintf("could not load %d kubeconfig files", len(loadErrs))
+}
+
+// findKubeconfigLoadErrors returns all the [loader.LoadError] instances
+// inside the error tree.
+func findKubeconfigLoadErrors(err error) []*loader.LoadError {
+	if loadErr, ok := err.(*loader.LoadError); ok {
+		return []*loader.LoadError{loadErr}
+	}
+
+	var loadErrs []*loader.LoadError
+	switch wrapper := err.(type) {
+	case interface{ Unwrap() error }:
+		loadErrs = findKubeconfigLoadErrors(wrapper.Unwrap())
+	case interface{ Unwrap() []error }:
+		for _, child := range wrapper.Unwrap() {
+			loadErrs = append(loadErrs, findKubeconfigLoadErrors(child)...)
+		}
+	}
+
+	return loadErrs
+}
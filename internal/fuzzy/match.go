@@ -2,7 +2,9 @@ package fuzzy
 
 import (
 	"errors"
+	"maps"
 	"slices"
+	"strings"
 
 	"github.com/junegunn/fzf/src/algo"
 	"github.com/junegunn/fzf/src/util"
@@ -70,18 +72,45 @@ func StringMatches(items []string, query string) []string {
 	return results
 }
 
-func MatchOneOrPick(items []string, query string) (string, error) {
-	slices.Sort(items)
+// MatchOneOrPick returns the item matching the query in the [PickOptions].
+// If there is no query or it fuzzily matches multiple items, a fzf TUI is
+// opened for the user to pick one of them.
+//
+// The picker lists items with the highest [PickOptions.Boost] first, and
+// items with the same boost by name. If the query matches one of the
+// [PickOptions.Aliases], the item it refers to is used instead.
+func MatchOneOrPick(items []string, opts PickOptions) (string, error) {
+	boost := func(item *string) int { return 0 }
+	if opts.Boost != nil {
+		boost = func(item *string) int { return opts.Boost(*item) }
+	}
+
+	slices.SortStableFunc(items, func(a, b string) int {
+		return CompareBoostThenName(boost(&a), boost(&b), a, b)
+	})
 
+	query := opts.Query
 	if query != "" {
 		// Exact match?
-		_, found := slices.BinarySearch(items, query)
-		if found {
+		if slices.Contains(items, query) {
 			return query, nil
 		}
 
+		if target, ok := opts.Aliases[query]; ok {
+			return target, nil
+		}
+
 		// Fuzzy match?
-		items = StringMatches(items, query)
+		choices := slices.Concat(items, slices.Collect(maps.Keys(opts.Aliases)))
+		matches := SortedMatchesFunc(choices, query,
+			func(s *string) string { return *s },
+			boost,
+			func(a, b MatchResult[string]) int {
+				return CompareBoostThenName(a.Score, b.Score, a.Item, b.Item)
+			},
+		)
+
+		items = resolveAliases(matches, opts.Aliases)
 		switch len(items) {
 		case 0:
 			return "", ErrNoMatch
@@ -92,20 +121,44 @@ func MatchOneOrPick(items []string, query string) (string, error) {
 	}
 
 	// No query or more than 1 item. Use fzf TUI as a picker.
-	pickOpts := PickOptions{
-		Query: query,
+	// Ties in fzf's scores are broken by the order the items are in.
+	if opts.Boost != nil {
+		opts.FzfArgs = slices.Concat([]string{"--tiebreak=index"}, opts.FzfArgs)
+	}
+
+	return Pick(items, &opts)
+}
+
+// resolveAliases replaces aliases with the items they refer to, keeping only
+// the first occurrence of each item.
+func resolveAliases(items []string, aliases map[string]string) []string {
+	seen := make(map[string]bool, len(items))
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		if target, ok := aliases[item]; ok {
+			item = target
+		}
+
+		if !seen[item] {
+			seen[item] = true
+			result = append(result, item)
+		}
 	}
 
-	return Pick(items, &pickOpts)
+	return result
 }
 
 // SortedMatchesFunc runs a fuzzy find query against a slice, returning the
 // items which can be matched by the query. The returned slice is sorted by
 // score.
+//
+// If getBoost is not nil, the value it returns for an item is added to the
+// item's score before sorting.
 func SortedMatchesFunc[T any](
 	items []T,
 	query string,
 	getName func(*T) string,
+	getBoost func(*T) int,
 	compare func(a, b MatchResult[T]) int,
 ) []T {
 	var matches []MatchResult[T]
@@ -129,9 +182,14 @@ func SortedMatchesFunc[T any](
 			continue
 		}
 
+		score := match.Score
+		if getBoost != nil {
+			score += getBoost(&item)
+		}
+
 		matches = append(matches, MatchResult[T]{
 			Item:  item,
-			Score: match.Score,
+			Score: score,
 		})
 	}
 
@@ -146,3 +204,13 @@ func SortedMatchesFunc[T any](
 
 	return result
 }
+
+// CompareBoostThenName compares two items by their boost or score (highest
+// first), then by their names.
+func CompareBoostThenName(boostA, boostB int, nameA, nameB string) int {
+	if boostA != boostB {
+		return boostB - boostA
+	}
+
+	return strings.Compare(nameA, nameB)
+}
@@ -0,0 +1,137 @@
+package fuzzy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortedMatchesFuncBoost(t *testing.T) {
+	testcases := map[string]struct {
+		Items    []string
+		Query    string
+		Boost    map[string]int
+		Expected []string
+	}{
+		"Ties are broken by name": {
+			Items:    []string{"prod-b", "prod-c", "prod-a"},
+			Query:    "prod",
+			Expected: []string{"prod-a", "prod-b", "prod-c"},
+		},
+		"Boosted items come first": {
+			Items:    []string{"prod-b", "prod-c", "prod-a"},
+			Query:    "prod",
+			Boost:    map[string]int{"prod-c": 10, "prod-b": 5},
+			Expected: []string{"prod-c", "prod-b", "prod-a"},
+		},
+		"Equally boosted items are sorted by name": {
+			Items:    []string{"prod-b", "prod-c", "prod-a"},
+			Query:    "prod",
+			Boost:    map[string]int{"prod-c": 5, "prod-b": 5},
+			Expected: []string{"prod-b", "prod-c", "prod-a"},
+		},
+		"Boosted items must still match": {
+			Items:    []string{"prod", "staging"},
+			Query:    "prod",
+			Boost:    map[string]int{"staging": 100},
+			Expected: []string{"prod"},
+		},
+	}
+
+	t.Parallel()
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			actual := SortedMatchesFunc(tc.Items, tc.Query,
+				func(s *string) string { return *s },
+				func(s *string) int { return tc.Boost[*s] },
+				func(a, b MatchResult[string]) int {
+					return CompareBoostThenName(a.Score, b.Score, a.Item, b.Item)
+				},
+			)
+
+			require.Equal(t, tc.Expected, actual)
+		})
+	}
+}
+
+func TestMatchOneOrPick(t *testing.T) {
+	testcases := map[string]struct {
+		Items         []string
+		Query         string
+		Aliases       map[string]string
+		Boost         map[string]int
+		Expected      string
+		ExpectedError error
+	}{
+		"Exact match is preferred over fuzzy matches": {
+			Items:    []string{"prod-admin", "prod"},
+			Query:    "prod",
+			Boost:    map[string]int{"prod-admin": 100},
+			Expected: "prod",
+		},
+		"Exact alias": {
+			Items:    []string{"prod-admin", "prod"},
+			Query:    "pa",
+			Aliases:  map[string]string{"pa": "prod-admin"},
+			Expected: "prod-admin",
+		},
+		"Alias and its target are one match": {
+			Items:    []string{"production", "staging"},
+			Query:    "prd",
+			Aliases:  map[string]string{"prod": "production"},
+			Expected: "production",
+		},
+		"Single fuzzy match": {
+			Items:    []string{"production", "staging"},
+			Query:    "stg",
+			Expected: "staging",
+		},
+		"No match": {
+			Items:         []string{"production", "staging"},
+			Query:         "dev",
+			Boost:         map[string]int{"staging": 100},
+			ExpectedError: ErrNoMatch,
+		},
+	}
+
+	t.Parallel()
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := MatchOneOrPick(tc.Items, PickOptions{
+				Query:   tc.Query,
+				Aliases: tc.Aliases,
+				Boost:   func(name string) int { return tc.Boost[name] },
+			})
+
+			require.ErrorIs(t, err, tc.ExpectedError)
+			require.Equal(t, tc.Expected, actual)
+		})
+	}
+}
+
+func TestCompareBoostThenName(t *testing.T) {
+	testcases := map[string]struct {
+		BoostA, BoostB int
+		NameA, NameB   string
+		Expected       int
+	}{
+		"Higher boost first":  {BoostA: 2, BoostB: 1, NameA: "b", NameB: "a", Expected: -1},
+		"Lower boost last":    {BoostA: 1, BoostB: 2, NameA: "a", NameB: "b", Expected: 1},
+		"Same boost by name":  {BoostA: 1, BoostB: 1, NameA: "a", NameB: "b", Expected: -1},
+		"Same boost and name": {BoostA: 1, BoostB: 1, NameA: "a", NameB: "a", Expected: 0},
+	}
+
+	t.Parallel()
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			actual := CompareBoostThenName(tc.BoostA, tc.BoostB, tc.NameA, tc.NameB)
+			require.Equal(t, tc.Expected, max(-1, min(1, actual)))
+		})
+	}
+}
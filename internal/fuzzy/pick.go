@@ -3,6 +3,7 @@ package fuzzy
 import (
 	"errors"
 	"fmt"
+	"strings"
 
 	fzf "github.com/junegunn/fzf/src"
 )
@@ -11,13 +12,64 @@ var ErrUserCancelled = errors.New("user cancelled")
 
 type PickOptions struct {
 	Query string
+
+	// FzfArgs are extra command-line options for fzf.
+	FzfArgs []string
+
+	// Boost returns an amount added to an item's fuzzy match score, used to
+	// rank items that the user prefers higher than the others.
+	// This is optional.
+	Boost func(item string) int
+
+	// Aliases are alternate names for items, as a map of the alias to the
+	// item. Aliases can be matched by the query, but only the items they
+	// refer to are picked.
+	Aliases map[string]string
+
+	// Columns returns the text shown above the items in the picker, and the
+	// lines shown for each item in place of the item itself. This may contain
+	// ANSI colors. Items without a line are shown as-is.
+	// This is optional, and only called if the picker is opened.
+	Columns func() (header string, lines map[string]string)
+
+	// Preview is a command run to preview the highlighted item, where "{1}"
+	// is replaced with the quoted item. It is run with the user's shell.
+	Preview string
 }
 
 // Pick opens a fzf TUI for the user pick a single item out of a list.
 func Pick(items []string, opts *PickOptions) (string, error) {
-	fzfOpts, err := fzf.ParseOptions(true, []string{})
+	if opts == nil {
+		opts = &PickOptions{}
+	}
+
+	var header string
+	var lines map[string]string
+	if opts.Columns != nil {
+		header, lines = opts.Columns()
+	}
+
+	// The user's fzf options come last so they can override kubesel's.
+	var fzfArgs []string
+	if lines != nil {
+		// Each item is given to fzf as "<item>\t<line>", but only the line
+		// is shown and only the item is printed.
+		fzfArgs = append(fzfArgs, "--ansi", "--delimiter=\t", "--with-nth=2..", "--accept-nth=1")
+	}
+
+	if header != "" {
+		fzfArgs = append(fzfArgs, "--header="+header)
+	}
+
+	if opts.Preview != "" {
+		fzfArgs = append(fzfArgs, "--preview="+opts.Preview, "--preview-window=down,wrap")
+	}
+
+	fzfArgs = append(fzfArgs, opts.FzfArgs...)
+
+	fzfOpts, err := fzf.ParseOptions(true, fzfArgs)
 	if err != nil {
-		return "", err
+		return "", fmt.Errorf("invalid fzf options: %w", err)
 	}
 
 	// Create input channel to send data to fzf.
@@ -28,9 +80,7 @@ func Pick(items []string, opts *PickOptions) (string, error) {
 	fzfOpts.ForceTtyIn = true
 	fzfOpts.ClearOnExit = true
 
-	if opts != nil {
-		fzfOpts.Query = opts.Query
-	}
+	fzfOpts.Query = opts.Query
 
 	// Set fzf input/output.
 	fzfOpts.Input = inputCh
@@ -41,6 +91,15 @@ func Pick(items []string, opts *PickOptions) (string, error) {
 	// Start fzf and wait for it to finish.
 	go func() {
 		for _, item := range items {
+			if lines != nil {
+				line, ok := lines[item]
+				if !ok {
+					line = item
+				}
+
+				item = item + "\t" + line
+			}
+
 			inputCh <- item
 		}
 
@@ -56,5 +115,11 @@ func Pick(items []string, opts *PickOptions) (string, error) {
 		return "", fmt.Errorf("cannot open fzf: %w", err)
 	}
 
+	// Only the item is wanted, not the line shown for it.
+	// The `--accept-nth` option doesn't apply to every fzf mode.
+	if lines != nil {
+		result, _, _ = strings.Cut(result, "\t")
+	}
+
 	return result, nil
 }
@@ -0,0 +1,39 @@
+// Package jsonnum converts numbers decoded by [encoding/json] into the same
+// types that YAML would have decoded them as.
+//
+// When decoding into an `any`, [encoding/json] uses float64 for all numbers.
+// YAML uses int for integers, which is what the rest of kubesel expects.
+// Decoding with [json.Decoder.UseNumber] and passing the result to [Normalize]
+// gives the same result as YAML.
+package jsonnum
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// Normalize replaces any [json.Number] inside an untyped value with an int or
+// float64. Maps and slices are modified in place.
+func Normalize(value any) any {
+	switch value := value.(type) {
+	case json.Number:
+		if i, err := strconv.Atoi(value.String()); err == nil {
+			return i
+		}
+
+		f, _ := value.Float64()
+		return f
+
+	case map[string]any:
+		for k, v := range value {
+			value[k] = Normalize(v)
+		}
+
+	case []any:
+		for i, v := range value {
+			value[i] = Normalize(v)
+		}
+	}
+
+	return value
+}
@@ -0,0 +1,25 @@
+package jsonnum
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	t.Parallel()
+
+	decoder := json.NewDecoder(bytes.NewReader([]byte(`{"int": 1, "float": 1.5, "list": [2, {"nested": 3}], "string": "4"}`)))
+	decoder.UseNumber()
+
+	var actual any
+	require.NoError(t, decoder.Decode(&actual))
+	require.Equal(t, map[string]any{
+		"int":    1,
+		"float":  1.5,
+		"list":   []any{2, map[string]any{"nested": 3}},
+		"string": "4",
+	}, Normalize(actual))
+}
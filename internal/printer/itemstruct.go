@@ -120,6 +120,12 @@ func applyPrinterFieldTagOption(target *ItemStructField, optName string, optVal
 	return nil
 }
 
+// Format returns the field's value in an item, formatted as a string.
+func (f *ItemStructField) Format(item any) string {
+	value := reflect.ValueOf(item).FieldByIndex(f.ReflectFieldIndex)
+	return f.ReflectFormatter(value).value
+}
+
 // ItemStructFieldList is a list of [ItemStructField] objects.
 type ItemStructFieldList []ItemStructField
 
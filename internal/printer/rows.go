@@ -0,0 +1,68 @@
+package printer
+
+import (
+	"strings"
+)
+
+// Rows formats items as rows of aligned columns, without printing them.
+// The rows are returned in the same order as the items, along with a header
+// row containing the column names.
+//
+// Only the column, header, and wide options of the [TableOptions] are used.
+func Rows(items ItemType, values []any, opts TableOptions) (string, []string, error) {
+	fields, err := tableFields(items, opts)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if opts.ColumnSeparator == "" {
+		opts.ColumnSeparator = " "
+	}
+
+	// Format the cells and calculate the column widths.
+	headers := make([]string, len(fields))
+	widths := make([]int, len(fields))
+	for col, field := range fields {
+		headers[col] = field.Name
+		if opts.HeaderTransform != nil {
+			headers[col] = opts.HeaderTransform(headers[col])
+		}
+
+		widths[col] = len(headers[col])
+	}
+
+	cells := make([][]string, len(values))
+	for row, value := range values {
+		cells[row] = make([]string, len(fields))
+		for col, field := range fields {
+			cell := field.Format(value)
+			cells[row][col] = cell
+			widths[col] = max(widths[col], len(cell))
+		}
+	}
+
+	// Join the cells into rows.
+	joinRow := func(row []string) string {
+		var sb strings.Builder
+		for col, cell := range row {
+			if col > 0 {
+				sb.WriteString(opts.ColumnSeparator)
+			}
+
+			sb.WriteString(cell)
+			if col < len(row)-1 {
+				sb.WriteString(strings.Repeat(" ", widths[col]-len(cell)))
+			}
+		}
+
+		return sb.String()
+	}
+
+	rows := make([]string, len(cells))
+	for i, row := range cells {
+		rows[i] = joinRow(row)
+	}
+
+	header := ApplyColor(opts.HeaderColor, joinRow(headers))
+	return header, rows, nil
+}
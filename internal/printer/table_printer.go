@@ -39,22 +39,9 @@ type TableOptions struct {
 // Table returns a [Printer] that buffers its contents and writes everything
 // out as a table when closed.
 func Table(items ItemType, w io.Writer, opts TableOptions) (Printer, error) {
-	var err error
-	fields := items.Fields
-
-	// If PrintColumns is not nil, print only specific columns.
-	if opts.PickColumns != nil {
-		fields, err = fields.Pick(opts.PickColumns)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	// If ShowWide is false, remove "wide" columns.
-	if !opts.ShowWide {
-		fields = fields.FilterOut(func(f *ItemStructField) bool {
-			return f.OnlyWide
-		})
+	fields, err := tableFields(items, opts)
+	if err != nil {
+		return nil, err
 	}
 
 	// Calculate the minimum column widths.
@@ -80,6 +67,30 @@ func Table(items ItemType, w io.Writer, opts TableOptions) (Printer, error) {
 	}, nil
 }
 
+// tableFields returns the fields printed as columns, as chosen by the
+// [TableOptions].
+func tableFields(items ItemType, opts TableOptions) (ItemStructFieldList, error) {
+	var err error
+	fields := items.Fields
+
+	// If PrintColumns is not nil, print only specific columns.
+	if opts.PickColumns != nil {
+		fields, err = fields.Pick(opts.PickColumns)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// If ShowWide is false, remove "wide" columns.
+	if !opts.ShowWide {
+		fields = fields.FilterOut(func(f *ItemStructField) bool {
+			return f.OnlyWide
+		})
+	}
+
+	return fields, nil
+}
+
 type tablePrinter struct {
 	options  TableOptions
 	itemType reflect.Type
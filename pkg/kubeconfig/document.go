@@ -0,0 +1,350 @@
+package kubeconfig
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultIndent is the indentation used when writing a [Document] whose
+// original indentation could not be determined.
+const defaultIndent = 2
+
+// Document holds the original YAML structure of a kubeconfig file, allowing
+// changes made to its [Config] to be written back without losing the file's
+// comments or the order of its keys.
+//
+// A Document does not hold the [Config] itself. Instead, the [Config] is
+// decoded by [UnmarshalDocument] and passed back to [Document.Marshal] after
+// it has been modified. This keeps the typed [Config] API unchanged.
+type Document struct {
+	source   []byte
+	root     yaml.Node
+	original *Config // a copy of the Config as it was decoded
+	indent   int
+}
+
+// UnmarshalDocument parses a kubeconfig file into the provided [Config],
+// returning a [Document] that remembers the file's original YAML structure.
+func UnmarshalDocument(data []byte, config *Config) (*Document, error) {
+	doc := &Document{
+		source: data,
+		indent: detectIndent(data),
+	}
+
+	err := yaml.Unmarshal(data, &doc.root)
+	if err != nil {
+		return nil, err
+	}
+
+	// An empty file has no document node.
+	if doc.root.Kind != 0 {
+		err = doc.root.Decode(config)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	doc.original = config.Clone()
+	return doc, nil
+}
+
+// ParseDocument parses a kubeconfig file, returning a [Document] that
+// remembers the file's original YAML structure.
+//
+// Unlike [UnmarshalDocument], the [Config] is not returned. This is used to
+// save changes to a [Config] that was already decoded from the same data.
+func ParseDocument(data []byte) (*Document, error) {
+	doc := &Document{
+		source:   data,
+		original: new(Config),
+		indent:   detectIndent(data),
+	}
+
+	err := yaml.Unmarshal(data, &doc.root)
+	if err != nil {
+		return nil, err
+	}
+
+	// An empty file has no document node.
+	if doc.root.Kind != 0 {
+		err = doc.root.Decode(doc.original)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return doc, nil
+}
+
+// Marshal encodes the [Config] as YAML, reusing the structure of the original
+// document wherever possible.
+//
+// If the [Config] was not changed, the original file contents are returned
+// as-is. Otherwise, the changes are applied to the original YAML nodes:
+//   - Comments are kept for any keys and items that still exist.
+//   - Existing keys keep their order, and new keys are appended.
+//   - Named items (e.g. clusters) are matched by name, not by position.
+//   - Scalars keep their original quoting style when possible.
+//
+// The indentation of the original file is kept, but block sequences will
+// always be indented relative to their parent key.
+func (d *Document) Marshal(config *Config) ([]byte, error) {
+	changed, err := d.isChanged(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if !changed {
+		return d.source, nil
+	}
+
+	// Encode the updated config as YAML nodes.
+	var updated yaml.Node
+	err = updated.Encode(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// Merge the changes into the original document.
+	root := &yaml.Node{Kind: yaml.DocumentNode}
+	if d.root.Kind == yaml.DocumentNode && len(d.root.Content) == 1 {
+		root.HeadComment = d.root.HeadComment
+		root.LineComment = d.root.LineComment
+		root.FootComment = d.root.FootComment
+		root.Content = []*yaml.Node{mergeNodes(d.root.Content[0], &updated)}
+	} else {
+		root.Content = []*yaml.Node{&updated}
+	}
+
+	// Write it.
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(d.indent)
+	err = encoder.Encode(root)
+	if err != nil {
+		return nil, err
+	}
+
+	err = encoder.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// isChanged returns true if the [Config] is different from the one originally
+// decoded from the document.
+func (d *Document) isChanged(config *Config) (bool, error) {
+	originalEncoded, err := yaml.Marshal(d.original)
+	if err != nil {
+		return false, fmt.Errorf("encoding original kubeconfig: %w", err)
+	}
+
+	encoded, err := yaml.Marshal(config)
+	if err != nil {
+		return false, err
+	}
+
+	return !bytes.Equal(originalEncoded, encoded), nil
+}
+
+// mergeNodes returns a YAML node with the value of `updated`, reusing as much
+// of `original` as possible to keep its comments, key order, and styles.
+func mergeNodes(original, updated *yaml.Node) *yaml.Node {
+	if nodesAreEquivalent(original, updated) {
+		return original
+	}
+
+	if original.Kind != updated.Kind || original.Kind == yaml.AliasNode {
+		return withCommentsFrom(original, updated)
+	}
+
+	switch original.Kind {
+	case yaml.MappingNode:
+		return mergeMappingNodes(original, updated)
+
+	case yaml.SequenceNode:
+		return mergeSequenceNodes(original, updated)
+
+	case yaml.ScalarNode:
+		merged := *original
+		merged.Tag = updated.Tag
+		merged.Value = updated.Value
+
+		// The original style may not be able to represent the new value
+		// (e.g. a string that looks like a number needs quotes).
+		if original.ShortTag() != updated.ShortTag() || updated.Style != 0 {
+			merged.Style = updated.Style
+		}
+
+		return &merged
+	}
+
+	return withCommentsFrom(original, updated)
+}
+
+// mergeMappingNodes merges two YAML mapping nodes.
+// Keys from the original mapping keep their order, removed keys are dropped,
+// and new keys are added to the end.
+func mergeMappingNodes(original, updated *yaml.Node) *yaml.Node {
+	merged := *original
+	merged.Content = make([]*yaml.Node, 0, len(updated.Content))
+
+	updatedValues := make(map[string]*yaml.Node, len(updated.Content)/2)
+	for i := 0; i+1 < len(updated.Content); i += 2 {
+		updatedValues[updated.Content[i].Value] = updated.Content[i+1]
+	}
+
+	// Keep the keys that still exist, in their original order.
+	seen := make(map[string]bool, len(updatedValues))
+	for i := 0; i+1 < len(original.Content); i += 2 {
+		key := original.Content[i]
+		updatedValue, ok := updatedValues[key.Value]
+		if !ok || seen[key.Value] {
+			continue
+		}
+
+		seen[key.Value] = true
+		merged.Content = append(merged.Content, key, mergeNodes(original.Content[i+1], updatedValue))
+	}
+
+	// Add new keys.
+	for i := 0; i+1 < len(updated.Content); i += 2 {
+		key := updated.Content[i]
+		if !seen[key.Value] {
+			merged.Content = append(merged.Content, key, updated.Content[i+1])
+		}
+	}
+
+	return &merged
+}
+
+// mergeSequenceNodes merges two YAML sequence nodes.
+//
+// Items are matched by their `name` field if they have one. Items that can't
+// be matched by name (e.g. renamed items, or items without names) are matched
+// by their position instead. The order of the updated sequence is used.
+func mergeSequenceNodes(original, updated *yaml.Node) *yaml.Node {
+	merged := *original
+	merged.Content = make([]*yaml.Node, len(updated.Content))
+	used := make([]bool, len(original.Content))
+
+	// Find the names used by the updated items.
+	updatedNames := make(map[string]bool, len(updated.Content))
+	for _, item := range updated.Content {
+		if name, ok := namedItemName(item); ok {
+			updatedNames[name] = true
+		}
+	}
+
+	// Match by name.
+	originalByName := make(map[string]int, len(original.Content))
+	for i, item := range original.Content {
+		if name, ok := namedItemName(item); ok {
+			if _, exists := originalByName[name]; !exists {
+				originalByName[name] = i
+			}
+		}
+	}
+
+	matches := make([]int, len(updated.Content))
+	for i, item := range updated.Content {
+		matches[i] = -1
+		if name, ok := namedItemName(item); ok {
+			if index, found := originalByName[name]; found && !used[index] {
+				matches[i] = index
+				used[index] = true
+			}
+		}
+	}
+
+	// Match by position.
+	for i := range updated.Content {
+		if matches[i] != -1 || i >= len(original.Content) || used[i] {
+			continue
+		}
+
+		// Don't steal an item that still exists under its own name.
+		if name, ok := namedItemName(original.Content[i]); ok && updatedNames[name] {
+			continue
+		}
+
+		matches[i] = i
+		used[i] = true
+	}
+
+	// Merge the matched items.
+	for i, item := range updated.Content {
+		if matches[i] == -1 {
+			merged.Content[i] = item
+		} else {
+			merged.Content[i] = mergeNodes(original.Content[matches[i]], item)
+		}
+	}
+
+	return &merged
+}
+
+// namedItemName returns the value of the `name` key if the YAML node is a
+// mapping containing one.
+func namedItemName(node *yaml.Node) (string, bool) {
+	if node.Kind != yaml.MappingNode {
+		return "", false
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == "name" && node.Content[i+1].Kind == yaml.ScalarNode {
+			return node.Content[i+1].Value, true
+		}
+	}
+
+	return "", false
+}
+
+// nodesAreEquivalent returns true if both YAML nodes decode to the same value.
+func nodesAreEquivalent(a, b *yaml.Node) bool {
+	var aValue, bValue any
+	if a.Decode(&aValue) != nil || b.Decode(&bValue) != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(aValue, bValue)
+}
+
+// withCommentsFrom returns a copy of the `updated` node with the comments of
+// the `original` node.
+func withCommentsFrom(original, updated *yaml.Node) *yaml.Node {
+	merged := *updated
+	merged.HeadComment = original.HeadComment
+	merged.LineComment = original.LineComment
+	merged.FootComment = original.FootComment
+	return &merged
+}
+
+// detectIndent guesses the number of spaces used to indent a YAML document.
+// This is the smallest indentation of any line that isn't blank or a comment.
+func detectIndent(data []byte) int {
+	indent := 0
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		lineIndent := len(line) - len(trimmed)
+		if lineIndent > 0 && (indent == 0 || lineIndent < indent) {
+			indent = lineIndent
+		}
+	}
+
+	if indent < 2 {
+		return defaultIndent
+	}
+
+	return indent
+}
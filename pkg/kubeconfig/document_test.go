@@ -0,0 +1,245 @@
+package kubeconfig
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lithammer/dedent"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/eth-p/kubesel/internal/testutil"
+)
+
+var roundTripTestcases = map[string]string{
+	"Empty file": ``,
+
+	"kubectl style": `
+		apiVersion: v1
+		clusters:
+		- cluster:
+		    certificate-authority-data: YWJjCg==
+		    server: https://127.0.0.1:6443
+		  name: kind-kind
+		contexts:
+		- context:
+		    cluster: kind-kind
+		    user: kind-kind
+		  name: kind-kind
+		current-context: kind-kind
+		kind: Config
+		preferences: {}
+		users:
+		- name: kind-kind
+		  user:
+		    token: abcdef
+	`,
+
+	"Comments and custom order": `
+		# My hand-written kubeconfig.
+		kind: Config
+		apiVersion: v1
+
+		users:
+		  - name: me # that's me
+		    user:
+		      username: me
+		      password: 'hunter2'
+
+		# Clusters
+		clusters:
+		  - name: local
+		    cluster:
+		      server: "https://localhost:6443"
+		      unknown-field: [1, 2, 3]
+	`,
+
+	"Anchors and aliases": `
+		contexts:
+		  - name: a
+		    context: &ctx
+		      cluster: shared
+		      user: shared
+		  - name: b
+		    context: *ctx
+	`,
+}
+
+func TestDocumentRoundTrip(t *testing.T) {
+	t.Parallel()
+	for name, source := range roundTripTestcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			source := []byte(dedent.Dedent(source))
+
+			var config Config
+			doc, err := UnmarshalDocument(source, &config)
+			require.NoError(t, err, "unmarshalling")
+
+			actual, err := doc.Marshal(&config)
+			require.NoError(t, err, "marshalling")
+			require.Equal(t, string(source), string(actual))
+		})
+	}
+}
+
+func TestDocumentPreservesStructure(t *testing.T) {
+	testcases := map[string]struct {
+		Source   string
+		Edit     func(c *Config)
+		Expected string
+	}{
+		"Changed value keeps comments and order": {
+			Source: `
+				# Header comment.
+				kind: Config
+				current-context: old # trailing comment
+				apiVersion: v1
+			`,
+			Edit: func(c *Config) {
+				c.CurrentContext = PtrFrom("new")
+			},
+			Expected: `
+				# Header comment.
+				kind: Config
+				current-context: new # trailing comment
+				apiVersion: v1
+			`,
+		},
+
+		"Renamed item keeps comments": {
+			Source: `
+				contexts:
+				  # The first context.
+				  - name: first
+				    context:
+				      cluster: foo # the cluster
+				  # The second context.
+				  - name: second
+				    context:
+				      cluster: bar
+			`,
+			Edit: func(c *Config) {
+				c.Contexts[1].Name = PtrFrom("renamed")
+			},
+			Expected: `
+				contexts:
+				  # The first context.
+				  - name: first
+				    context:
+				      cluster: foo # the cluster
+				  # The second context.
+				  - name: renamed
+				    context:
+				      cluster: bar
+			`,
+		},
+
+		"Removed item is matched by name": {
+			Source: `
+				clusters:
+				  - name: a # A
+				    cluster:
+				      server: a
+				  - name: b # B
+				    cluster:
+				      server: b
+			`,
+			Edit: func(c *Config) {
+				c.Clusters = c.Clusters[1:]
+			},
+			Expected: `
+				clusters:
+				  - name: b # B
+				    cluster:
+				      server: b
+			`,
+		},
+
+		"New keys and items are appended": {
+			Source: `
+				# Users.
+				users:
+				  - name: a
+				    user:
+				      token: a
+			`,
+			Edit: func(c *Config) {
+				c.AuthInfos[0].User.Username = PtrFrom("someone")
+				c.AuthInfos = append(c.AuthInfos, NamedAuthInfo{
+					Name: PtrFrom("b"),
+					User: &AuthInfo{Token: PtrFrom("b")},
+				})
+				c.CurrentContext = PtrFrom("ctx")
+			},
+			Expected: `
+				# Users.
+				users:
+				  - name: a
+				    user:
+				      token: a
+				      username: someone
+				  - name: b
+				    user:
+				      token: b
+				current-context: ctx
+			`,
+		},
+
+		"Unknown fields are preserved": {
+			Source: `
+				current-context: a
+				x-custom: # custom
+				  nested: [1, 2]
+			`,
+			Edit: func(c *Config) {
+				c.CurrentContext = PtrFrom("b")
+			},
+			Expected: `
+				current-context: b
+				x-custom: # custom
+				  nested: [1, 2]
+			`,
+		},
+
+		"Quoting style kept": {
+			Source: `
+				current-context: 'old'
+			`,
+			Edit: func(c *Config) {
+				c.CurrentContext = PtrFrom("new")
+			},
+			Expected: `
+				current-context: 'new'
+			`,
+		},
+
+		"Quotes added when needed": {
+			Source: `
+				current-context: old
+			`,
+			Edit: func(c *Config) {
+				c.CurrentContext = PtrFrom("123")
+			},
+			Expected: `
+				current-context: "123"
+			`,
+		},
+	}
+
+	t.Parallel()
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var config Config
+			doc, err := UnmarshalDocument([]byte(dedent.Dedent(tc.Source)), &config)
+			require.NoError(t, err, "unmarshalling")
+
+			tc.Edit(&config)
+			actual, err := doc.Marshal(&config)
+			require.NoError(t, err, "marshalling")
+			expected := strings.TrimLeft(dedent.Dedent(tc.Expected), "\n")
+			require.Equal(t, expected, string(actual))
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package kcutils
+
+import (
+	"slices"
+
+	"github.com/eth-p/kubesel/pkg/kubeconfig"
+)
+
+// RenameContext changes the name of every [kubeconfig.NamedContext] called
+// `from` inside the provided [kubeconfig.Config] struct. If the config's
+// current-context refers to the renamed context, it will be updated too.
+//
+// If the configuration does not contain the context, false will be returned.
+func RenameContext(from, to string, config *kubeconfig.Config) bool {
+	renamed := renameNamed(from, to, config.Contexts, namedContextName)
+	if renamed && config.CurrentContext != nil && *config.CurrentContext == from {
+		config.CurrentContext = PointerFor(to)
+	}
+
+	return renamed
+}
+
+// RenameCluster changes the name of every [kubeconfig.NamedCluster] called
+// `from` inside the provided [kubeconfig.Config] struct.
+//
+// Contexts referring to the cluster are not updated.
+// See [ReplaceClusterReferences] for that.
+//
+// If the configuration does not contain the cluster, false will be returned.
+func RenameCluster(from, to string, config *kubeconfig.Config) bool {
+	return renameNamed(from, to, config.Clusters, namedClusterName)
+}
+
+// RenameAuthInfo changes the name of every [kubeconfig.NamedAuthInfo] called
+// `from` inside the provided [kubeconfig.Config] struct.
+//
+// Contexts referring to the user are not updated.
+// See [ReplaceAuthInfoReferences] for that.
+//
+// If the configuration does not contain the user, false will be returned.
+func RenameAuthInfo(from, to string, config *kubeconfig.Config) bool {
+	return renameNamed(from, to, config.AuthInfos, namedAuthInfoName)
+}
+
+// RemoveContext removes every [kubeconfig.NamedContext] with the given name
+// from the provided [kubeconfig.Config] struct.
+//
+// If the configuration does not contain the context, false will be returned.
+func RemoveContext(name string, config *kubeconfig.Config) bool {
+	var removed bool
+	config.Contexts, removed = removeNamed(name, config.Contexts, namedContextName)
+	return removed
+}
+
+// RemoveCluster removes every [kubeconfig.NamedCluster] with the given name
+// from the provided [kubeconfig.Config] struct.
+//
+// If the configuration does not contain the cluster, false will be returned.
+func RemoveCluster(name string, config *kubeconfig.Config) bool {
+	var removed bool
+	config.Clusters, removed = removeNamed(name, config.Clusters, namedClusterName)
+	return removed
+}
+
+// RemoveAuthInfo removes every [kubeconfig.NamedAuthInfo] with the given name
+// from the provided [kubeconfig.Config] struct.
+//
+// If the configuration does not contain the user, false will be returned.
+func RemoveAuthInfo(name string, config *kubeconfig.Config) bool {
+	var removed bool
+	config.AuthInfos, removed = removeNamed(name, config.AuthInfos, namedAuthInfoName)
+	return removed
+}
+
+// ReplaceClusterReferences changes every [kubeconfig.Context] in the provided
+// [kubeconfig.Config] struct that refers to the `from` cluster to refer to the
+// `to` cluster instead.
+//
+// The number of updated contexts is returned.
+func ReplaceClusterReferences(from, to string, config *kubeconfig.Config) int {
+	count := 0
+	for _, namedContext := range config.Contexts {
+		kcContext := namedContext.Context
+		if kcContext != nil && kcContext.Cluster != nil && *kcContext.Cluster == from {
+			kcContext.Cluster = PointerFor(to)
+			count++
+		}
+	}
+
+	return count
+}
+
+// ReplaceAuthInfoReferences changes every [kubeconfig.Context] in the provided
+// [kubeconfig.Config] struct that refers to the `from` user to refer to the
+// `to` user instead.
+//
+// The number of updated contexts is returned.
+func ReplaceAuthInfoReferences(from, to string, config *kubeconfig.Config) int {
+	count := 0
+	for _, namedContext := range config.Contexts {
+		kcContext := namedContext.Context
+		if kcContext != nil && kcContext.User != nil && *kcContext.User == from {
+			kcContext.User = PointerFor(to)
+			count++
+		}
+	}
+
+	return count
+}
+
+// FindClusterReferences returns the names of the contexts in the provided
+// [kubeconfig.Config] struct that refer to the named cluster.
+func FindClusterReferences(name string, config *kubeconfig.Config) []string {
+	return findContextReferences(name, config, func(c *kubeconfig.Context) *string {
+		return c.Cluster
+	})
+}
+
+// FindAuthInfoReferences returns the names of the contexts in the provided
+// [kubeconfig.Config] struct that refer to the named user.
+func FindAuthInfoReferences(name string, config *kubeconfig.Config) []string {
+	return findContextReferences(name, config, func(c *kubeconfig.Context) *string {
+		return c.User
+	})
+}
+
+// findContextReferences returns the names of the contexts whose referenced
+// item, as returned by the ref function, has the given name.
+func findContextReferences(name string, config *kubeconfig.Config, ref func(*kubeconfig.Context) *string) []string {
+	var names []string
+	for _, namedContext := range config.Contexts {
+		if namedContext.Name == nil || namedContext.Context == nil {
+			continue
+		}
+
+		if refName := ref(namedContext.Context); refName != nil && *refName == name {
+			names = append(names, *namedContext.Name)
+		}
+	}
+
+	return names
+}
+
+func namedContextName(n *kubeconfig.NamedContext) **string   { return &n.Name }
+func namedClusterName(n *kubeconfig.NamedCluster) **string   { return &n.Name }
+func namedAuthInfoName(n *kubeconfig.NamedAuthInfo) **string { return &n.Name }
+
+// renameNamed renames the items in a slice of named kubeconfig items.
+//
+// The name pointers are replaced rather than written through, since they may
+// be shared with other copies of the same item (e.g. a merged kubeconfig).
+func renameNamed[T any](from, to string, items []T, nameOf func(*T) **string) bool {
+	renamed := false
+	for i := range items {
+		name := nameOf(&items[i])
+		if *name != nil && **name == from {
+			*name = PointerFor(to)
+			renamed = true
+		}
+	}
+
+	return renamed
+}
+
+// removeNamed removes the items with a specific name from a slice of named
+// kubeconfig items.
+func removeNamed[T any](name string, items []T, nameOf func(*T) **string) ([]T, bool) {
+	originalLength := len(items)
+	items = slices.DeleteFunc(items, func(item T) bool {
+		itemName := *nameOf(&item)
+		return itemName != nil && *itemName == name
+	})
+
+	return items, len(items) != originalLength
+}
@@ -0,0 +1,202 @@
+package kcutils
+
+import (
+	"testing"
+
+	. "github.com/eth-p/kubesel/internal/testutil"
+	"github.com/eth-p/kubesel/pkg/kubeconfig"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenameContext(t *testing.T) {
+	testcases := map[string]struct {
+		Kubeconfig      *kubeconfig.Config
+		Expected        *kubeconfig.Config
+		ExpectedRenamed bool
+	}{
+		"Returns false if no context with name": {
+			Kubeconfig: &kubeconfig.Config{
+				Contexts: []kubeconfig.NamedContext{
+					{Name: PtrFrom("other")},
+				},
+			},
+			Expected: &kubeconfig.Config{
+				Contexts: []kubeconfig.NamedContext{
+					{Name: PtrFrom("other")},
+				},
+			},
+			ExpectedRenamed: false,
+		},
+
+		"Renames context and current-context": {
+			Kubeconfig: &kubeconfig.Config{
+				CurrentContext: PtrFrom("from"),
+				Contexts: []kubeconfig.NamedContext{
+					{Name: PtrFrom("other")},
+					{
+						Name: PtrFrom("from"),
+						Context: &kubeconfig.Context{
+							Cluster: PtrFrom("cluster"),
+						},
+						Remaining: map[string]any{"unknown": "kept"},
+					},
+				},
+			},
+			Expected: &kubeconfig.Config{
+				CurrentContext: PtrFrom("to"),
+				Contexts: []kubeconfig.NamedContext{
+					{Name: PtrFrom("other")},
+					{
+						Name: PtrFrom("to"),
+						Context: &kubeconfig.Context{
+							Cluster: PtrFrom("cluster"),
+						},
+						Remaining: map[string]any{"unknown": "kept"},
+					},
+				},
+			},
+			ExpectedRenamed: true,
+		},
+	}
+
+	t.Parallel()
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			actualRenamed := RenameContext("from", "to", tc.Kubeconfig)
+			require.Equal(t, tc.ExpectedRenamed, actualRenamed)
+
+			// Compare.
+			diff := cmp.Diff(tc.Expected, tc.Kubeconfig, cmpopts.EquateEmpty())
+			require.Empty(t, diff, "--- Expected\n+++ Actual")
+		})
+	}
+}
+
+func TestRenameDoesNotWriteThroughSharedPointers(t *testing.T) {
+	t.Parallel()
+
+	sharedName := PtrFrom("from")
+	original := &kubeconfig.Config{
+		Clusters: []kubeconfig.NamedCluster{{Name: sharedName}},
+	}
+
+	copied := &kubeconfig.Config{
+		Clusters: []kubeconfig.NamedCluster{original.Clusters[0]},
+	}
+
+	require.True(t, RenameCluster("from", "to", copied))
+	require.Equal(t, "to", *copied.Clusters[0].Name)
+	require.Equal(t, "from", *original.Clusters[0].Name)
+}
+
+func TestRemoveCluster(t *testing.T) {
+	testcases := map[string]struct {
+		Kubeconfig      *kubeconfig.Config
+		Expected        *kubeconfig.Config
+		ExpectedRemoved bool
+	}{
+		"Returns false if no cluster with name": {
+			Kubeconfig: &kubeconfig.Config{
+				Clusters: []kubeconfig.NamedCluster{
+					{Name: PtrFrom("other")},
+				},
+			},
+			Expected: &kubeconfig.Config{
+				Clusters: []kubeconfig.NamedCluster{
+					{Name: PtrFrom("other")},
+				},
+			},
+			ExpectedRemoved: false,
+		},
+
+		"Removes all clusters with name": {
+			Kubeconfig: &kubeconfig.Config{
+				Clusters: []kubeconfig.NamedCluster{
+					{Name: PtrFrom("target")},
+					{Name: PtrFrom("other")},
+					{Name: PtrFrom("target")},
+				},
+			},
+			Expected: &kubeconfig.Config{
+				Clusters: []kubeconfig.NamedCluster{
+					{Name: PtrFrom("other")},
+				},
+			},
+			ExpectedRemoved: true,
+		},
+	}
+
+	t.Parallel()
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			actualRemoved := RemoveCluster("target", tc.Kubeconfig)
+			require.Equal(t, tc.ExpectedRemoved, actualRemoved)
+
+			// Compare.
+			diff := cmp.Diff(tc.Expected, tc.Kubeconfig, cmpopts.EquateEmpty())
+			require.Empty(t, diff, "--- Expected\n+++ Actual")
+		})
+	}
+}
+
+func TestReplaceReferences(t *testing.T) {
+	t.Parallel()
+
+	kc := &kubeconfig.Config{
+		Contexts: []kubeconfig.NamedContext{
+			{
+				Name: PtrFrom("a"),
+				Context: &kubeconfig.Context{
+					Cluster: PtrFrom("from"),
+					User:    PtrFrom("from"),
+				},
+			},
+			{
+				Name: PtrFrom("b"),
+				Context: &kubeconfig.Context{
+					Cluster: PtrFrom("from"),
+					User:    PtrFrom("other"),
+				},
+			},
+			{
+				Name: PtrFrom("c"),
+			},
+		},
+	}
+
+	require.Equal(t, []string{"a", "b"}, FindClusterReferences("from", kc))
+	require.Equal(t, []string{"a"}, FindAuthInfoReferences("from", kc))
+	require.Empty(t, FindAuthInfoReferences("missing", kc))
+
+	require.Equal(t, 2, ReplaceClusterReferences("from", "to", kc))
+	require.Equal(t, 1, ReplaceAuthInfoReferences("from", "to", kc))
+
+	expected := []kubeconfig.NamedContext{
+		{
+			Name: PtrFrom("a"),
+			Context: &kubeconfig.Context{
+				Cluster: PtrFrom("to"),
+				User:    PtrFrom("to"),
+			},
+		},
+		{
+			Name: PtrFrom("b"),
+			Context: &kubeconfig.Context{
+				Cluster: PtrFrom("to"),
+				User:    PtrFrom("other"),
+			},
+		},
+		{
+			Name: PtrFrom("c"),
+		},
+	}
+
+	diff := cmp.Diff(expected, kc.Contexts, cmpopts.EquateEmpty())
+	require.Empty(t, diff, "--- Expected\n+++ Actual")
+}
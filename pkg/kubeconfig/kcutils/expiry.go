@@ -0,0 +1,170 @@
+package kcutils
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/eth-p/kubesel/pkg/kubeconfig"
+)
+
+// CredentialExpiry describes when a credential of a [kubeconfig.AuthInfo]
+// expires.
+type CredentialExpiry struct {
+	// Credential is the kubeconfig field holding the credential, such as
+	// `token` or `client-certificate-data`.
+	Credential string
+
+	// Expires is when the credential stops being valid.
+	Expires time.Time
+
+	// Refreshable is true if the credential is renewed automatically
+	// once it expires, such as an OIDC id-token with a refresh-token.
+	Refreshable bool
+}
+
+// FindCredentialExpiries returns the expiry times of the credentials in a
+// [kubeconfig.AuthInfo]. This looks at the JWT `exp` claim of the token and
+// OIDC id-token, and the expiry date of the client certificate.
+//
+// Credentials that don't expire or aren't JWTs are left out. Relative paths
+// are resolved against baseDir, which should be the directory containing
+// the kubeconfig file that defined the user.
+func FindCredentialExpiries(authInfo *kubeconfig.AuthInfo, baseDir string) ([]CredentialExpiry, error) {
+	if authInfo == nil {
+		return nil, nil
+	}
+
+	var expiries []CredentialExpiry
+	var errs []error
+	add := func(credential string, data []byte, err error, parse expiryParser) {
+		var expires time.Time
+		var ok bool
+		if err == nil {
+			expires, ok, err = parse(data)
+		}
+
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Errorf("%s: %w", credential, err))
+		case ok:
+			expiries = append(expiries, CredentialExpiry{Credential: credential, Expires: expires})
+		}
+	}
+
+	// Client certificates.
+	switch {
+	case authInfo.ClientCertificateData != nil:
+		data, err := base64.StdEncoding.DecodeString(*authInfo.ClientCertificateData)
+		add("client-certificate-data", data, err, certificateExpiry)
+	case authInfo.ClientCertificateFile != nil:
+		data, err := os.ReadFile(resolvePath(*authInfo.ClientCertificateFile, baseDir))
+		add("client-certificate", data, err, certificateExpiry)
+	}
+
+	// Bearer tokens.
+	switch {
+	case authInfo.Token != nil:
+		add("token", []byte(*authInfo.Token), nil, tokenExpiry)
+	case authInfo.TokenFile != nil:
+		data, err := os.ReadFile(resolvePath(*authInfo.TokenFile, baseDir))
+		add("token-file", data, err, tokenExpiry)
+	}
+
+	// OIDC tokens.
+	if authInfo.AuthProvider != nil {
+		if idToken, found := authInfo.AuthProvider.Config["id-token"]; found {
+			if expires, ok, _ := tokenExpiry([]byte(idToken)); ok {
+				_, refreshable := authInfo.AuthProvider.Config["refresh-token"]
+				expiries = append(expiries, CredentialExpiry{
+					Credential:  "auth-provider id-token",
+					Expires:     expires,
+					Refreshable: refreshable,
+				})
+			}
+		}
+	}
+
+	return expiries, errors.Join(errs...)
+}
+
+// EarliestExpiry returns the credential that expires first, ignoring the
+// ones that are renewed automatically.
+func EarliestExpiry(expiries []CredentialExpiry) (CredentialExpiry, bool) {
+	var earliest CredentialExpiry
+	found := false
+	for _, expiry := range expiries {
+		if expiry.Refreshable {
+			continue
+		}
+
+		if !found || expiry.Expires.Before(earliest.Expires) {
+			earliest = expiry
+			found = true
+		}
+	}
+
+	return earliest, found
+}
+
+// expiryParser returns when the credential in the data expires. If it
+// doesn't expire, false is returned.
+type expiryParser func(data []byte) (time.Time, bool, error)
+
+// certificateExpiry returns the NotAfter time of the first certificate in
+// PEM-encoded data.
+func certificateExpiry(data []byte) (time.Time, bool, error) {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return time.Time{}, false, errors.New("no PEM-encoded certificate")
+		}
+
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+
+		return cert.NotAfter, true, nil
+	}
+}
+
+// tokenExpiry returns the time from the `exp` claim of a JWT. If the token
+// isn't a JWT or doesn't have an `exp` claim, false is returned.
+func tokenExpiry(data []byte) (time.Time, bool, error) {
+	parts := strings.Split(strings.TrimSpace(string(data)), ".")
+	if len(parts) != 3 {
+		return time.Time{}, false, nil
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, false, nil
+	}
+
+	var claims struct {
+		Exp *json.Number `json:"exp"`
+	}
+
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == nil {
+		return time.Time{}, false, nil
+	}
+
+	exp, err := claims.Exp.Float64()
+	if err != nil {
+		return time.Time{}, false, nil
+	}
+
+	return time.Unix(int64(exp), 0), true, nil
+}
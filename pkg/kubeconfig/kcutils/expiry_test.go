@@ -0,0 +1,145 @@
+package kcutils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/eth-p/kubesel/internal/testutil"
+	"github.com/eth-p/kubesel/pkg/kubeconfig"
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+)
+
+func testJWT(claims string) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(claims))
+	return header + "." + payload + ".signature"
+}
+
+func testCertificate(t *testing.T, notAfter time.Time) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    notAfter.Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestFindCredentialExpiries(t *testing.T) {
+	t.Parallel()
+
+	certExpires := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	cert := testCertificate(t, certExpires)
+
+	authInfo := &kubeconfig.AuthInfo{
+		ClientCertificateData: PtrFrom(base64.StdEncoding.EncodeToString(cert)),
+		Token:                 PtrFrom(testJWT(`{"sub":"me","exp":1900000000}`)),
+		AuthProvider: &kubeconfig.AuthProviderConfig{
+			Name: PtrFrom("oidc"),
+			Config: map[string]string{
+				"id-token":      testJWT(`{"exp":1800000000}`),
+				"refresh-token": "refresh",
+			},
+		},
+	}
+
+	expiries, err := FindCredentialExpiries(authInfo, "")
+	require.NoError(t, err)
+	diff := cmp.Diff([]CredentialExpiry{
+		{Credential: "client-certificate-data", Expires: certExpires},
+		{Credential: "token", Expires: time.Unix(1900000000, 0)},
+		{Credential: "auth-provider id-token", Expires: time.Unix(1800000000, 0), Refreshable: true},
+	}, expiries)
+	require.Empty(t, diff, "--- Expected\n+++ Actual")
+}
+
+func TestFindCredentialExpiriesFromFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	certExpires := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "client.crt"), testCertificate(t, certExpires), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "token"), []byte(testJWT(`{"exp":1900000000}`)+"\n"), 0o600))
+
+	authInfo := &kubeconfig.AuthInfo{
+		ClientCertificateFile: PtrFrom("client.crt"),
+		TokenFile:             PtrFrom("token"),
+	}
+
+	expiries, err := FindCredentialExpiries(authInfo, dir)
+	require.NoError(t, err)
+	diff := cmp.Diff([]CredentialExpiry{
+		{Credential: "client-certificate", Expires: certExpires},
+		{Credential: "token-file", Expires: time.Unix(1900000000, 0)},
+	}, expiries)
+	require.Empty(t, diff, "--- Expected\n+++ Actual")
+}
+
+func TestFindCredentialExpiriesIgnoresNonExpiring(t *testing.T) {
+	t.Parallel()
+
+	for _, token := range []string{
+		"not-a-jwt",
+		testJWT(`{"sub":"me"}`),
+		"a.!!!.c",
+	} {
+		t.Run(fmt.Sprintf("%q", token), func(t *testing.T) {
+			t.Parallel()
+
+			expiries, err := FindCredentialExpiries(&kubeconfig.AuthInfo{Token: PtrFrom(token)}, "")
+			require.NoError(t, err)
+			require.Empty(t, expiries)
+		})
+	}
+}
+
+func TestFindCredentialExpiriesInvalidCertificate(t *testing.T) {
+	t.Parallel()
+
+	authInfo := &kubeconfig.AuthInfo{
+		ClientCertificateData: PtrFrom(base64.StdEncoding.EncodeToString([]byte("not a cert"))),
+		ClientCertificateFile: PtrFrom("ignored.crt"),
+		TokenFile:             PtrFrom("does-not-exist"),
+	}
+
+	expiries, err := FindCredentialExpiries(authInfo, t.TempDir())
+	require.Error(t, err)
+	require.ErrorIs(t, err, os.ErrNotExist)
+	require.ErrorContains(t, err, "client-certificate-data")
+	require.Empty(t, expiries)
+}
+
+func TestEarliestExpiry(t *testing.T) {
+	t.Parallel()
+
+	expiries := []CredentialExpiry{
+		{Credential: "token", Expires: time.Unix(300, 0)},
+		{Credential: "auth-provider id-token", Expires: time.Unix(100, 0), Refreshable: true},
+		{Credential: "client-certificate-data", Expires: time.Unix(200, 0)},
+	}
+
+	earliest, ok := EarliestExpiry(expiries)
+	require.True(t, ok)
+	require.Equal(t, "client-certificate-data", earliest.Credential)
+
+	_, ok = EarliestExpiry(expiries[1:2])
+	require.False(t, ok)
+}
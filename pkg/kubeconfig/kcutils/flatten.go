@@ -0,0 +1,181 @@
+package kcutils
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eth-p/kubesel/pkg/kubeconfig"
+)
+
+const (
+	// RedactedData replaces the value of redacted `*-data` fields.
+	RedactedData = "DATA+OMITTED"
+
+	// RedactedSecret replaces the value of other redacted fields.
+	RedactedSecret = "REDACTED"
+)
+
+// redactedAuthProviderKeys are the [kubeconfig.AuthProviderConfig] keys that
+// hold credentials.
+var redactedAuthProviderKeys = []string{
+	"access-token",
+	"client-secret",
+	"id-token",
+	"refresh-token",
+}
+
+// FlattenCluster replaces the file references in a [kubeconfig.Cluster] with
+// the contents of those files, inlined as `*-data` fields.
+//
+// Relative paths are resolved against baseDir, which should be the directory
+// containing the kubeconfig file that defined the cluster.
+func FlattenCluster(cluster *kubeconfig.Cluster, baseDir string) error {
+	if cluster == nil {
+		return nil
+	}
+
+	return inlineFile(&cluster.CertificateAuthorityFile, &cluster.CertificateAuthorityData, baseDir)
+}
+
+// FlattenAuthInfo replaces the file references in a [kubeconfig.AuthInfo]
+// with the contents of those files. Certificates and keys are inlined as
+// `*-data` fields, and the token file is inlined as the token.
+//
+// Relative paths are resolved against baseDir, which should be the directory
+// containing the kubeconfig file that defined the user.
+func FlattenAuthInfo(authInfo *kubeconfig.AuthInfo, baseDir string) error {
+	if authInfo == nil {
+		return nil
+	}
+
+	err := inlineFile(&authInfo.ClientCertificateFile, &authInfo.ClientCertificateData, baseDir)
+	if err != nil {
+		return err
+	}
+
+	err = inlineFile(&authInfo.ClientKeyFile, &authInfo.ClientKeyData, baseDir)
+	if err != nil {
+		return err
+	}
+
+	if authInfo.TokenFile != nil {
+		contents, err := os.ReadFile(resolvePath(*authInfo.TokenFile, baseDir))
+		if err != nil {
+			return fmt.Errorf("reading token file: %w", err)
+		}
+
+		authInfo.Token = PointerFor(strings.TrimSpace(string(contents)))
+		authInfo.TokenFile = nil
+	}
+
+	return nil
+}
+
+// ResolveConfigPaths makes the file references in the clusters and users of a
+// [kubeconfig.Config] absolute, resolving relative paths against baseDir.
+func ResolveConfigPaths(config *kubeconfig.Config, baseDir string) {
+	for _, namedCluster := range config.Clusters {
+		ResolveClusterPaths(namedCluster.Cluster, baseDir)
+	}
+
+	for _, namedAuthInfo := range config.AuthInfos {
+		ResolveAuthInfoPaths(namedAuthInfo.User, baseDir)
+	}
+}
+
+// ResolveClusterPaths makes the file references in a [kubeconfig.Cluster]
+// absolute, resolving relative paths against baseDir.
+func ResolveClusterPaths(cluster *kubeconfig.Cluster, baseDir string) {
+	if cluster == nil {
+		return
+	}
+
+	resolvePathField(&cluster.CertificateAuthorityFile, baseDir)
+}
+
+// ResolveAuthInfoPaths makes the file references in a [kubeconfig.AuthInfo]
+// absolute, resolving relative paths against baseDir.
+func ResolveAuthInfoPaths(authInfo *kubeconfig.AuthInfo, baseDir string) {
+	if authInfo == nil {
+		return
+	}
+
+	resolvePathField(&authInfo.ClientCertificateFile, baseDir)
+	resolvePathField(&authInfo.ClientKeyFile, baseDir)
+	resolvePathField(&authInfo.TokenFile, baseDir)
+}
+
+// RedactCluster replaces the data of a [kubeconfig.Cluster] with placeholder
+// text, in the same way as `kubectl config view`.
+func RedactCluster(cluster *kubeconfig.Cluster) {
+	if cluster == nil {
+		return
+	}
+
+	redact(&cluster.CertificateAuthorityData, RedactedData)
+}
+
+// RedactAuthInfo replaces the credentials of a [kubeconfig.AuthInfo] with
+// placeholder text, in the same way as `kubectl config view`.
+func RedactAuthInfo(authInfo *kubeconfig.AuthInfo) {
+	if authInfo == nil {
+		return
+	}
+
+	redact(&authInfo.ClientCertificateData, RedactedData)
+	redact(&authInfo.ClientKeyData, RedactedData)
+	redact(&authInfo.Token, RedactedSecret)
+	redact(&authInfo.Password, RedactedSecret)
+
+	if authInfo.AuthProvider != nil {
+		for _, key := range redactedAuthProviderKeys {
+			if _, ok := authInfo.AuthProvider.Config[key]; ok {
+				authInfo.AuthProvider.Config[key] = RedactedSecret
+			}
+		}
+	}
+}
+
+// inlineFile reads the file referenced by a `*-file` field, storing its
+// base64-encoded contents in the `*-data` field.
+func inlineFile(fileField **string, dataField **string, baseDir string) error {
+	if *fileField == nil {
+		return nil
+	}
+
+	contents, err := os.ReadFile(resolvePath(**fileField, baseDir))
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", **fileField, err)
+	}
+
+	*dataField = PointerFor(base64.StdEncoding.EncodeToString(contents))
+	*fileField = nil
+	return nil
+}
+
+// resolvePath resolves a path relative to the base directory, in the same way
+// kubectl resolves paths relative to the kubeconfig file defining them.
+func resolvePath(path string, baseDir string) string {
+	if path == "" || filepath.IsAbs(path) || baseDir == "" {
+		return path
+	}
+
+	return filepath.Join(baseDir, path)
+}
+
+// resolvePathField replaces a non-nil path with its resolved path.
+func resolvePathField(field **string, baseDir string) {
+	if *field != nil {
+		*field = PointerFor(resolvePath(**field, baseDir))
+	}
+}
+
+// redact replaces a non-nil string value.
+func redact(field **string, replacement string) {
+	if *field != nil {
+		*field = PointerFor(replacement)
+	}
+}
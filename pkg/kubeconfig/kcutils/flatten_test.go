@@ -0,0 +1,101 @@
+package kcutils
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/eth-p/kubesel/internal/testutil"
+	"github.com/eth-p/kubesel/pkg/kubeconfig"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlattenCluster(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ca.crt"), []byte("ca"), 0o600))
+
+	cluster := &kubeconfig.Cluster{
+		Server:                   PtrFrom("https://cluster"),
+		CertificateAuthorityFile: PtrFrom("ca.crt"),
+	}
+
+	require.NoError(t, FlattenCluster(cluster, dir))
+	diff := cmp.Diff(&kubeconfig.Cluster{
+		Server:                   PtrFrom("https://cluster"),
+		CertificateAuthorityData: PtrFrom(base64.StdEncoding.EncodeToString([]byte("ca"))),
+	}, cluster, cmpopts.EquateEmpty())
+	require.Empty(t, diff, "--- Expected\n+++ Actual")
+}
+
+func TestFlattenAuthInfo(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "client.crt"), []byte("cert"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "client.key"), []byte("key"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "token"), []byte("secret\n"), 0o600))
+
+	authInfo := &kubeconfig.AuthInfo{
+		ClientCertificateFile: PtrFrom("client.crt"),
+		ClientKeyFile:         PtrFrom(filepath.Join(dir, "client.key")),
+		TokenFile:             PtrFrom("token"),
+	}
+
+	require.NoError(t, FlattenAuthInfo(authInfo, dir))
+	diff := cmp.Diff(&kubeconfig.AuthInfo{
+		ClientCertificateData: PtrFrom(base64.StdEncoding.EncodeToString([]byte("cert"))),
+		ClientKeyData:         PtrFrom(base64.StdEncoding.EncodeToString([]byte("key"))),
+		Token:                 PtrFrom("secret"),
+	}, authInfo, cmpopts.EquateEmpty())
+	require.Empty(t, diff, "--- Expected\n+++ Actual")
+}
+
+func TestFlattenAuthInfoMissingFile(t *testing.T) {
+	t.Parallel()
+
+	authInfo := &kubeconfig.AuthInfo{
+		ClientKeyFile: PtrFrom("does-not-exist.key"),
+	}
+
+	err := FlattenAuthInfo(authInfo, t.TempDir())
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestRedactAuthInfo(t *testing.T) {
+	t.Parallel()
+
+	authInfo := &kubeconfig.AuthInfo{
+		ClientKeyData: PtrFrom("a2V5"),
+		Token:         PtrFrom("secret"),
+		Username:      PtrFrom("me"),
+		AuthProvider: &kubeconfig.AuthProviderConfig{
+			Name: PtrFrom("oidc"),
+			Config: map[string]string{
+				"client-id":     "kubectl",
+				"client-secret": "secret",
+				"id-token":      "secret",
+			},
+		},
+	}
+
+	RedactAuthInfo(authInfo)
+	diff := cmp.Diff(&kubeconfig.AuthInfo{
+		ClientKeyData: PtrFrom(RedactedData),
+		Token:         PtrFrom(RedactedSecret),
+		Username:      PtrFrom("me"),
+		AuthProvider: &kubeconfig.AuthProviderConfig{
+			Name: PtrFrom("oidc"),
+			Config: map[string]string{
+				"client-id":     "kubectl",
+				"client-secret": RedactedSecret,
+				"id-token":      RedactedSecret,
+			},
+		},
+	}, authInfo, cmpopts.EquateEmpty())
+	require.Empty(t, diff, "--- Expected\n+++ Actual")
+}
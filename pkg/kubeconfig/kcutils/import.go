@@ -0,0 +1,288 @@
+package kcutils
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/eth-p/kubesel/pkg/kubeconfig"
+)
+
+// ErrNameConflict is returned by [Import] when an imported item has the same
+// name as a different, existing item.
+var ErrNameConflict = errors.New("name already exists")
+
+// ConflictStrategy determines what [Import] does when an imported item has
+// the same name as an existing item.
+//
+// Conflicts are only considered for items that are different. If the existing
+// item is identical to the imported one, it is reused.
+type ConflictStrategy int
+
+const (
+	// ConflictError returns [ErrNameConflict] and imports nothing.
+	ConflictError ConflictStrategy = iota
+
+	// ConflictRename imports the item under a new name, adding a numbered
+	// suffix (e.g. `-2`) until the name is unique.
+	ConflictRename
+)
+
+// ImportOptions are the options for [Import].
+type ImportOptions struct {
+	// Prefix is added to the names of all imported items.
+	Prefix string
+
+	// OnConflict is the strategy for handling name conflicts.
+	OnConflict ConflictStrategy
+
+	// Existing are other configs whose names should be treated as taken.
+	// This is typically the merged kubeconfig. Relative paths inside them
+	// should already be made absolute.
+	Existing []*kubeconfig.Config
+
+	// SourceDir is the directory that relative paths inside the source config
+	// are relative to. They are made absolute in the imported items, since
+	// the target config may be in a different directory.
+	SourceDir string
+
+	// TargetDir is the directory that relative paths inside the target config
+	// are relative to. They are made absolute when comparing the existing
+	// items to the imported ones, but are left as-is in the target config.
+	TargetDir string
+}
+
+// ImportResult describes what happened to each item during an [Import].
+type ImportResult struct {
+	Clusters  []ImportedItem
+	AuthInfos []ImportedItem
+	Contexts  []ImportedItem
+}
+
+// ImportedItem describes what happened to a single item during an [Import].
+type ImportedItem struct {
+	// OriginalName is the name of the item in the imported config.
+	OriginalName string
+
+	// Name is the name of the item after it was imported.
+	Name string
+
+	// Reused is true if an identical item already existed, and the imported
+	// item was not added.
+	Reused bool
+}
+
+// Renamed returns true if the item was imported under a different name.
+func (i *ImportedItem) Renamed() bool {
+	return i.OriginalName != i.Name
+}
+
+// Import adds the clusters, users, and contexts of the source config to the
+// target config. The source config is not modified.
+//
+// Imported items are renamed according to the [ImportOptions], and the
+// contexts are updated to refer to the renamed clusters and users. If an
+// error is returned, the target config is left unchanged.
+func Import(source *kubeconfig.Config, target *kubeconfig.Config, opts ImportOptions) (*ImportResult, error) {
+	// Items are compared with their relative paths made absolute, so the
+	// same file being imported again is recognized as identical.
+	resolvedTarget := target.Clone()
+	ResolveConfigPaths(resolvedTarget, opts.TargetDir)
+
+	existing := append([]*kubeconfig.Config{resolvedTarget}, opts.Existing...)
+	result := &ImportResult{}
+
+	// Clusters.
+	var clusters []kubeconfig.NamedCluster
+	clusterNames := make(map[string]string)
+	for _, namedCluster := range source.Clusters {
+		if namedCluster.Name == nil || hasKey(clusterNames, *namedCluster.Name) {
+			continue
+		}
+
+		clone := namedCluster.Clone()
+		ResolveClusterPaths(clone.Cluster, opts.SourceDir)
+
+		item, err := importName(*namedCluster.Name, clone.Cluster, clusterNames, opts, existing,
+			func(name string, config *kubeconfig.Config) (any, bool) {
+				return findNamedValue(name, config.Clusters, func(n *kubeconfig.NamedCluster) (*string, any) {
+					return n.Name, n.Cluster
+				})
+			},
+		)
+
+		if err != nil {
+			return nil, fmt.Errorf("cluster %s: %w", *namedCluster.Name, err)
+		}
+
+		clusterNames[item.OriginalName] = item.Name
+		result.Clusters = append(result.Clusters, item)
+		if !item.Reused {
+			clone.Name = PointerFor(item.Name)
+			clusters = append(clusters, *clone)
+		}
+	}
+
+	// Users.
+	var authInfos []kubeconfig.NamedAuthInfo
+	authInfoNames := make(map[string]string)
+	for _, namedAuthInfo := range source.AuthInfos {
+		if namedAuthInfo.Name == nil || hasKey(authInfoNames, *namedAuthInfo.Name) {
+			continue
+		}
+
+		clone := namedAuthInfo.Clone()
+		ResolveAuthInfoPaths(clone.User, opts.SourceDir)
+
+		item, err := importName(*namedAuthInfo.Name, clone.User, authInfoNames, opts, existing,
+			func(name string, config *kubeconfig.Config) (any, bool) {
+				return findNamedValue(name, config.AuthInfos, func(n *kubeconfig.NamedAuthInfo) (*string, any) {
+					return n.Name, n.User
+				})
+			},
+		)
+
+		if err != nil {
+			return nil, fmt.Errorf("user %s: %w", *namedAuthInfo.Name, err)
+		}
+
+		authInfoNames[item.OriginalName] = item.Name
+		result.AuthInfos = append(result.AuthInfos, item)
+		if !item.Reused {
+			clone.Name = PointerFor(item.Name)
+			authInfos = append(authInfos, *clone)
+		}
+	}
+
+	// Contexts.
+	// The references are updated first, since the updated context is what
+	// will be compared against existing contexts.
+	var contexts []kubeconfig.NamedContext
+	contextNames := make(map[string]string)
+	for _, namedContext := range source.Contexts {
+		if namedContext.Name == nil || hasKey(contextNames, *namedContext.Name) {
+			continue
+		}
+
+		clone := namedContext.Clone()
+		if clone.Context != nil {
+			if clone.Context.Cluster != nil && hasKey(clusterNames, *clone.Context.Cluster) {
+				clone.Context.Cluster = PointerFor(clusterNames[*clone.Context.Cluster])
+			}
+
+			if clone.Context.User != nil && hasKey(authInfoNames, *clone.Context.User) {
+				clone.Context.User = PointerFor(authInfoNames[*clone.Context.User])
+			}
+		}
+
+		item, err := importName(*namedContext.Name, clone.Context, contextNames, opts, existing,
+			func(name string, config *kubeconfig.Config) (any, bool) {
+				return findNamedValue(name, config.Contexts, func(n *kubeconfig.NamedContext) (*string, any) {
+					return n.Name, n.Context
+				})
+			},
+		)
+
+		if err != nil {
+			return nil, fmt.Errorf("context %s: %w", *namedContext.Name, err)
+		}
+
+		contextNames[item.OriginalName] = item.Name
+		result.Contexts = append(result.Contexts, item)
+		if !item.Reused {
+			clone.Name = PointerFor(item.Name)
+			contexts = append(contexts, *clone)
+		}
+	}
+
+	// Add the items.
+	target.Clusters = append(target.Clusters, clusters...)
+	target.AuthInfos = append(target.AuthInfos, authInfos...)
+	target.Contexts = append(target.Contexts, contexts...)
+	return result, nil
+}
+
+// importName determines the name that an item will be imported as.
+//
+// The imported map contains the original and new names of the items of the
+// same type that were already imported. The new names are treated as taken.
+//
+// The find function is used to look up the value of an existing item with
+// the same name inside a config.
+func importName(
+	originalName string,
+	value any,
+	imported map[string]string,
+	opts ImportOptions,
+	existing []*kubeconfig.Config,
+	find func(name string, config *kubeconfig.Config) (any, bool),
+) (ImportedItem, error) {
+	item := ImportedItem{
+		OriginalName: originalName,
+		Name:         opts.Prefix + originalName,
+	}
+
+	importedNames := make(map[string]bool, len(imported))
+	for _, name := range imported {
+		importedNames[name] = true
+	}
+
+	desired := item.Name
+	for attempt := 2; ; attempt++ {
+		existingValue, found := findInAny(item.Name, existing, find)
+		if !found && !importedNames[item.Name] {
+			return item, nil
+		}
+
+		if found && !importedNames[item.Name] && reflect.DeepEqual(existingValue, value) {
+			item.Reused = true
+			return item, nil
+		}
+
+		if opts.OnConflict != ConflictRename {
+			return item, fmt.Errorf("%w: %s", ErrNameConflict, item.Name)
+		}
+
+		item.Name = desired + "-" + strconv.Itoa(attempt)
+	}
+}
+
+// findInAny returns the value of the named item inside the first config that
+// contains it.
+func findInAny(
+	name string,
+	configs []*kubeconfig.Config,
+	find func(name string, config *kubeconfig.Config) (any, bool),
+) (any, bool) {
+	for _, config := range configs {
+		if config == nil {
+			continue
+		}
+
+		if value, found := find(name, config); found {
+			return value, true
+		}
+	}
+
+	return nil, false
+}
+
+// findNamedValue returns the value of the first item in the slice with the
+// given name.
+func findNamedValue[T any](name string, items []T, get func(*T) (*string, any)) (any, bool) {
+	for i := range items {
+		itemName, value := get(&items[i])
+		if itemName != nil && *itemName == name {
+			return value, true
+		}
+	}
+
+	return nil, false
+}
+
+// hasKey returns true if the map contains the key.
+func hasKey[K comparable, V any](m map[K]V, key K) bool {
+	_, ok := m[key]
+	return ok
+}
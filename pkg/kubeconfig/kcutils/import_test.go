@@ -0,0 +1,267 @@
+package kcutils
+
+import (
+	"testing"
+
+	. "github.com/eth-p/kubesel/internal/testutil"
+	"github.com/eth-p/kubesel/pkg/kubeconfig"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImport(t *testing.T) {
+	source := &kubeconfig.Config{
+		Clusters: []kubeconfig.NamedCluster{
+			{Name: PtrFrom("prod"), Cluster: &kubeconfig.Cluster{Server: PtrFrom("https://new-prod")}},
+		},
+		AuthInfos: []kubeconfig.NamedAuthInfo{
+			{Name: PtrFrom("admin"), User: &kubeconfig.AuthInfo{Token: PtrFrom("token")}},
+		},
+		Contexts: []kubeconfig.NamedContext{
+			{Name: PtrFrom("prod"), Context: &kubeconfig.Context{
+				Cluster: PtrFrom("prod"),
+				User:    PtrFrom("admin"),
+			}},
+		},
+	}
+
+	testcases := map[string]struct {
+		Existing       *kubeconfig.Config
+		Options        ImportOptions
+		Expected       *kubeconfig.Config
+		ExpectedResult *ImportResult
+		ExpectedError  error
+	}{
+		"Adds items without conflicts": {
+			Existing: &kubeconfig.Config{},
+			Expected: &kubeconfig.Config{
+				Clusters:  source.Clusters,
+				AuthInfos: source.AuthInfos,
+				Contexts:  source.Contexts,
+			},
+			ExpectedResult: &ImportResult{
+				Clusters:  []ImportedItem{{OriginalName: "prod", Name: "prod"}},
+				AuthInfos: []ImportedItem{{OriginalName: "admin", Name: "admin"}},
+				Contexts:  []ImportedItem{{OriginalName: "prod", Name: "prod"}},
+			},
+		},
+
+		"Prefix renames items and references": {
+			Existing: &kubeconfig.Config{},
+			Options:  ImportOptions{Prefix: "new-"},
+			Expected: &kubeconfig.Config{
+				Clusters: []kubeconfig.NamedCluster{
+					{Name: PtrFrom("new-prod"), Cluster: &kubeconfig.Cluster{Server: PtrFrom("https://new-prod")}},
+				},
+				AuthInfos: []kubeconfig.NamedAuthInfo{
+					{Name: PtrFrom("new-admin"), User: &kubeconfig.AuthInfo{Token: PtrFrom("token")}},
+				},
+				Contexts: []kubeconfig.NamedContext{
+					{Name: PtrFrom("new-prod"), Context: &kubeconfig.Context{
+						Cluster: PtrFrom("new-prod"),
+						User:    PtrFrom("new-admin"),
+					}},
+				},
+			},
+			ExpectedResult: &ImportResult{
+				Clusters:  []ImportedItem{{OriginalName: "prod", Name: "new-prod"}},
+				AuthInfos: []ImportedItem{{OriginalName: "admin", Name: "new-admin"}},
+				Contexts:  []ImportedItem{{OriginalName: "prod", Name: "new-prod"}},
+			},
+		},
+
+		"Conflict returns error and changes nothing": {
+			Existing: &kubeconfig.Config{
+				Clusters: []kubeconfig.NamedCluster{
+					{Name: PtrFrom("prod"), Cluster: &kubeconfig.Cluster{Server: PtrFrom("https://old-prod")}},
+				},
+			},
+			Expected: &kubeconfig.Config{
+				Clusters: []kubeconfig.NamedCluster{
+					{Name: PtrFrom("prod"), Cluster: &kubeconfig.Cluster{Server: PtrFrom("https://old-prod")}},
+				},
+			},
+			ExpectedError: ErrNameConflict,
+		},
+
+		"Conflict renames items and references": {
+			Existing: &kubeconfig.Config{
+				Clusters: []kubeconfig.NamedCluster{
+					{Name: PtrFrom("prod"), Cluster: &kubeconfig.Cluster{Server: PtrFrom("https://old-prod")}},
+				},
+			},
+			Options: ImportOptions{OnConflict: ConflictRename},
+			Expected: &kubeconfig.Config{
+				Clusters: []kubeconfig.NamedCluster{
+					{Name: PtrFrom("prod"), Cluster: &kubeconfig.Cluster{Server: PtrFrom("https://old-prod")}},
+					{Name: PtrFrom("prod-2"), Cluster: &kubeconfig.Cluster{Server: PtrFrom("https://new-prod")}},
+				},
+				AuthInfos: source.AuthInfos,
+				Contexts: []kubeconfig.NamedContext{
+					{Name: PtrFrom("prod"), Context: &kubeconfig.Context{
+						Cluster: PtrFrom("prod-2"),
+						User:    PtrFrom("admin"),
+					}},
+				},
+			},
+			ExpectedResult: &ImportResult{
+				Clusters:  []ImportedItem{{OriginalName: "prod", Name: "prod-2"}},
+				AuthInfos: []ImportedItem{{OriginalName: "admin", Name: "admin"}},
+				Contexts:  []ImportedItem{{OriginalName: "prod", Name: "prod"}},
+			},
+		},
+
+		"Identical items are reused": {
+			Existing: &kubeconfig.Config{
+				AuthInfos: []kubeconfig.NamedAuthInfo{
+					{Name: PtrFrom("admin"), User: &kubeconfig.AuthInfo{Token: PtrFrom("token")}},
+				},
+			},
+			Expected: &kubeconfig.Config{
+				Clusters:  source.Clusters,
+				AuthInfos: source.AuthInfos,
+				Contexts:  source.Contexts,
+			},
+			ExpectedResult: &ImportResult{
+				Clusters:  []ImportedItem{{OriginalName: "prod", Name: "prod"}},
+				AuthInfos: []ImportedItem{{OriginalName: "admin", Name: "admin", Reused: true}},
+				Contexts:  []ImportedItem{{OriginalName: "prod", Name: "prod"}},
+			},
+		},
+
+		"Conflicts with other configs are detected": {
+			Existing: &kubeconfig.Config{},
+			Options: ImportOptions{
+				Existing: []*kubeconfig.Config{{
+					Contexts: []kubeconfig.NamedContext{{Name: PtrFrom("prod")}},
+				}},
+			},
+			Expected:      &kubeconfig.Config{},
+			ExpectedError: ErrNameConflict,
+		},
+	}
+
+	t.Parallel()
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			target := tc.Existing
+			actualResult, err := Import(source, target, tc.Options)
+			if tc.ExpectedError != nil {
+				require.ErrorIs(t, err, tc.ExpectedError)
+			} else {
+				require.NoError(t, err)
+				diff := cmp.Diff(tc.ExpectedResult, actualResult, cmpopts.EquateEmpty())
+				require.Empty(t, diff, "--- Expected Result\n+++ Actual Result")
+			}
+
+			// Compare.
+			diff := cmp.Diff(tc.Expected, target, cmpopts.EquateEmpty())
+			require.Empty(t, diff, "--- Expected\n+++ Actual")
+		})
+	}
+}
+
+func TestImportAvoidsNamesUsedByImport(t *testing.T) {
+	t.Parallel()
+
+	source := &kubeconfig.Config{
+		Clusters: []kubeconfig.NamedCluster{
+			{Name: PtrFrom("a"), Cluster: &kubeconfig.Cluster{Server: PtrFrom("1")}},
+			{Name: PtrFrom("a-2"), Cluster: &kubeconfig.Cluster{Server: PtrFrom("2")}},
+		},
+	}
+
+	target := &kubeconfig.Config{
+		Clusters: []kubeconfig.NamedCluster{
+			{Name: PtrFrom("a"), Cluster: &kubeconfig.Cluster{Server: PtrFrom("0")}},
+		},
+	}
+
+	result, err := Import(source, target, ImportOptions{OnConflict: ConflictRename})
+	require.NoError(t, err)
+	require.Equal(t, []ImportedItem{
+		{OriginalName: "a", Name: "a-2"},
+		{OriginalName: "a-2", Name: "a-2-2"},
+	}, result.Clusters)
+}
+
+func TestImportResolvesRelativePaths(t *testing.T) {
+	source := &kubeconfig.Config{
+		Clusters: []kubeconfig.NamedCluster{
+			{Name: PtrFrom("prod"), Cluster: &kubeconfig.Cluster{CertificateAuthorityFile: PtrFrom("ca.crt")}},
+		},
+	}
+
+	testcases := map[string]struct {
+		Existing       *kubeconfig.Config
+		Options        ImportOptions
+		ExpectedFile   string
+		ExpectedResult []ImportedItem
+	}{
+		"Imported paths are made absolute": {
+			Existing:       &kubeconfig.Config{},
+			Options:        ImportOptions{SourceDir: "/source"},
+			ExpectedFile:   "/source/ca.crt",
+			ExpectedResult: []ImportedItem{{OriginalName: "prod", Name: "prod"}},
+		},
+		"Same file in the target is reused": {
+			Existing: &kubeconfig.Config{
+				Clusters: []kubeconfig.NamedCluster{
+					{Name: PtrFrom("prod"), Cluster: &kubeconfig.Cluster{CertificateAuthorityFile: PtrFrom("../source/ca.crt")}},
+				},
+			},
+			Options:        ImportOptions{SourceDir: "/source", TargetDir: "/target"},
+			ExpectedFile:   "../source/ca.crt",
+			ExpectedResult: []ImportedItem{{OriginalName: "prod", Name: "prod", Reused: true}},
+		},
+		"Same file in other configs is reused": {
+			Existing: &kubeconfig.Config{},
+			Options: ImportOptions{
+				SourceDir: "/source",
+				Existing: []*kubeconfig.Config{{
+					Clusters: []kubeconfig.NamedCluster{
+						{Name: PtrFrom("prod"), Cluster: &kubeconfig.Cluster{CertificateAuthorityFile: PtrFrom("/source/ca.crt")}},
+					},
+				}},
+			},
+			ExpectedResult: []ImportedItem{{OriginalName: "prod", Name: "prod", Reused: true}},
+		},
+		"Different file in the target conflicts": {
+			Existing: &kubeconfig.Config{
+				Clusters: []kubeconfig.NamedCluster{
+					{Name: PtrFrom("prod"), Cluster: &kubeconfig.Cluster{CertificateAuthorityFile: PtrFrom("ca.crt")}},
+				},
+			},
+			Options:      ImportOptions{SourceDir: "/source", TargetDir: "/target", OnConflict: ConflictRename},
+			ExpectedFile: "ca.crt",
+			ExpectedResult: []ImportedItem{
+				{OriginalName: "prod", Name: "prod-2"},
+			},
+		},
+	}
+
+	t.Parallel()
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			target := tc.Existing
+			result, err := Import(source, target, tc.Options)
+			require.NoError(t, err)
+			require.Equal(t, tc.ExpectedResult, result.Clusters)
+			require.Equal(t, "ca.crt", *source.Clusters[0].Cluster.CertificateAuthorityFile, "the source is not modified")
+
+			if tc.ExpectedFile != "" {
+				require.Equal(t, tc.ExpectedFile, *target.Clusters[0].Cluster.CertificateAuthorityFile, "the target's paths are kept")
+			}
+
+			if !result.Clusters[0].Reused {
+				imported := target.Clusters[len(target.Clusters)-1]
+				require.Equal(t, "/source/ca.crt", *imported.Cluster.CertificateAuthorityFile)
+			}
+		})
+	}
+}
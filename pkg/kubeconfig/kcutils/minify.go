@@ -0,0 +1,82 @@
+package kcutils
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/eth-p/kubesel/pkg/kubeconfig"
+)
+
+var (
+	ErrContextNotFound  = errors.New("context not found")
+	ErrClusterNotFound  = errors.New("cluster not found")
+	ErrAuthInfoNotFound = errors.New("user not found")
+)
+
+// Minify returns a new [kubeconfig.Config] containing only the named
+// [kubeconfig.NamedContext] and the [kubeconfig.NamedCluster] and
+// [kubeconfig.NamedAuthInfo] it refers to. The new config's current-context
+// will be set to the context.
+//
+// The returned config is a deep copy, and can be changed without affecting
+// the original config.
+func Minify(contextName string, config *kubeconfig.Config) (*kubeconfig.Config, error) {
+	kcContext := FindContext(contextName, config)
+	if kcContext == nil && !hasContext(contextName, config) {
+		return nil, fmt.Errorf("%w: %s", ErrContextNotFound, contextName)
+	}
+
+	minified := &kubeconfig.Config{
+		ApiVersion:     PointerFor("v1"),
+		Kind:           PointerFor("Config"),
+		CurrentContext: PointerFor(contextName),
+		Contexts: []kubeconfig.NamedContext{{
+			Name:    PointerFor(contextName),
+			Context: kcContext.Clone(),
+		}},
+	}
+
+	if kcContext == nil {
+		return minified, nil
+	}
+
+	// Add the cluster.
+	if kcContext.Cluster != nil {
+		cluster := FindCluster(*kcContext.Cluster, config)
+		if cluster == nil {
+			return nil, fmt.Errorf("%w: %s (referenced by context %s)", ErrClusterNotFound, *kcContext.Cluster, contextName)
+		}
+
+		minified.Clusters = []kubeconfig.NamedCluster{{
+			Name:    PointerFor(*kcContext.Cluster),
+			Cluster: cluster.Clone(),
+		}}
+	}
+
+	// Add the user.
+	if kcContext.User != nil {
+		authInfo := FindAuthInfo(*kcContext.User, config)
+		if authInfo == nil {
+			return nil, fmt.Errorf("%w: %s (referenced by context %s)", ErrAuthInfoNotFound, *kcContext.User, contextName)
+		}
+
+		minified.AuthInfos = []kubeconfig.NamedAuthInfo{{
+			Name: PointerFor(*kcContext.User),
+			User: authInfo.Clone(),
+		}}
+	}
+
+	return minified, nil
+}
+
+// hasContext returns true if the config contains a context with the given
+// name, even if the context itself is empty.
+func hasContext(name string, config *kubeconfig.Config) bool {
+	for _, namedContext := range config.Contexts {
+		if namedContext.Name != nil && *namedContext.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,114 @@
+package kcutils
+
+import (
+	"testing"
+
+	. "github.com/eth-p/kubesel/internal/testutil"
+	"github.com/eth-p/kubesel/pkg/kubeconfig"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMinify(t *testing.T) {
+	source := &kubeconfig.Config{
+		CurrentContext: PtrFrom("other"),
+		Clusters: []kubeconfig.NamedCluster{
+			{Name: PtrFrom("other"), Cluster: &kubeconfig.Cluster{Server: PtrFrom("https://other")}},
+			{Name: PtrFrom("cluster"), Cluster: &kubeconfig.Cluster{Server: PtrFrom("https://cluster")}},
+		},
+		AuthInfos: []kubeconfig.NamedAuthInfo{
+			{Name: PtrFrom("other"), User: &kubeconfig.AuthInfo{Token: PtrFrom("other")}},
+			{Name: PtrFrom("user"), User: &kubeconfig.AuthInfo{Token: PtrFrom("user")}},
+		},
+		Contexts: []kubeconfig.NamedContext{
+			{Name: PtrFrom("other"), Context: &kubeconfig.Context{Cluster: PtrFrom("other")}},
+			{Name: PtrFrom("context"), Context: &kubeconfig.Context{
+				Cluster:   PtrFrom("cluster"),
+				User:      PtrFrom("user"),
+				Namespace: PtrFrom("ns"),
+			}},
+			{Name: PtrFrom("missing-cluster"), Context: &kubeconfig.Context{Cluster: PtrFrom("missing")}},
+			{Name: PtrFrom("missing-user"), Context: &kubeconfig.Context{User: PtrFrom("missing")}},
+		},
+	}
+
+	testcases := map[string]struct {
+		Context       string
+		Expected      *kubeconfig.Config
+		ExpectedError error
+	}{
+		"Keeps only the referenced items": {
+			Context: "context",
+			Expected: &kubeconfig.Config{
+				ApiVersion:     PtrFrom("v1"),
+				Kind:           PtrFrom("Config"),
+				CurrentContext: PtrFrom("context"),
+				Clusters: []kubeconfig.NamedCluster{
+					{Name: PtrFrom("cluster"), Cluster: &kubeconfig.Cluster{Server: PtrFrom("https://cluster")}},
+				},
+				AuthInfos: []kubeconfig.NamedAuthInfo{
+					{Name: PtrFrom("user"), User: &kubeconfig.AuthInfo{Token: PtrFrom("user")}},
+				},
+				Contexts: []kubeconfig.NamedContext{
+					{Name: PtrFrom("context"), Context: &kubeconfig.Context{
+						Cluster:   PtrFrom("cluster"),
+						User:      PtrFrom("user"),
+						Namespace: PtrFrom("ns"),
+					}},
+				},
+			},
+		},
+
+		"Errors if context does not exist": {
+			Context:       "missing",
+			ExpectedError: ErrContextNotFound,
+		},
+
+		"Errors if cluster does not exist": {
+			Context:       "missing-cluster",
+			ExpectedError: ErrClusterNotFound,
+		},
+
+		"Errors if user does not exist": {
+			Context:       "missing-user",
+			ExpectedError: ErrAuthInfoNotFound,
+		},
+	}
+
+	t.Parallel()
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := Minify(tc.Context, source)
+			if tc.ExpectedError != nil {
+				require.ErrorIs(t, err, tc.ExpectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			diff := cmp.Diff(tc.Expected, actual, cmpopts.EquateEmpty())
+			require.Empty(t, diff, "--- Expected\n+++ Actual")
+		})
+	}
+}
+
+func TestMinifyDoesNotShareValues(t *testing.T) {
+	t.Parallel()
+
+	source := &kubeconfig.Config{
+		Clusters: []kubeconfig.NamedCluster{
+			{Name: PtrFrom("cluster"), Cluster: &kubeconfig.Cluster{Server: PtrFrom("https://cluster")}},
+		},
+		Contexts: []kubeconfig.NamedContext{
+			{Name: PtrFrom("context"), Context: &kubeconfig.Context{Cluster: PtrFrom("cluster")}},
+		},
+	}
+
+	minified, err := Minify("context", source)
+	require.NoError(t, err)
+
+	*minified.Clusters[0].Cluster.Server = "changed"
+	require.Equal(t, "https://cluster", *source.Clusters[0].Cluster.Server)
+}
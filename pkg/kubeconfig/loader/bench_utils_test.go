@@ -0,0 +1,76 @@
+package loader
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const (
+	benchmarkNumFiles         = 30
+	benchmarkItemsPerFile     = 5
+	benchmarkCertificateBytes = 1500
+)
+
+// simulatedCertificate is base64-encoded data around the size of a typical
+// certificate, since those make up most of a cloud provider's kubeconfig.
+var simulatedCertificate = base64.StdEncoding.EncodeToString(
+	[]byte(strings.Repeat("x", benchmarkCertificateBytes)),
+)
+
+// simulateKubeconfig generates the contents of a kubeconfig file similar to
+// one created by a cloud provider's CLI.
+func simulateKubeconfig(fileIndex int) string {
+	var sb strings.Builder
+	sb.WriteString("apiVersion: v1\nkind: Config\n")
+
+	sb.WriteString("clusters:\n")
+	for i := range benchmarkItemsPerFile {
+		fmt.Fprintf(&sb, "- name: cluster-%d-%d\n", fileIndex, i)
+		fmt.Fprintf(&sb, "  cluster:\n")
+		fmt.Fprintf(&sb, "    server: https://cluster-%d-%d.example.com\n", fileIndex, i)
+		fmt.Fprintf(&sb, "    certificate-authority-data: %s\n", simulatedCertificate)
+	}
+
+	sb.WriteString("users:\n")
+	for i := range benchmarkItemsPerFile {
+		fmt.Fprintf(&sb, "- name: user-%d-%d\n", fileIndex, i)
+		fmt.Fprintf(&sb, "  user:\n")
+		fmt.Fprintf(&sb, "    exec:\n")
+		fmt.Fprintf(&sb, "      apiVersion: client.authentication.k8s.io/v1beta1\n")
+		fmt.Fprintf(&sb, "      command: cloud-cli\n")
+		fmt.Fprintf(&sb, "      args: [get-token, --cluster, cluster-%d-%d]\n", fileIndex, i)
+		fmt.Fprintf(&sb, "      interactiveMode: IfAvailable\n")
+	}
+
+	sb.WriteString("contexts:\n")
+	for i := range benchmarkItemsPerFile {
+		fmt.Fprintf(&sb, "- name: context-%d-%d\n", fileIndex, i)
+		fmt.Fprintf(&sb, "  context:\n")
+		fmt.Fprintf(&sb, "    cluster: cluster-%d-%d\n", fileIndex, i)
+		fmt.Fprintf(&sb, "    user: user-%d-%d\n", fileIndex, i)
+		fmt.Fprintf(&sb, "    namespace: default\n")
+	}
+
+	return sb.String()
+}
+
+// writeSimulatedKubeconfigs writes [benchmarkNumFiles] simulated kubeconfig
+// files, returning their paths.
+func writeSimulatedKubeconfigs(t testing.TB) []string {
+	contents := make(map[string]string, benchmarkNumFiles)
+	names := make([]string, benchmarkNumFiles)
+	for i := range benchmarkNumFiles {
+		names[i] = fmt.Sprintf("kubeconfig-%d.yaml", i)
+		contents[names[i]] = simulateKubeconfig(i)
+	}
+
+	paths := writeTestKubeconfigs(t, contents)
+	files := make([]string, benchmarkNumFiles)
+	for i, name := range names {
+		files[i] = paths[name]
+	}
+
+	return files
+}
@@ -0,0 +1,282 @@
+package loader
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+
+	"github.com/eth-p/kubesel/internal/jsonnum"
+	"github.com/eth-p/kubesel/internal/parallel"
+	"github.com/eth-p/kubesel/pkg/kubeconfig"
+)
+
+// cacheVersion is changed whenever the format of the cache file changes.
+// Cache files with a different version are ignored.
+const cacheVersion = 2
+
+// fileCache is the contents of a cache file.
+//
+// This is encoded as JSON rather than with [encoding/gob], since gob cannot
+// tell the difference between a nil pointer and a pointer to a zero value
+// (e.g. `namespace: ""`). Decoding JSON is still much faster than parsing
+// YAML.
+type fileCache struct {
+	Version int            `json:"version"`
+	Files   []*cachedFile  `json:"files"`
+	Merged  []*cachedMerge `json:"merged"`
+}
+
+// cachedFile is the parsed contents of a kubeconfig file, and the file info
+// used to determine if it changed.
+type cachedFile struct {
+	Path       string            `json:"path"`
+	Size       int64             `json:"size"`
+	ModTime    int64             `json:"mtime"`
+	ChangeTime int64             `json:"ctime,omitempty"`
+	Inode      uint64            `json:"inode,omitempty"`
+	Config     kubeconfig.Config `json:"config"`
+}
+
+// cachedMerge is the merged contents of consecutive cached kubeconfig files.
+type cachedMerge struct {
+	Paths  []string          `json:"paths"`
+	Config kubeconfig.Config `json:"config"`
+}
+
+// CacheOptions are used by [LoadMultipleFilesCached].
+type CacheOptions struct {
+	// File is the path of the cache file.
+	File string
+
+	// Skip returns true for files that should never be cached. This is
+	// useful for files that are only used by one shell or change often,
+	// since the cache file is shared and rewritten whenever they change.
+	Skip func(path string) bool
+}
+
+// LoadMultipleFilesCached is like [LoadMultipleFiles], but reuses the parsed
+// contents of files that have not changed since they were last loaded.
+//
+// A file is considered unchanged if its size, modification time, change time,
+// and inode number are the same as when it was cached. Only the changed files
+// are parsed, and the cache file is updated afterwards. Files that could not
+// be loaded or that were encrypted are never cached. If the cache file cannot
+// be read or written, the files are loaded normally.
+//
+// The merged contents of consecutive cached files are cached too, so nothing
+// needs to be merged again unless one of the files changed.
+//
+// The cache file contains the full contents of the kubeconfig files, including
+// any credentials. It is created with permissions only allowing the current
+// user to read it.
+//
+// Kubeconfig files loaded from the cache do not remember their original YAML
+// structure. They should be loaded again with [LoadFromFile] before being
+// saved, otherwise their comments will be lost.
+func LoadMultipleFilesCached(files []string, opts CacheOptions) *LoadedKubeconfigCollection {
+	cache := readFileCache(opts.File)
+	cachedFiles := make(map[string]*cachedFile, len(cache.Files))
+	for _, entry := range cache.Files {
+		cachedFiles[entry.Path] = entry
+	}
+
+	type loadResult struct {
+		kc        *LoadedKubeconfig
+		entry     *cachedFile
+		fromCache bool
+	}
+
+	// Load each config file in parallel.
+	loaded := parallel.Ordered(files, func(file string) loadResult {
+		if opts.Skip != nil && opts.Skip(file) {
+			return loadResult{kc: LoadFromFile(file)}
+		}
+
+		kc, entry, fromCache := loadFromFileOrCache(file, cachedFiles[file])
+		return loadResult{kc, entry, fromCache}
+	})
+
+	result := newLoadedKubeconfigCollection(len(files))
+	updatedCache := &fileCache{Version: cacheVersion}
+	changed := false
+
+	// Merge them in order. Consecutive cached files are merged together
+	// first, so their merged contents can be reused next time.
+	var run []*LoadedKubeconfig
+	runChanged := false
+	mergeRun := func() {
+		if len(run) == 0 {
+			return
+		}
+
+		merge := findCachedMerge(cache.Merged, run)
+		if merge == nil || runChanged {
+			merge = new(cachedMerge)
+			for _, kc := range run {
+				merge.Paths = append(merge.Paths, kc.Path)
+				merge.Config = *kubeconfig.MergeConfig(&merge.Config, &kc.Config)
+			}
+
+			changed = true
+		}
+
+		updatedCache.Merged = append(updatedCache.Merged, merge)
+		result.merge(&merge.Config)
+		run, runChanged = nil, false
+	}
+
+	for i, res := range loaded {
+		result.record(i, res.kc)
+		if res.entry == nil {
+			mergeRun()
+			result.merge(&res.kc.Config)
+			continue
+		}
+
+		updatedCache.Files = append(updatedCache.Files, res.entry)
+		run = append(run, res.kc)
+		if !res.fromCache {
+			runChanged = true
+		}
+	}
+
+	mergeRun()
+
+	// Update the cache.
+	changed = changed ||
+		len(updatedCache.Files) != len(cache.Files) ||
+		len(updatedCache.Merged) != len(cache.Merged)
+
+	if changed {
+		_ = writeFileCache(opts.File, updatedCache)
+	}
+
+	return result
+}
+
+// findCachedMerge returns the cached merged contents of the files, or nil if
+// they were not merged before.
+func findCachedMerge(merges []*cachedMerge, files []*LoadedKubeconfig) *cachedMerge {
+	for _, merge := range merges {
+		if slices.EqualFunc(merge.Paths, files, func(path string, kc *LoadedKubeconfig) bool {
+			return path == kc.Path
+		}) {
+			return merge
+		}
+	}
+
+	return nil
+}
+
+// loadFromFileOrCache loads a kubeconfig file, using the cached copy if the
+// file has not changed. This returns the [cachedFile] to store in the updated
+// cache, or nil if the file should not be cached.
+func loadFromFileOrCache(file string, cached *cachedFile) (*LoadedKubeconfig, *cachedFile, bool) {
+	// The file info is checked before reading the file. If the file changes
+	// after this, the file info won't match next time.
+	stat, err := os.Stat(file)
+	if err != nil {
+		return LoadFromFile(file), nil, false
+	}
+
+	size := stat.Size()
+	modTime := stat.ModTime().UnixNano()
+	inode, changeTime := fileIdentity(stat)
+	if cached != nil &&
+		cached.Size == size &&
+		cached.ModTime == modTime &&
+		cached.ChangeTime == changeTime &&
+		cached.Inode == inode {
+		return &LoadedKubeconfig{Path: file, Config: cached.Config}, cached, true
+	}
+
+	// It changed, so load the file.
+	kc := LoadFromFile(file)
+	if len(kc.Errors) > 0 || kc.Encrypted {
+		return kc, nil, false
+	}
+
+	return kc, &cachedFile{
+		Path:       file,
+		Size:       size,
+		ModTime:    modTime,
+		ChangeTime: changeTime,
+		Inode:      inode,
+		Config:     kc.Config,
+	}, false
+}
+
+// readFileCache reads a cache file.
+// If it doesn't exist or can't be read, an empty cache is returned.
+func readFileCache(cacheFile string) *fileCache {
+	var cache fileCache
+	data, err := os.ReadFile(cacheFile)
+	if err != nil {
+		return &fileCache{}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if decoder.Decode(&cache) != nil || cache.Version != cacheVersion {
+		return &fileCache{}
+	}
+
+	for _, entry := range cache.Files {
+		normalizeNumbers(reflect.ValueOf(&entry.Config))
+	}
+
+	for _, merge := range cache.Merged {
+		normalizeNumbers(reflect.ValueOf(&merge.Config))
+	}
+
+	return &cache
+}
+
+// normalizeNumbers walks through a value, replacing any [json.Number] inside
+// its untyped fields with the int or float64 that YAML would have decoded.
+func normalizeNumbers(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			normalizeNumbers(v.Elem())
+		}
+
+	case reflect.Struct:
+		for i := range v.NumField() {
+			if v.Type().Field(i).IsExported() {
+				normalizeNumbers(v.Field(i))
+			}
+		}
+
+	case reflect.Slice:
+		for i := range v.Len() {
+			normalizeNumbers(v.Index(i))
+		}
+
+	case reflect.Map:
+		if untyped, ok := v.Interface().(map[string]any); ok {
+			jsonnum.Normalize(untyped)
+		}
+	}
+}
+
+// writeFileCache atomically writes a cache file.
+func writeFileCache(cacheFile string, cache *fileCache) error {
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return fmt.Errorf("marshalling cache: %w", err)
+	}
+
+	err = os.MkdirAll(filepath.Dir(cacheFile), 0o700)
+	if err != nil && !errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("creating cache directory: %w", err)
+	}
+
+	return WriteFileAtomically(cacheFile, data, 0o600)
+}
@@ -0,0 +1,17 @@
+package loader
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// fileIdentity returns the inode number and change time of a file. Unlike the
+// modification time, the change time cannot be set back by the file's owner.
+func fileIdentity(stat fs.FileInfo) (inode uint64, changeTime int64) {
+	sys, ok := stat.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0
+	}
+
+	return sys.Ino, sys.Ctimespec.Nano()
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !darwin
+
+package loader
+
+import (
+	"io/fs"
+)
+
+// fileIdentity returns the inode number and change time of a file.
+// They are not available on this platform, so only the size and modification
+// time are used to tell if a file changed.
+func fileIdentity(stat fs.FileInfo) (inode uint64, changeTime int64) {
+	return 0, 0
+}
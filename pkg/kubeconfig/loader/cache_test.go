@@ -0,0 +1,240 @@
+package loader
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/lithammer/dedent"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMultipleFilesCached(t *testing.T) {
+	t.Parallel()
+
+	paths := writeTestKubeconfigs(t, map[string]string{
+		"first.yaml": `
+			current-context: ""
+			clusters:
+			  - name: first
+			    cluster:
+			      server: first
+			      insecure-skip-tls-verify: false
+			      unknown: [1, "two", { three: 3 }]
+			contexts:
+			  - name: first
+			    context: { cluster: first, namespace: "" }
+			extensions:
+			  - name: ext
+			    extension: { apiVersion: v1, kind: Ext, value: 1 }
+		`,
+		"second.yaml": `
+			clusters:
+			  - name: second
+			    cluster: { server: second }
+		`,
+	})
+
+	files := []string{paths["first.yaml"], paths["second.yaml"], filepath.Join(t.TempDir(), "missing.yaml")}
+	cacheFile := filepath.Join(t.TempDir(), "cache", "kubeconfigs.json")
+
+	// The first load creates the cache, and the second one reads it.
+	expected := LoadMultipleFiles(files)
+	uncached := LoadMultipleFilesCached(files, CacheOptions{File: cacheFile})
+	require.FileExists(t, cacheFile)
+	cached := LoadMultipleFilesCached(files, CacheOptions{File: cacheFile})
+
+	for _, actual := range []*LoadedKubeconfigCollection{uncached, cached} {
+		diff := cmp.Diff(expected.Merged, actual.Merged, cmpopts.EquateEmpty())
+		require.Empty(t, diff, "--- Expected\n+++ Actual")
+		require.Equal(t, sourcePaths(expected.Provenance.Clusters.ShadowedIn("first")), sourcePaths(actual.Provenance.Clusters.ShadowedIn("first")))
+		require.Len(t, actual.Configs[2].Errors, 1, "missing file has error")
+	}
+
+	// Pointers to empty values need to be kept.
+	require.NotNil(t, cached.Merged.CurrentContext)
+	require.NotNil(t, cached.Merged.Clusters[0].Cluster.InsecureSkipTLSVerify)
+	require.NotNil(t, cached.Merged.Contexts[0].Context.Namespace)
+
+	// The cache file should only be readable by the user.
+	stat, err := os.Stat(cacheFile)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), stat.Mode().Perm())
+}
+
+func TestLoadMultipleFilesCachedInvalidation(t *testing.T) {
+	t.Parallel()
+
+	paths := writeTestKubeconfigs(t, map[string]string{
+		"config.yaml": `
+			current-context: before
+		`,
+	})
+
+	files := []string{paths["config.yaml"]}
+	cacheFile := filepath.Join(t.TempDir(), "kubeconfigs.json")
+	LoadMultipleFilesCached(files, CacheOptions{File: cacheFile})
+
+	// Change the file, keeping the same size.
+	err := os.WriteFile(paths["config.yaml"], []byte("current-context: after!\n"), 0o600)
+	require.NoError(t, err)
+
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(paths["config.yaml"], future, future))
+
+	actual := LoadMultipleFilesCached(files, CacheOptions{File: cacheFile})
+	require.Equal(t, "after!", *actual.Merged.CurrentContext)
+}
+
+func TestLoadMultipleFilesCachedDetectsReplacedFiles(t *testing.T) {
+	t.Parallel()
+
+	paths := writeTestKubeconfigs(t, map[string]string{
+		"config.yaml":      "current-context: before\n",
+		"replacement.yaml": "current-context: after!\n",
+	})
+
+	files := []string{paths["config.yaml"]}
+	cacheFile := filepath.Join(t.TempDir(), "kubeconfigs.json")
+	LoadMultipleFilesCached(files, CacheOptions{File: cacheFile})
+
+	// Replace the file with one of the same size and modification time.
+	stat, err := os.Stat(paths["config.yaml"])
+	require.NoError(t, err)
+	require.NoError(t, os.Chtimes(paths["replacement.yaml"], stat.ModTime(), stat.ModTime()))
+	require.NoError(t, os.Rename(paths["replacement.yaml"], paths["config.yaml"]))
+
+	actual := LoadMultipleFilesCached(files, CacheOptions{File: cacheFile})
+	require.Equal(t, "after!", *actual.Merged.CurrentContext)
+}
+
+func TestLoadMultipleFilesCachedSkipsFiles(t *testing.T) {
+	t.Parallel()
+
+	paths := writeTestKubeconfigs(t, map[string]string{
+		"session.yaml": `
+			current-context: session
+			contexts:
+			  - name: shared
+			    context: { cluster: from-session }
+		`,
+		"config.yaml": `
+			current-context: config
+			contexts:
+			  - name: shared
+			    context: { cluster: from-config }
+			  - name: other
+			    context: { cluster: other }
+		`,
+	})
+
+	files := []string{paths["session.yaml"], paths["config.yaml"]}
+	cacheFile := filepath.Join(t.TempDir(), "kubeconfigs.json")
+	opts := CacheOptions{
+		File: cacheFile,
+		Skip: func(path string) bool { return path == paths["session.yaml"] },
+	}
+
+	expected := LoadMultipleFiles(files)
+	LoadMultipleFilesCached(files, opts)
+	cacheStat, err := os.Stat(cacheFile)
+	require.NoError(t, err)
+
+	cacheContents, err := os.ReadFile(cacheFile)
+	require.NoError(t, err)
+	require.NotContains(t, string(cacheContents), paths["session.yaml"], "skipped files are not cached")
+
+	// Changing the skipped file doesn't change the cache.
+	err = os.WriteFile(paths["session.yaml"], []byte("current-context: changed\n"), 0o600)
+	require.NoError(t, err)
+
+	actual := LoadMultipleFilesCached(files, opts)
+	require.Equal(t, "changed", *actual.Merged.CurrentContext)
+
+	newCacheStat, err := os.Stat(cacheFile)
+	require.NoError(t, err)
+	require.Equal(t, cacheStat.ModTime(), newCacheStat.ModTime(), "the cache is not rewritten")
+
+	// The merged contents are the same as if nothing was cached.
+	require.NoError(t, os.WriteFile(paths["session.yaml"], []byte(dedent.Dedent(`
+		current-context: session
+		contexts:
+		  - name: shared
+		    context: { cluster: from-session }
+	`)), 0o600))
+
+	actual = LoadMultipleFilesCached(files, opts)
+	diff := cmp.Diff(expected.Merged, actual.Merged, cmpopts.EquateEmpty())
+	require.Empty(t, diff, "--- Expected\n+++ Actual")
+}
+
+func TestLoadMultipleFilesCachedReusesMergedFiles(t *testing.T) {
+	t.Parallel()
+
+	paths := writeTestKubeconfigs(t, map[string]string{
+		"first.yaml":  "current-context: first\n",
+		"second.yaml": "current-context: second\n",
+	})
+
+	files := []string{paths["first.yaml"], paths["second.yaml"]}
+	cacheFile := filepath.Join(t.TempDir(), "kubeconfigs.json")
+	LoadMultipleFilesCached(files, CacheOptions{File: cacheFile})
+
+	cache := readFileCache(cacheFile)
+	require.Len(t, cache.Merged, 1)
+	require.Equal(t, files, cache.Merged[0].Paths)
+	require.Equal(t, "first", *cache.Merged[0].Config.CurrentContext)
+
+	// The merged contents are used as-is when none of the files changed.
+	other := "from-cache"
+	cache.Merged[0].Config.CurrentContext = &other
+	require.NoError(t, writeFileCache(cacheFile, cache))
+
+	actual := LoadMultipleFilesCached(files, CacheOptions{File: cacheFile})
+	require.Equal(t, "from-cache", *actual.Merged.CurrentContext)
+
+	// They're merged again if the files are in a different order.
+	slices.Reverse(files)
+	actual = LoadMultipleFilesCached(files, CacheOptions{File: cacheFile})
+	require.Equal(t, "second", *actual.Merged.CurrentContext)
+}
+
+func TestLoadMultipleFilesCachedIgnoresBadCache(t *testing.T) {
+	t.Parallel()
+
+	paths := writeTestKubeconfigs(t, map[string]string{
+		"config.yaml": `
+			current-context: ctx
+		`,
+	})
+
+	cacheFile := filepath.Join(t.TempDir(), "kubeconfigs.json")
+	require.NoError(t, os.WriteFile(cacheFile, []byte("not json"), 0o600))
+
+	actual := LoadMultipleFilesCached([]string{paths["config.yaml"]}, CacheOptions{File: cacheFile})
+	require.Equal(t, "ctx", *actual.Merged.CurrentContext)
+}
+
+func BenchmarkLoadMultipleFiles(b *testing.B) {
+	files := writeSimulatedKubeconfigs(b)
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		LoadMultipleFiles(files)
+	}
+}
+
+func BenchmarkLoadMultipleFilesCached(b *testing.B) {
+	files := writeSimulatedKubeconfigs(b)
+	cacheFile := filepath.Join(b.TempDir(), "kubeconfigs.json")
+	LoadMultipleFilesCached(files, CacheOptions{File: cacheFile})
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		LoadMultipleFilesCached(files, CacheOptions{File: cacheFile})
+	}
+}
@@ -1,7 +1,58 @@
 package loader
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
 
 var ErrNoKubeDir = errors.New("cannot find .kube directory")
 var ErrReading = errors.New("read error")
 var ErrParsing = errors.New("parse error")
+var ErrDecrypting = errors.New("decryption error")
+var ErrSavingEncrypted = errors.New("cannot save an encrypted kubeconfig file")
+
+// ParseError describes a problem found while parsing a kubeconfig file.
+//
+// The Line and Column are 1-indexed, and are zero if the position of the
+// problem is unknown.
+type ParseError struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *ParseError) Error() string {
+	switch {
+	case e.Line > 0 && e.Column > 0:
+		return fmt.Sprintf("%v: line %d, column %d: %s", ErrParsing, e.Line, e.Column, e.Message)
+	case e.Line > 0:
+		return fmt.Sprintf("%v: line %d: %s", ErrParsing, e.Line, e.Message)
+	default:
+		return fmt.Sprintf("%v: %s", ErrParsing, e.Message)
+	}
+}
+
+func (e *ParseError) Unwrap() error {
+	return ErrParsing
+}
+
+// LoadError describes the problems encountered when loading a specific
+// kubeconfig file.
+type LoadError struct {
+	Path   string
+	Errors []error
+}
+
+func (e *LoadError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+
+	return fmt.Sprintf("error loading %s: %s", e.Path, strings.Join(messages, "; "))
+}
+
+func (e *LoadError) Unwrap() []error {
+	return e.Errors
+}
@@ -11,37 +11,96 @@ import (
 )
 
 // LoadedKubeconfig represents a loaded kubectl configuration file.
+//
+// If the file could not be read, or if parts of it could not be parsed, the
+// problems are listed in Errors. Parsing problems are described by
+// [ParseError], and the parts of the file that could be parsed are still
+// available in the Config.
+//
+// Files encrypted with SOPS are decrypted in memory using local age keys.
 type LoadedKubeconfig struct {
 	Path   string
 	Config kubeconfig.Config
 	Errors []error
+
+	// Encrypted is true if the file was encrypted with SOPS.
+	// The Config contains the decrypted contents, which are never cached
+	// or saved back to the file.
+	Encrypted bool
+
+	// source is the original contents of the file. When saving, it is parsed
+	// into a [kubeconfig.Document] to preserve the file's comments.
+	source []byte
 }
 
 type LoadedKubeconfigCollection struct {
 	Configs []*LoadedKubeconfig
 	Merged  *kubeconfig.Config
+
+	// Provenance records which of the Configs defined each named item in
+	// the Merged kubeconfig, including any duplicates that were shadowed.
+	Provenance *Provenance
 }
 
 func LoadMultipleFiles(files []string) *LoadedKubeconfigCollection {
-	result := new(LoadedKubeconfigCollection)
-	result.Configs = make([]*LoadedKubeconfig, len(files))
-	result.Merged = new(kubeconfig.Config)
+	result := newLoadedKubeconfigCollection(len(files))
 
 	// Load each config file in parallel, merging them iteratively.
 	for i, kc := range parallel.Ordered(files, LoadFromFile) {
-		result.Configs[i] = kc
-		result.Merged = kubeconfig.MergeConfig(result.Merged, &kc.Config)
+		result.add(i, kc)
 	}
 
 	return result
 }
 
+func newLoadedKubeconfigCollection(size int) *LoadedKubeconfigCollection {
+	return &LoadedKubeconfigCollection{
+		Configs:    make([]*LoadedKubeconfig, size),
+		Merged:     new(kubeconfig.Config),
+		Provenance: newProvenance(),
+	}
+}
+
+// add merges a [LoadedKubeconfig] into the collection.
+// This must be called in order.
+func (c *LoadedKubeconfigCollection) add(index int, kc *LoadedKubeconfig) {
+	c.record(index, kc)
+	c.merge(&kc.Config)
+}
+
+// record adds a [LoadedKubeconfig] to the collection without merging it.
+// This must be called in order.
+func (c *LoadedKubeconfigCollection) record(index int, kc *LoadedKubeconfig) {
+	c.Configs[index] = kc
+	c.Provenance.record(kc)
+}
+
+// merge merges a config into the merged kubeconfig. Since merging is
+// associative, this can be the already-merged contents of several files.
+func (c *LoadedKubeconfigCollection) merge(config *kubeconfig.Config) {
+	c.Merged = kubeconfig.MergeConfig(c.Merged, config)
+}
+
+// Err returns a [LoadError] describing the problems encountered when loading
+// the kubeconfig file, or nil if it was loaded successfully.
+func (kc *LoadedKubeconfig) Err() error {
+	if len(kc.Errors) == 0 {
+		return nil
+	}
+
+	return &LoadError{
+		Path:   kc.Path,
+		Errors: kc.Errors,
+	}
+}
+
 // LoadFromFile reads and parses a [kubeconfig.Config] file from the filesystem,
 // returning a [LoadedKubeconfig] with its contents.
 func LoadFromFile(file string) *LoadedKubeconfig {
 	handle, err := os.OpenFile(file, os.O_RDONLY, 0)
 	if err != nil {
 		return &LoadedKubeconfig{
+			Path:   file,
 			Errors: []error{fmt.Errorf("%w: %w", ErrReading, err)},
 		}
 	}
@@ -64,10 +123,51 @@ func LoadFromReader(reader io.Reader) *LoadedKubeconfig {
 	}
 
 	result := new(LoadedKubeconfig)
-	err = yaml.Unmarshal(buffer, &result.Config)
+
+	var root yaml.Node
+	err = yaml.Unmarshal(buffer, &root)
 	if err != nil {
-		result.Errors = []error{fmt.Errorf("%w: %w", ErrParsing, err)}
+		result.Errors = newParseErrors(buffer, err)
+		return result
+	}
+
+	// Decrypt the file if it was encrypted with SOPS.
+	if isSopsEncrypted(&root) {
+		result.Encrypted = true
+		buffer, err = decryptSopsWithLocalIdentities(&root)
+		if err != nil {
+			result.Errors = []error{err}
+			return result
+		}
+
+		root = yaml.Node{}
+		err = yaml.Unmarshal(buffer, &root)
+		if err != nil {
+			result.Errors = newParseErrors(buffer, err)
+			return result
+		}
+	}
+
+	// Decode the config from the nodes that were already parsed.
+	// An empty file has no document node.
+	result.source = buffer
+	if root.Kind != 0 {
+		err = root.Decode(&result.Config)
+		if err != nil {
+			result.Errors = newParseErrors(buffer, err)
+		}
 	}
 
 	return result
 }
+
+// decryptSopsWithLocalIdentities decrypts a SOPS-encrypted YAML document using
+// the age identities available on this computer.
+func decryptSopsWithLocalIdentities(root *yaml.Node) ([]byte, error) {
+	identities, err := findAgeIdentities()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDecrypting, err)
+	}
+
+	return decryptSops(root, identities)
+}
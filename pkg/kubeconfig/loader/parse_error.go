@@ -0,0 +1,95 @@
+package loader
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	yamlSyntaxErrorPattern = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+	yamlTypeErrorPattern   = regexp.MustCompile(`^line (\d+): (.*)$`)
+)
+
+// newParseErrors converts an error returned by the YAML library into
+// [ParseError] instances.
+//
+// The YAML library only reports the line of a problem as part of its error
+// message. For type errors, the document was parsed successfully, so the
+// column is found by looking for the value on that line.
+func newParseErrors(data []byte, err error) []error {
+	var typeErr *yaml.TypeError
+	if !errors.As(err, &typeErr) {
+		return []error{parseErrorFromMessage(yamlSyntaxErrorPattern, strings.TrimPrefix(err.Error(), "yaml: "), err.Error())}
+	}
+
+	var root yaml.Node
+	_ = yaml.Unmarshal(data, &root)
+
+	errs := make([]error, len(typeErr.Errors))
+	for i, message := range typeErr.Errors {
+		parseErr := parseErrorFromMessage(yamlTypeErrorPattern, message, message)
+		if parseErr.Line > 0 {
+			parseErr.Column = findValueColumn(&root, parseErr.Line)
+		}
+
+		errs[i] = parseErr
+	}
+
+	return errs
+}
+
+// parseErrorFromMessage creates a [ParseError] from an error message that
+// matches the pattern. The pattern must capture the line and the message.
+// If it does not match, the fallback message is used and the position is
+// left unknown.
+func parseErrorFromMessage(pattern *regexp.Regexp, fallback string, message string) *ParseError {
+	matches := pattern.FindStringSubmatch(message)
+	if matches == nil {
+		return &ParseError{Message: fallback}
+	}
+
+	line, _ := strconv.Atoi(matches[1])
+	return &ParseError{
+		Line:    line,
+		Message: matches[2],
+	}
+}
+
+// findValueColumn returns the column of the value on the given line.
+//
+// Values of keys on the line are preferred over other nodes, since type
+// errors happen when the value of a key is the wrong type. If there are none,
+// the column of the first value on the line is used instead. Block-style
+// collections are skipped, since they start at the indicator of their first
+// item rather than at a value.
+func findValueColumn(node *yaml.Node, line int) int {
+	valueColumn, firstColumn := 0, 0
+
+	var visit func(node *yaml.Node)
+	visit = func(node *yaml.Node) {
+		isBlockCollection := node.Kind != yaml.ScalarNode && node.Style&yaml.FlowStyle == 0
+		if node.Line == line && firstColumn == 0 && !isBlockCollection {
+			firstColumn = node.Column
+		}
+
+		for i, child := range node.Content {
+			isMappingValue := node.Kind == yaml.MappingNode && i%2 == 1
+			if isMappingValue && child.Line == line && node.Content[i-1].Line == line {
+				valueColumn = child.Column
+			}
+
+			visit(child)
+		}
+	}
+
+	visit(node)
+	if valueColumn != 0 {
+		return valueColumn
+	}
+
+	return firstColumn
+}
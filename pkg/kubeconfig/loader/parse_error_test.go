@@ -0,0 +1,79 @@
+package loader
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/lithammer/dedent"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadFromReaderParseErrors(t *testing.T) {
+	t.Parallel()
+	testcases := map[string]struct {
+		source   string
+		expected []error
+	}{
+		"Syntax error has line": {
+			source: dedent.Dedent(`
+				current-context: a
+				  cluster: b
+			`),
+			expected: []error{
+				&ParseError{Line: 2, Message: "mapping values are not allowed in this context"},
+			},
+		},
+		"Type errors have line and column": {
+			source: dedent.Dedent(`
+				current-context: [a]
+				clusters:
+				  - name: a
+				    cluster: [1]
+			`),
+			expected: []error{
+				&ParseError{Line: 1, Column: 18, Message: "cannot unmarshal !!seq into string"},
+				&ParseError{Line: 4, Column: 14, Message: "cannot unmarshal !!seq into kubeconfig.Cluster"},
+			},
+		},
+		"Type error in list item uses item column": {
+			source: dedent.Dedent(`
+				contexts:
+				  - 5
+			`),
+			expected: []error{
+				&ParseError{Line: 2, Column: 5, Message: "cannot unmarshal !!int `5` into kubeconfig.NamedContext"},
+			},
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			kc := LoadFromReader(strings.NewReader(strings.TrimPrefix(tc.source, "\n")))
+			diff := cmp.Diff(tc.expected, kc.Errors)
+			require.Empty(t, diff, "--- Expected\n+++ Actual")
+
+			for _, err := range kc.Errors {
+				require.True(t, errors.Is(err, ErrParsing), "is ErrParsing")
+			}
+		})
+	}
+}
+
+func TestLoadFromReaderKeepsValidPartsOnTypeError(t *testing.T) {
+	t.Parallel()
+
+	kc := LoadFromReader(strings.NewReader(dedent.Dedent(`
+		contexts:
+		  - name: a
+		    context: {cluster: a}
+		  - name: b
+		    context: [oops]
+	`)))
+
+	require.Len(t, kc.Errors, 1)
+	require.Len(t, kc.Config.Contexts, 2)
+	require.Equal(t, "a", *kc.Config.Contexts[0].Name)
+}
@@ -1,11 +1,13 @@
 package loader
 
 import (
+	"errors"
 	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 
 	"github.com/eth-p/kubesel/internal/parallel"
 )
@@ -19,12 +21,28 @@ const DefaultKubeconfigFileName = "config"
 // variable as a list of paths and falling back to `$HOME/.kube/config` if
 // the variable is not defined.
 //
+// The kubeconfig files inside the provided source directories are added
+// after those files. The directories are scanned every time this is called,
+// so new files will be found without needing to change `KUBECONFIG`.
+// See [FindKubeConfigFilesInDir] for details.
+//
 // REF: https://kubernetes.io/docs/concepts/configuration/organize-cluster-access-kubeconfig/#the-kubeconfig-environment-variable
-func FindKubeConfigFiles() ([]string, error) {
+func FindKubeConfigFiles(sourceDirs ...string) ([]string, error) {
+	files, err := FindKubeConfigFilesFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	return AppendKubeConfigFilesInDirs(files, sourceDirs...)
+}
+
+// FindKubeConfigFilesFromEnv returns the kubectl configuration files listed
+// in the `KUBECONFIG` environment variable, or the default `.kube/config`
+// file if the variable is not defined.
+func FindKubeConfigFilesFromEnv() ([]string, error) {
+	var files []string
 	kubeconfigVar, ok := os.LookupEnv("KUBECONFIG")
 	if ok {
-		var files []string
-
 		for _, file := range filepath.SplitList(kubeconfigVar) {
 			if file == "" {
 				continue
@@ -32,16 +50,98 @@ func FindKubeConfigFiles() ([]string, error) {
 
 			files = append(files, file)
 		}
+	} else {
+		// Search for the default kubeconfig file.
+		defaultKubeconfig, err := FindDefaultKubeconfigFile()
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, defaultKubeconfig)
+	}
+
+	return files, nil
+}
+
+// AppendKubeConfigFilesInDirs appends the kubeconfig files inside the source
+// directories to a list of kubeconfig files. Files that are already in the
+// list are not added again.
+func AppendKubeConfigFilesInDirs(files []string, sourceDirs ...string) ([]string, error) {
+	if len(sourceDirs) == 0 {
 		return files, nil
 	}
 
-	// Search for the default kubeconfig file.
-	defaultKubeconfig, err := FindDefaultKubeconfigFile()
+	seenFiles := make(map[string]bool, len(files))
+	for _, file := range files {
+		seenFiles[filepath.Clean(file)] = true
+	}
+
+	for _, dir := range sourceDirs {
+		dirFiles, err := FindKubeConfigFilesInDir(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range dirFiles {
+			if !seenFiles[file] {
+				seenFiles[file] = true
+				files = append(files, file)
+			}
+		}
+	}
+
+	return files, nil
+}
+
+// FindKubeConfigFilesInDir returns the kubeconfig files inside a directory,
+// sorted by name.
+//
+// Subdirectories, hidden files, and backup or swap files are ignored. If the
+// directory does not exist, no files will be returned.
+func FindKubeConfigFilesInDir(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
 	if err != nil {
-		return nil, err
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("error reading kubeconfig directory: %w", err)
+	}
+
+	files := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || isIgnoredFileName(name) {
+			continue
+		}
+
+		// Follow symlinks to check if they point to a directory.
+		file := filepath.Join(filepath.Clean(dir), name)
+		if entry.Type()&fs.ModeSymlink != 0 {
+			if stat, err := os.Stat(file); err == nil && stat.IsDir() {
+				continue
+			}
+		}
+
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+// isIgnoredFileName returns true if the file name looks like a backup, swap,
+// or temporary file.
+func isIgnoredFileName(name string) bool {
+	if strings.HasSuffix(name, "~") {
+		return true
+	}
+
+	switch filepath.Ext(name) {
+	case ".bak", ".orig", ".swp", ".tmp":
+		return true
 	}
 
-	return []string{defaultKubeconfig}, nil
+	return false
 }
 
 // FindDefaultKubeconfigFile returns the path to the default `.kube/config`
@@ -236,3 +236,67 @@ func TestFindDefaultKubeDirPOSIX(t *testing.T) {
 		})
 	}
 }
+
+func TestFindKubeConfigFilesInDir(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for _, name := range []string{"b.yaml", "a", "c.yml", ".hidden", "d.yaml.swp", "e.yaml~", "f.bak"} {
+		require.NoError(t, os.WriteFile(path.Join(dir, name), []byte{}, 0o600))
+	}
+
+	require.NoError(t, os.Mkdir(path.Join(dir, "subdir"), 0o700))
+	require.NoError(t, os.Symlink(path.Join(dir, "subdir"), path.Join(dir, "link-to-dir")))
+	require.NoError(t, os.Symlink(path.Join(dir, "a"), path.Join(dir, "link-to-file")))
+
+	actual, err := FindKubeConfigFilesInDir(dir)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		path.Join(dir, "a"),
+		path.Join(dir, "b.yaml"),
+		path.Join(dir, "c.yml"),
+		path.Join(dir, "link-to-file"),
+	}, actual)
+}
+
+func TestFindKubeConfigFilesInDirMissing(t *testing.T) {
+	t.Parallel()
+
+	actual, err := FindKubeConfigFilesInDir(path.Join(t.TempDir(), "does-not-exist"))
+	require.NoError(t, err)
+	require.Empty(t, actual)
+}
+
+func TestFindKubeConfigFilesWithSourceDirs(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(path.Join(dir, "a.yaml"), []byte{}, 0o600))
+	require.NoError(t, os.WriteFile(path.Join(dir, "b.yaml"), []byte{}, 0o600))
+
+	t.Setenv("KUBECONFIG", "/fake/config:"+path.Join(dir, "b.yaml"))
+	actual, err := FindKubeConfigFiles(dir)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"/fake/config",
+		path.Join(dir, "b.yaml"),
+		path.Join(dir, "a.yaml"),
+	}, actual)
+}
+
+func TestAppendKubeConfigFilesInDirs(t *testing.T) {
+	t.Parallel()
+
+	dirA := t.TempDir()
+	dirB := t.TempDir()
+	require.NoError(t, os.WriteFile(path.Join(dirA, "a.yaml"), []byte{}, 0o600))
+	require.NoError(t, os.WriteFile(path.Join(dirB, "b.yaml"), []byte{}, 0o600))
+	require.NoError(t, os.WriteFile(path.Join(dirB, "c.yaml"), []byte{}, 0o600))
+
+	actual, err := AppendKubeConfigFilesInDirs([]string{"/fake/config", path.Join(dirB, "c.yaml")}, dirA, dirB)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"/fake/config",
+		path.Join(dirB, "c.yaml"),
+		path.Join(dirA, "a.yaml"),
+		path.Join(dirB, "b.yaml"),
+	}, actual)
+}
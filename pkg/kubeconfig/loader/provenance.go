@@ -0,0 +1,82 @@
+package loader
+
+// Provenance records which loaded kubeconfig files defined the named items
+// inside a merged kubeconfig.
+type Provenance struct {
+	Clusters  NamedItemSources
+	Contexts  NamedItemSources
+	AuthInfos NamedItemSources
+}
+
+func newProvenance() *Provenance {
+	return &Provenance{
+		Clusters:  make(NamedItemSources),
+		Contexts:  make(NamedItemSources),
+		AuthInfos: make(NamedItemSources),
+	}
+}
+
+// record adds the named items of a [LoadedKubeconfig] to the provenance.
+// This must be called in the same order the files are merged.
+func (p *Provenance) record(kc *LoadedKubeconfig) {
+	for _, item := range kc.Config.Clusters {
+		p.Clusters.add(item.Name, kc)
+	}
+
+	for _, item := range kc.Config.Contexts {
+		p.Contexts.add(item.Name, kc)
+	}
+
+	for _, item := range kc.Config.AuthInfos {
+		p.AuthInfos.add(item.Name, kc)
+	}
+}
+
+// NamedItemSources maps the name of an item to the loaded kubeconfig files
+// which defined an item with that name.
+//
+// The files are listed in merge order. Following the first-definition-wins
+// rule of [kubeconfig.MergeConfig], the first file is the one whose definition
+// ended up in the merged kubeconfig. Definitions from any other files were
+// shadowed by it.
+type NamedItemSources map[string][]*LoadedKubeconfig
+
+func (s NamedItemSources) add(name *string, kc *LoadedKubeconfig) {
+	if name == nil {
+		return
+	}
+
+	s[*name] = append(s[*name], kc)
+}
+
+// DefinedBy returns the [LoadedKubeconfig] whose definition of the named item
+// was used in the merged kubeconfig.
+//
+// If no loaded file defines the item, nil will be returned.
+func (s NamedItemSources) DefinedBy(name string) *LoadedKubeconfig {
+	sources := s[name]
+	if len(sources) == 0 {
+		return nil
+	}
+
+	return sources[0]
+}
+
+// ShadowedIn returns the [LoadedKubeconfig] files whose definitions of the
+// named item were discarded because an earlier file also defined it.
+//
+// A file may appear more than once if it defines the same name multiple times.
+func (s NamedItemSources) ShadowedIn(name string) []*LoadedKubeconfig {
+	sources := s[name]
+	if len(sources) <= 1 {
+		return nil
+	}
+
+	return sources[1:]
+}
+
+// IsShadowing returns true if the used definition of the named item shadowed
+// one or more other definitions.
+func (s NamedItemSources) IsShadowing(name string) bool {
+	return len(s[name]) > 1
+}
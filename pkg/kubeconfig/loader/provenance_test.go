@@ -0,0 +1,103 @@
+package loader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lithammer/dedent"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestKubeconfigs(t testing.TB, files map[string]string) map[string]string {
+	dir := t.TempDir()
+	paths := make(map[string]string, len(files))
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		err := os.WriteFile(path, []byte(dedent.Dedent(contents)), 0o600)
+		require.NoError(t, err, "writing test kubeconfig")
+		paths[name] = path
+	}
+
+	return paths
+}
+
+func sourcePaths(sources []*LoadedKubeconfig) []string {
+	paths := make([]string, len(sources))
+	for i, kc := range sources {
+		paths[i] = kc.Path
+	}
+
+	return paths
+}
+
+func TestLoadMultipleFilesProvenance(t *testing.T) {
+	t.Parallel()
+
+	paths := writeTestKubeconfigs(t, map[string]string{
+		"first.yaml": `
+			clusters:
+			  - name: shared-cluster
+			    cluster: { server: first }
+			  - name: first-cluster
+			    cluster: { server: first }
+			contexts:
+			  - name: shared-context
+			    context: { cluster: first-cluster }
+			users:
+			  - name: first-user
+			    user: { username: first }
+		`,
+		"second.yaml": `
+			clusters:
+			  - name: shared-cluster
+			    cluster: { server: second }
+			contexts:
+			  - name: shared-context
+			    context: { cluster: shared-cluster }
+			  - name: shared-context
+			    context: { cluster: shared-cluster }
+			  - name: second-context
+			    context: { cluster: shared-cluster }
+		`,
+	})
+
+	first, second := paths["first.yaml"], paths["second.yaml"]
+	loaded := LoadMultipleFiles([]string{first, second})
+	provenance := loaded.Provenance
+
+	t.Run("DefinedBy returns first definition", func(t *testing.T) {
+		t.Parallel()
+		require.Equal(t, first, provenance.Clusters.DefinedBy("shared-cluster").Path)
+		require.Equal(t, first, provenance.Clusters.DefinedBy("first-cluster").Path)
+		require.Equal(t, first, provenance.Contexts.DefinedBy("shared-context").Path)
+		require.Equal(t, second, provenance.Contexts.DefinedBy("second-context").Path)
+		require.Equal(t, first, provenance.AuthInfos.DefinedBy("first-user").Path)
+	})
+
+	t.Run("DefinedBy returns nil for unknown names", func(t *testing.T) {
+		t.Parallel()
+		require.Nil(t, provenance.Clusters.DefinedBy("unknown"))
+		require.Nil(t, provenance.Contexts.DefinedBy("first-cluster"))
+	})
+
+	t.Run("ShadowedIn returns later definitions", func(t *testing.T) {
+		t.Parallel()
+		require.Equal(t, []string{second}, sourcePaths(provenance.Clusters.ShadowedIn("shared-cluster")))
+		require.Equal(t, []string{second, second}, sourcePaths(provenance.Contexts.ShadowedIn("shared-context")))
+		require.Empty(t, provenance.Contexts.ShadowedIn("second-context"))
+	})
+
+	t.Run("IsShadowing", func(t *testing.T) {
+		t.Parallel()
+		require.True(t, provenance.Clusters.IsShadowing("shared-cluster"))
+		require.False(t, provenance.Clusters.IsShadowing("first-cluster"))
+		require.False(t, provenance.Clusters.IsShadowing("unknown"))
+	})
+
+	t.Run("Merged uses first definition", func(t *testing.T) {
+		t.Parallel()
+		require.Len(t, loaded.Merged.Clusters, 2)
+		require.Equal(t, "first", *loaded.Merged.Clusters[0].Cluster.Server)
+	})
+}
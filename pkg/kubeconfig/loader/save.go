@@ -0,0 +1,59 @@
+package loader
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/eth-p/kubesel/pkg/kubeconfig"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultFileMode is the permissions used when saving a kubeconfig file that
+// does not already exist.
+const defaultFileMode = 0o600
+
+// Save writes the [LoadedKubeconfig]'s Config to its Path, atomically replacing
+// the file's prior contents. If the file already exists, its permissions are
+// kept.
+//
+// If the kubeconfig was loaded from a file, its comments and key order will
+// be preserved. See [kubeconfig.Document] for details.
+//
+// Encrypted kubeconfig files cannot be saved, since that would replace them
+// with their decrypted contents.
+func (kc *LoadedKubeconfig) Save() error {
+	if kc.Encrypted {
+		return fmt.Errorf("%w: %s", ErrSavingEncrypted, kc.Path)
+	}
+
+	if len(kc.Errors) > 0 {
+		return fmt.Errorf("refusing to save kubeconfig with errors: %w", errors.Join(kc.Errors...))
+	}
+
+	mode := os.FileMode(defaultFileMode)
+	if stat, err := os.Stat(kc.Path); err == nil {
+		mode = stat.Mode().Perm()
+	}
+
+	marshalled, err := kc.marshal()
+	if err != nil {
+		return fmt.Errorf("marshalling kubeconfig: %w", err)
+	}
+
+	return WriteFileAtomically(kc.Path, marshalled, mode)
+}
+
+// marshal encodes the Config as YAML, using the original document structure
+// if there is one. The document is only parsed here, so loading files that
+// are never saved stays fast.
+func (kc *LoadedKubeconfig) marshal() ([]byte, error) {
+	if kc.source != nil {
+		doc, err := kubeconfig.ParseDocument(kc.source)
+		if err == nil {
+			return doc.Marshal(&kc.Config)
+		}
+	}
+
+	return yaml.Marshal(&kc.Config)
+}
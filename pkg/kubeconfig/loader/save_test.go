@@ -0,0 +1,67 @@
+package loader
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSavePreservesUnmodifiedFile(t *testing.T) {
+	t.Parallel()
+
+	source := `# Comment that yaml.Marshal would drop.
+users:
+- name: me
+  user: { token: abc }  # flow style
+clusters: []
+`
+
+	paths := writeTestKubeconfigs(t, map[string]string{"config.yaml": source})
+	path := paths["config.yaml"]
+	require.NoError(t, os.Chmod(path, 0o640))
+
+	kc := LoadFromFile(path)
+	require.Empty(t, kc.Errors)
+	require.NoError(t, kc.Save())
+
+	actual, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, source, string(actual))
+
+	stat, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o640), stat.Mode().Perm(), "permissions are kept")
+}
+
+func TestSaveRefusesFilesWithErrors(t *testing.T) {
+	t.Parallel()
+
+	paths := writeTestKubeconfigs(t, map[string]string{"config.yaml": "users: ["})
+	kc := LoadFromFile(paths["config.yaml"])
+	require.NotEmpty(t, kc.Errors)
+	require.Error(t, kc.Save())
+}
+
+func TestSavePreservesCommentsOfModifiedFile(t *testing.T) {
+	t.Parallel()
+
+	source := `# Comment that yaml.Marshal would drop.
+users:
+- name: me # the user
+  user: { token: abc }
+`
+
+	paths := writeTestKubeconfigs(t, map[string]string{"config.yaml": source})
+	kc := LoadFromFile(paths["config.yaml"])
+	require.Empty(t, kc.Errors)
+
+	name := "you"
+	kc.Config.AuthInfos[0].Name = &name
+	require.NoError(t, kc.Save())
+
+	actual, err := os.ReadFile(paths["config.yaml"])
+	require.NoError(t, err)
+	require.Contains(t, string(actual), "# Comment that yaml.Marshal would drop.")
+	require.Contains(t, string(actual), "name: you")
+}
@@ -0,0 +1,475 @@
+package loader
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"gopkg.in/yaml.v3"
+)
+
+// SOPS environment variables used to find age identities.
+// These are the same ones used by the `sops` command.
+const (
+	SopsAgeKeyEnvVar     = "SOPS_AGE_KEY"
+	SopsAgeKeyFileEnvVar = "SOPS_AGE_KEY_FILE"
+)
+
+// sopsMetadataKey is the top-level key containing the SOPS metadata of an
+// encrypted file.
+const sopsMetadataKey = "sops"
+
+// sopsEncryptedValuePattern matches a value encrypted by SOPS.
+var sopsEncryptedValuePattern = regexp.MustCompile(
+	`^ENC\[AES256_GCM,data:(.*),iv:(.*),tag:(.*),type:(.*)\]$`,
+)
+
+// sopsTypeTags are the YAML tags of the value types that SOPS can encrypt.
+var sopsTypeTags = map[string]string{
+	"str":   "!!str",
+	"int":   "!!int",
+	"float": "!!float",
+	"bool":  "!!bool",
+}
+
+// findAgeIdentities returns the age identities available to decrypt SOPS
+// files. Like the `sops` command, they are read from the `SOPS_AGE_KEY`
+// environment variable, the file specified by `SOPS_AGE_KEY_FILE`, and the
+// `sops/age/keys.txt` file inside the user config directory.
+var findAgeIdentities = sync.OnceValues(func() ([]age.Identity, error) {
+	var identities []age.Identity
+	if keys := os.Getenv(SopsAgeKeyEnvVar); keys != "" {
+		parsed, err := age.ParseIdentities(strings.NewReader(keys))
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", SopsAgeKeyEnvVar, err)
+		}
+
+		identities = append(identities, parsed...)
+	}
+
+	keyFiles := []string{os.Getenv(SopsAgeKeyFileEnvVar)}
+	if configDir := os.Getenv("XDG_CONFIG_HOME"); configDir != "" {
+		keyFiles = append(keyFiles, filepath.Join(configDir, "sops", "age", "keys.txt"))
+	} else if configDir, err := os.UserConfigDir(); err == nil {
+		keyFiles = append(keyFiles, filepath.Join(configDir, "sops", "age", "keys.txt"))
+	}
+
+	for _, keyFile := range keyFiles {
+		if keyFile == "" {
+			continue
+		}
+
+		data, err := os.ReadFile(keyFile)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("reading age keys: %w", err)
+		}
+
+		parsed, err := age.ParseIdentities(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", keyFile, err)
+		}
+
+		identities = append(identities, parsed...)
+	}
+
+	return identities, nil
+})
+
+// isSopsEncrypted returns true if the YAML document was encrypted by SOPS.
+func isSopsEncrypted(root *yaml.Node) bool {
+	metadata := findMappingValue(documentMapping(root), sopsMetadataKey)
+	return metadata != nil && findMappingValue(metadata, "mac") != nil
+}
+
+// sopsMacOnlyEncryptedInitialization is written to the message authentication
+// code before any values when SOPS was told to only authenticate the encrypted
+// values. It keeps the two kinds of codes from ever being the same.
+var sopsMacOnlyEncryptedInitialization = []byte{
+	0x8a, 0x3f, 0xd2, 0xad, 0x54, 0xce, 0x66, 0x52, 0x7b, 0x10, 0x34, 0xf3, 0xd1, 0x47, 0xbe, 0x0b,
+	0x0b, 0x97, 0x5b, 0x3b, 0xf4, 0x4f, 0x72, 0xc6, 0xfd, 0xad, 0xec, 0x81, 0x76, 0xf2, 0x7d, 0x69,
+}
+
+// decryptSops decrypts a YAML document that was encrypted by SOPS with age,
+// returning the decrypted document without its SOPS metadata.
+//
+// Like the `sops` command, this checks the message authentication code of
+// the whole file and requires every value that SOPS would have encrypted to
+// be encrypted. A file where values were added, changed, or replaced with
+// plaintext ones is rejected.
+func decryptSops(root *yaml.Node, identities []age.Identity) ([]byte, error) {
+	mapping := documentMapping(root)
+	metadata := findMappingValue(mapping, sopsMetadataKey)
+
+	rules, err := parseSopsEncryptionRules(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey, err := decryptSopsDataKey(metadata, identities)
+	if err != nil {
+		return nil, err
+	}
+
+	// Remove the metadata and decrypt the values.
+	for i := 0; i < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == sopsMetadataKey {
+			mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+			break
+		}
+	}
+
+	decrypter := sopsDecrypter{
+		dataKey: dataKey,
+		rules:   rules,
+		mac:     sha512.New(),
+	}
+
+	if rules.macOnlyEncrypted {
+		decrypter.mac.Write(sopsMacOnlyEncryptedInitialization)
+	}
+
+	err = decrypter.decryptNode(mapping, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = decrypter.verifyMac(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	return yaml.Marshal(root)
+}
+
+// decryptSopsDataKey decrypts the key used to encrypt the values of a SOPS
+// file, using whichever age identity it was encrypted for.
+func decryptSopsDataKey(metadata *yaml.Node, identities []age.Identity) ([]byte, error) {
+	recipients := findMappingValue(metadata, "age")
+	if recipients == nil || len(recipients.Content) == 0 {
+		return nil, fmt.Errorf("%w: file is not encrypted with age", ErrDecrypting)
+	}
+
+	if len(identities) == 0 {
+		return nil, fmt.Errorf("%w: no age keys found", ErrDecrypting)
+	}
+
+	for _, recipient := range recipients.Content {
+		enc := findMappingValue(recipient, "enc")
+		if enc == nil {
+			continue
+		}
+
+		reader, err := age.Decrypt(armor.NewReader(strings.NewReader(enc.Value)), identities...)
+		if err != nil {
+			continue
+		}
+
+		dataKey, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrDecrypting, err)
+		}
+
+		return dataKey, nil
+	}
+
+	return nil, fmt.Errorf("%w: none of the age keys can decrypt the file", ErrDecrypting)
+}
+
+// sopsEncryptionRules are the rules from the SOPS metadata that decide which
+// values of the file are encrypted.
+type sopsEncryptionRules struct {
+	unencryptedSuffix string
+	encryptedSuffix   string
+	unencryptedRegex  *regexp.Regexp
+	encryptedRegex    *regexp.Regexp
+	macOnlyEncrypted  bool
+}
+
+// parseSopsEncryptionRules reads the encryption rules from the SOPS metadata.
+func parseSopsEncryptionRules(metadata *yaml.Node) (*sopsEncryptionRules, error) {
+	rules := &sopsEncryptionRules{}
+	for _, key := range []string{"unencrypted_comment_regex", "encrypted_comment_regex"} {
+		if value := findMappingValue(metadata, key); value != nil && value.Value != "" {
+			return nil, fmt.Errorf("%w: %s is not supported", ErrDecrypting, key)
+		}
+	}
+
+	if value := findMappingValue(metadata, "unencrypted_suffix"); value != nil {
+		rules.unencryptedSuffix = value.Value
+	}
+
+	if value := findMappingValue(metadata, "encrypted_suffix"); value != nil {
+		rules.encryptedSuffix = value.Value
+	}
+
+	for key, re := range map[string]**regexp.Regexp{
+		"unencrypted_regex": &rules.unencryptedRegex,
+		"encrypted_regex":   &rules.encryptedRegex,
+	} {
+		value := findMappingValue(metadata, key)
+		if value == nil || value.Value == "" {
+			continue
+		}
+
+		compiled, err := regexp.Compile(value.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrDecrypting, key, err)
+		}
+
+		*re = compiled
+	}
+
+	if value := findMappingValue(metadata, "mac_only_encrypted"); value != nil {
+		rules.macOnlyEncrypted = value.Value == "true"
+	}
+
+	return rules, nil
+}
+
+// isEncrypted returns true if SOPS would have encrypted the value at the
+// given path of mapping keys. The rules are checked in the same order the
+// `sops` command checks them.
+func (r *sopsEncryptionRules) isEncrypted(path []string) bool {
+	encrypted := true
+	if r.unencryptedSuffix != "" && slices.ContainsFunc(path, func(key string) bool {
+		return strings.HasSuffix(key, r.unencryptedSuffix)
+	}) {
+		encrypted = false
+	}
+
+	if r.encryptedSuffix != "" {
+		encrypted = slices.ContainsFunc(path, func(key string) bool {
+			return strings.HasSuffix(key, r.encryptedSuffix)
+		})
+	}
+
+	if r.unencryptedRegex != nil && slices.ContainsFunc(path, r.unencryptedRegex.MatchString) {
+		encrypted = false
+	}
+
+	if r.encryptedRegex != nil {
+		encrypted = slices.ContainsFunc(path, r.encryptedRegex.MatchString)
+	}
+
+	return encrypted
+}
+
+// sopsDecrypter decrypts the values of a SOPS file, computing the message
+// authentication code of the decrypted values as it goes.
+type sopsDecrypter struct {
+	dataKey []byte
+	rules   *sopsEncryptionRules
+	mac     hash.Hash
+}
+
+// decryptNode decrypts the values inside a YAML node in-place.
+//
+// SOPS authenticates each value with the path of mapping keys leading to it,
+// so the path needs to be tracked while walking the tree.
+func (d *sopsDecrypter) decryptNode(node *yaml.Node, path []string) error {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			err := d.decryptNode(node.Content[i+1], append(path, node.Content[i].Value))
+			if err != nil {
+				return err
+			}
+		}
+
+	case yaml.SequenceNode:
+		for _, child := range node.Content {
+			err := d.decryptNode(child, path)
+			if err != nil {
+				return err
+			}
+		}
+
+	case yaml.ScalarNode:
+		return d.decryptValue(node, path)
+	}
+
+	return nil
+}
+
+// decryptValue decrypts a scalar YAML node if SOPS would have encrypted it,
+// and adds its value to the message authentication code.
+func (d *sopsDecrypter) decryptValue(node *yaml.Node, path []string) error {
+	if node.ShortTag() == "!!null" {
+		return nil // SOPS leaves null values alone.
+	}
+
+	encrypted := d.rules.isEncrypted(path)
+	if !encrypted {
+		if !d.rules.macOnlyEncrypted {
+			d.mac.Write(sopsMacBytes(node))
+		}
+
+		return nil
+	}
+
+	if !sopsEncryptedValuePattern.MatchString(node.Value) {
+		return fmt.Errorf("%w: line %d: value of %q is not encrypted", ErrDecrypting, node.Line, strings.Join(path, "."))
+	}
+
+	plaintext, valueType, err := decryptSopsValue(node.Value, d.dataKey, strings.Join(path, ":")+":")
+	if err != nil {
+		return fmt.Errorf("%w: line %d: %w", ErrDecrypting, node.Line, err)
+	}
+
+	tag, ok := sopsTypeTags[valueType]
+	if !ok {
+		return fmt.Errorf("%w: line %d: unsupported value type %q", ErrDecrypting, node.Line, valueType)
+	}
+
+	d.mac.Write(plaintext)
+	node.Value = string(plaintext)
+	node.Tag = tag
+	node.Style = 0
+	return nil
+}
+
+// verifyMac checks that the message authentication code of the decrypted
+// values is the one stored in the SOPS metadata.
+func (d *sopsDecrypter) verifyMac(metadata *yaml.Node) error {
+	mac := findMappingValue(metadata, "mac")
+	lastModified := findMappingValue(metadata, "lastmodified")
+	if mac == nil || lastModified == nil {
+		return fmt.Errorf("%w: missing message authentication code", ErrDecrypting)
+	}
+
+	// The code is encrypted with the modification time as additional data.
+	// SOPS formats the time again after parsing it, so this does too.
+	modTime, err := time.Parse(time.RFC3339, lastModified.Value)
+	if err != nil {
+		return fmt.Errorf("%w: lastmodified: %w", ErrDecrypting, err)
+	}
+
+	expected, _, err := decryptSopsValue(mac.Value, d.dataKey, modTime.Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("%w: message authentication code: %w", ErrDecrypting, err)
+	}
+
+	actual := fmt.Sprintf("%X", d.mac.Sum(nil))
+	if subtle.ConstantTimeCompare([]byte(actual), expected) != 1 {
+		return fmt.Errorf("%w: message authentication code does not match, the file may have been tampered with", ErrDecrypting)
+	}
+
+	return nil
+}
+
+// sopsMacBytes returns the bytes SOPS adds to the message authentication code
+// for an unencrypted scalar value. SOPS parses the value first, so numbers and
+// booleans are written in its canonical format.
+func sopsMacBytes(node *yaml.Node) []byte {
+	var value any
+	if err := node.Decode(&value); err != nil {
+		return []byte(node.Value)
+	}
+
+	switch value := value.(type) {
+	case string:
+		return []byte(value)
+	case int:
+		return []byte(strconv.Itoa(value))
+	case int64:
+		return []byte(strconv.FormatInt(value, 10))
+	case uint64:
+		return []byte(strconv.FormatUint(value, 10))
+	case float64:
+		return []byte(strconv.FormatFloat(value, 'f', -1, 64))
+	case bool:
+		if value {
+			return []byte("True")
+		}
+
+		return []byte("False")
+	default:
+		return []byte(node.Value)
+	}
+}
+
+// decryptSopsValue decrypts a value encrypted by SOPS, returning the
+// plaintext and the name of its type.
+func decryptSopsValue(value string, dataKey []byte, additionalData string) ([]byte, string, error) {
+	matches := sopsEncryptedValuePattern.FindStringSubmatch(value)
+	if matches == nil {
+		return nil, "", errors.New("not an encrypted value")
+	}
+
+	var parts [3][]byte
+	for i, encoded := range matches[1:4] {
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, "", err
+		}
+
+		parts[i] = decoded
+	}
+
+	data, iv, authTag := parts[0], parts[1], parts[2]
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	if err != nil {
+		return nil, "", err
+	}
+
+	plaintext, err := gcm.Open(nil, iv, append(data, authTag...), []byte(additionalData))
+	if err != nil {
+		return nil, "", err
+	}
+
+	return plaintext, matches[4], nil
+}
+
+// documentMapping returns the top-level mapping of a YAML document, or nil if
+// it is not a mapping.
+func documentMapping(root *yaml.Node) *yaml.Node {
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+
+	if root.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	return root
+}
+
+// findMappingValue returns the value of a key inside a YAML mapping, or nil if
+// the key does not exist.
+func findMappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping == nil || mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+
+	return nil
+}
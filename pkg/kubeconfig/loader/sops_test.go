@@ -0,0 +1,289 @@
+package loader
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	. "github.com/eth-p/kubesel/internal/testutil"
+	"github.com/eth-p/kubesel/pkg/kubeconfig"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/lithammer/dedent"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+var sopsTestPlaintext = dedent.Dedent(`
+	clusters:
+	  - name: prod
+	    cluster:
+	      server: https://prod.example.com
+	      insecure-skip-tls-verify: false
+	users:
+	  - name: admin
+	    user:
+	      token: secret-token
+	contexts:
+	  - name: prod-admin
+	    context:
+	      cluster: prod
+	      user: admin
+`)
+
+// The files in testdata/sops were encrypted by `sops --encrypt` (v3.9.4) with
+// the age key in testdata/sops/age-key.txt:
+//
+//   - kubeconfig.sops.yaml: every value is encrypted.
+//   - kubeconfig-regex.sops.yaml: `--encrypted-regex '^(token|client-key-data)$'`
+//   - kubeconfig-maconly.sops.yaml: `--encrypted-regex '^token$' --mac-only-encrypted`
+//
+// They all contain the same kubeconfig as sopsTestPlaintext, with an added
+// `apiVersion`, `kind`, comment, and null namespace.
+
+func sopsTestIdentity(t *testing.T) age.Identity {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", "sops", "age-key.txt"))
+	require.NoError(t, err)
+	identities, err := age.ParseIdentities(bytes.NewReader(data))
+	require.NoError(t, err)
+	require.Len(t, identities, 1)
+	return identities[0]
+}
+
+func sopsTestFixture(t *testing.T, name string) *yaml.Node {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", "sops", name))
+	require.NoError(t, err)
+
+	var root yaml.Node
+	require.NoError(t, yaml.Unmarshal(data, &root))
+	return &root
+}
+
+// sopsTestNode returns the node at a path of mapping keys, where sequences
+// are entered at their first item.
+func sopsTestNode(t *testing.T, root *yaml.Node, path ...string) *yaml.Node {
+	t.Helper()
+
+	node := documentMapping(root)
+	for _, key := range path {
+		if node.Kind == yaml.SequenceNode {
+			node = node.Content[0]
+		}
+
+		node = findMappingValue(node, key)
+		require.NotNil(t, node, "missing key %q", key)
+	}
+
+	return node
+}
+
+func TestDecryptSops(t *testing.T) {
+	t.Parallel()
+	identity := sopsTestIdentity(t)
+
+	expected := kubeconfig.Config{
+		ApiVersion: PtrFrom("v1"),
+		Kind:       PtrFrom("Config"),
+		Clusters: []kubeconfig.NamedCluster{
+			{
+				Name: PtrFrom("prod"),
+				Cluster: &kubeconfig.Cluster{
+					Server:                PtrFrom("https://prod.example.com"),
+					InsecureSkipTLSVerify: PtrFrom(false),
+				},
+			},
+		},
+		AuthInfos: []kubeconfig.NamedAuthInfo{
+			{
+				Name: PtrFrom("admin"),
+				User: &kubeconfig.AuthInfo{
+					Token: PtrFrom("secret-token"),
+				},
+			},
+		},
+		Contexts: []kubeconfig.NamedContext{
+			{
+				Name: PtrFrom("prod-admin"),
+				Context: &kubeconfig.Context{
+					Cluster: PtrFrom("prod"),
+					User:    PtrFrom("admin"),
+				},
+			},
+		},
+	}
+
+	for _, fixture := range []string{
+		"kubeconfig.sops.yaml",
+		"kubeconfig-regex.sops.yaml",
+		"kubeconfig-maconly.sops.yaml",
+	} {
+		t.Run(fixture, func(t *testing.T) {
+			t.Parallel()
+			root := sopsTestFixture(t, fixture)
+			require.True(t, isSopsEncrypted(root))
+
+			decrypted, err := decryptSops(root, []age.Identity{identity})
+			require.NoError(t, err)
+			require.NotContains(t, string(decrypted), "sops:")
+
+			var actual kubeconfig.Config
+			require.NoError(t, yaml.Unmarshal(decrypted, &actual))
+
+			diff := cmp.Diff(expected, actual, cmpopts.EquateEmpty())
+			require.Empty(t, diff, "--- Expected\n+++ Actual")
+		})
+	}
+}
+
+func TestDecryptSopsWithWrongIdentity(t *testing.T) {
+	t.Parallel()
+	otherIdentity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	root := sopsTestFixture(t, "kubeconfig.sops.yaml")
+	_, err = decryptSops(root, []age.Identity{otherIdentity})
+	require.ErrorIs(t, err, ErrDecrypting)
+}
+
+func TestDecryptSopsDetectsTampering(t *testing.T) {
+	t.Parallel()
+	identity := sopsTestIdentity(t)
+
+	testcases := map[string]struct {
+		fixture string
+		tamper  func(t *testing.T, root *yaml.Node)
+		message string
+	}{
+		"Moved encrypted values": {
+			fixture: "kubeconfig.sops.yaml",
+			tamper: func(t *testing.T, root *yaml.Node) {
+				server := sopsTestNode(t, root, "clusters", "cluster", "server")
+				token := sopsTestNode(t, root, "users", "user", "token")
+				server.Value, token.Value = token.Value, server.Value
+			},
+			message: "line 7",
+		},
+		"Removed encrypted value": {
+			fixture: "kubeconfig.sops.yaml",
+			tamper: func(t *testing.T, root *yaml.Node) {
+				cluster := sopsTestNode(t, root, "clusters", "cluster")
+				cluster.Content = cluster.Content[:2]
+			},
+			message: "message authentication code does not match",
+		},
+		"Plaintext value replacing an encrypted one": {
+			fixture: "kubeconfig.sops.yaml",
+			tamper: func(t *testing.T, root *yaml.Node) {
+				sopsTestNode(t, root, "clusters", "cluster", "server").Value = "https://evil.example.com"
+			},
+			message: `value of "clusters.cluster.server" is not encrypted`,
+		},
+		"Plaintext value matching the encrypted regex": {
+			fixture: "kubeconfig-regex.sops.yaml",
+			tamper: func(t *testing.T, root *yaml.Node) {
+				sopsTestNode(t, root, "users", "user", "token").Value = "stolen-token"
+			},
+			message: `value of "users.user.token" is not encrypted`,
+		},
+		"Changed unencrypted value": {
+			fixture: "kubeconfig-regex.sops.yaml",
+			tamper: func(t *testing.T, root *yaml.Node) {
+				sopsTestNode(t, root, "clusters", "cluster", "server").Value = "https://evil.example.com"
+			},
+			message: "message authentication code does not match",
+		},
+		"Changed MAC": {
+			fixture: "kubeconfig-regex.sops.yaml",
+			tamper: func(t *testing.T, root *yaml.Node) {
+				mac := sopsTestNode(t, root, "sops", "mac")
+				mac.Value = sopsTestNode(t, sopsTestFixture(t, "kubeconfig.sops.yaml"), "sops", "mac").Value
+			},
+			message: "message authentication code",
+		},
+		"Changed modification time": {
+			fixture: "kubeconfig-regex.sops.yaml",
+			tamper: func(t *testing.T, root *yaml.Node) {
+				sopsTestNode(t, root, "sops", "lastmodified").Value = "2000-01-01T00:00:00Z"
+			},
+			message: "message authentication code",
+		},
+		"Removed MAC": {
+			fixture: "kubeconfig-regex.sops.yaml",
+			tamper: func(t *testing.T, root *yaml.Node) {
+				sopsTestNode(t, root, "sops", "mac").Value = ""
+			},
+			message: "message authentication code",
+		},
+		"Disabled encrypted regex": {
+			fixture: "kubeconfig-regex.sops.yaml",
+			tamper: func(t *testing.T, root *yaml.Node) {
+				sopsTestNode(t, root, "sops", "encrypted_regex").Value = "^nothing$"
+				sopsTestNode(t, root, "users", "user", "token").Value = "stolen-token"
+			},
+			message: "message authentication code does not match",
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			root := sopsTestFixture(t, tc.fixture)
+			tc.tamper(t, root)
+
+			_, err := decryptSops(root, []age.Identity{identity})
+			require.ErrorIs(t, err, ErrDecrypting)
+			require.ErrorContains(t, err, tc.message)
+		})
+	}
+}
+
+func TestIsSopsEncrypted(t *testing.T) {
+	t.Parallel()
+	testcases := map[string]struct {
+		source   string
+		expected bool
+	}{
+		"Plain kubeconfig": {
+			source:   sopsTestPlaintext,
+			expected: false,
+		},
+		"Empty file": {
+			source:   "",
+			expected: false,
+		},
+		"Unrelated sops key": {
+			source:   "sops: yes\n",
+			expected: false,
+		},
+		"SOPS metadata": {
+			source:   "sops:\n  mac: ENC[...]\n  version: 3.9.4\n",
+			expected: true,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var root yaml.Node
+			require.NoError(t, yaml.Unmarshal([]byte(tc.source), &root))
+			require.Equal(t, tc.expected, isSopsEncrypted(&root))
+		})
+	}
+}
+
+func TestSaveRefusesEncryptedFiles(t *testing.T) {
+	t.Parallel()
+
+	kc := &LoadedKubeconfig{
+		Path:      t.TempDir() + "/config.yaml",
+		Encrypted: true,
+	}
+
+	require.ErrorIs(t, kc.Save(), ErrSavingEncrypted)
+}
@@ -0,0 +1,53 @@
+package loader
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// TempFileExt is the extension of the temporary files created by
+// [WriteFileAtomically]. Files with it are only left behind if the process
+// writing them was killed.
+const TempFileExt = ".swp"
+
+// WriteFileAtomically writes data to the named file with the given
+// permissions, atomically replacing its prior contents.
+//
+// The contents are written to a uniquely-named temporary file in the same
+// directory first, so concurrent writes don't interfere with each other.
+func WriteFileAtomically(path string, data []byte, perm os.FileMode) error {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*"+TempFileExt)
+	if err != nil {
+		return fmt.Errorf("creating file: %w", err)
+	}
+
+	err = file.Chmod(perm)
+	if err != nil {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+		return fmt.Errorf("changing file permissions: %w", err)
+	}
+
+	_, err = file.Write(data)
+	if err != nil {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+		return fmt.Errorf("writing to file: %w", err)
+	}
+
+	err = file.Close()
+	if err != nil {
+		_ = os.Remove(file.Name())
+		return fmt.Errorf("closing file: %w", err)
+	}
+
+	// Rename over existing file for atomic save.
+	err = os.Rename(file.Name(), path)
+	if err != nil {
+		_ = os.Remove(file.Name())
+		return fmt.Errorf("replacing file: %w", err)
+	}
+
+	return nil
+}
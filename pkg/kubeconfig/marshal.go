@@ -1,9 +1,12 @@
 package kubeconfig
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"reflect"
+
+	"github.com/eth-p/kubesel/internal/jsonnum"
 )
 
 // UnmarshalJSON implements [json.Unmarshaler].
@@ -18,11 +21,16 @@ import (
 func (e *Extension) UnmarshalJSON(data []byte) error {
 	fields := make(map[string]any)
 
-	err := json.Unmarshal(data, &fields)
+	// Decode numbers the same way YAML would.
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err := decoder.Decode(&fields)
 	if err != nil {
 		return err
 	}
 
+	jsonnum.Normalize(fields)
+
 	if apiVersionField, ok := fields["apiVersion"]; ok {
 		apiVersion, ok := apiVersionField.(string)
 		if !ok {
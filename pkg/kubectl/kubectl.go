@@ -1,16 +1,20 @@
+// Package kubectl runs the locally-installed `kubectl` command.
 package kubectl
 
 import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"slices"
 	"strings"
 )
 
 // Kubectl is a utility for running the locally-installed `kubectl` command.
 type Kubectl struct {
 	executable string
+	env        []string
 }
 
 // NewKubectlFromPATH returns a [Kubectl] wrapper for the `kubectl` command
@@ -32,6 +36,13 @@ func NewKubectl(executable string) (*Kubectl, error) {
 	}, nil
 }
 
+// WithEnv returns a copy of the [Kubectl] wrapper that runs kubectl with
+// extra environment variables, in the "key=value" form used by [os.Environ].
+func (k Kubectl) WithEnv(env []string) *Kubectl {
+	k.env = append(slices.Clone(k.env), env...)
+	return &k
+}
+
 // Exec runs the kubectl executable and returns its standard output.
 // If kubectl fails to execute, a [KubectlError] will be returned.
 func (k Kubectl) Exec(ctx context.Context, args []string) (string, error) {
@@ -40,6 +51,10 @@ func (k Kubectl) Exec(ctx context.Context, args []string) (string, error) {
 
 	proc := exec.CommandContext(ctx, k.executable, args...)
 	proc.Stdin = nil
+	if len(k.env) > 0 {
+		proc.Env = append(os.Environ(), k.env...)
+	}
+
 	proc.Stdout = &stdout
 	proc.Stderr = &stderr
 	err := proc.Run()
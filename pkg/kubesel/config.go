@@ -0,0 +1,467 @@
+package kubesel
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adrg/xdg"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileEnvVar is the environment variable containing the path of the
+// kubesel configuration file. If unset, [DefaultConfigFile] is used.
+const ConfigFileEnvVar = "KUBESEL_CONFIG"
+
+// Config is the kubesel configuration file.
+//
+// Most of the values are used as the defaults for command-line flags, and
+// can be overridden by using the flag.
+type Config struct {
+	// DataDir is the directory where kubesel stores its sessions and caches.
+	// The `KUBESEL_DATA_DIR` environment variable takes priority over this.
+	DataDir string `yaml:"data-dir,omitempty"`
+
+	// Color is when to print with colors: "auto", "always", or "never".
+	Color string `yaml:"color,omitempty"`
+
+	// KubeconfigCommandTimeout is how long a kubeconfig command can run
+	// before it is killed and its previous output is used instead.
+	KubeconfigCommandTimeout time.Duration `yaml:"kubeconfig-command-timeout,omitempty"`
+
+	// List contains the defaults for `kubesel list`.
+	List ListConfig `yaml:"list,omitempty"`
+
+	// Init contains the defaults for `kubesel init`.
+	Init InitConfig `yaml:"init,omitempty"`
+
+	// GC contains the options for automatic garbage collection.
+	GC GCConfig `yaml:"gc,omitempty"`
+
+	// Fzf contains the options for the fzf picker.
+	Fzf FzfConfig `yaml:"fzf,omitempty"`
+
+	// ProtectedContexts are glob patterns of context names which cannot be
+	// renamed or deleted by kubesel.
+	ProtectedContexts []string `yaml:"protected-contexts,omitempty"`
+
+	// ContextAliases are alternate names for contexts, as a map of the alias
+	// to the context name. They are only used by kubesel, and do not rename
+	// anything in the kubeconfig files.
+	ContextAliases map[string]string `yaml:"context-aliases,omitempty"`
+}
+
+// ListConfig contains the defaults for `kubesel list`.
+type ListConfig struct {
+	// Output is the default output format.
+	Output string `yaml:"output,omitempty"`
+}
+
+// InitConfig contains the defaults for `kubesel init`.
+type InitConfig struct {
+	AddKubeconfigs        []string      `yaml:"add-kubeconfigs,omitempty"`
+	AddKubeconfigDirs     []string      `yaml:"add-kubeconfig-dirs,omitempty"`
+	AddKubeconfigCommands []string      `yaml:"add-kubeconfig-commands,omitempty"`
+	KubeconfigCommandTTL  time.Duration `yaml:"kubeconfig-command-ttl,omitempty"`
+}
+
+// GCConfig contains the options for automatic garbage collection.
+type GCConfig struct {
+	// Aggressiveness is how often garbage collection runs automatically:
+	// "off", "low", "normal", or "high".
+	Aggressiveness string `yaml:"aggressiveness,omitempty"`
+}
+
+// FzfConfig contains the options for the fzf picker.
+type FzfConfig struct {
+	// Options are extra command-line options given to fzf.
+	Options []string `yaml:"options,omitempty"`
+}
+
+// DefaultConfig returns the [Config] used when there is no config file.
+func DefaultConfig() *Config {
+	return &Config{
+		Color: "auto",
+		List: ListConfig{
+			Output: "table",
+		},
+		GC: GCConfig{
+			Aggressiveness: "normal",
+		},
+	}
+}
+
+// MarshalYAML encodes the config with its durations written as strings
+// (e.g. `15m0s`), the same way they are written in the config file.
+func (c Config) MarshalYAML() (any, error) {
+	type plainConfig Config
+
+	var node yaml.Node
+	if err := node.Encode((*plainConfig)(&c)); err != nil {
+		return nil, err
+	}
+
+	formatConfigDurations(&node, reflect.ValueOf(c))
+	return &node, nil
+}
+
+// formatConfigDurations replaces the durations in an encoded struct with
+// their string forms.
+func formatConfigDurations(node *yaml.Node, value reflect.Value) {
+	typ := value.Type()
+	for i := range typ.NumField() {
+		name, _, _ := strings.Cut(typ.Field(i).Tag.Get("yaml"), ",")
+		child := findConfigSection(node, name)
+		if child == nil {
+			continue
+		}
+
+		field := value.Field(i)
+		switch {
+		case field.Type() == reflect.TypeFor[time.Duration]():
+			child.Tag = "!!str"
+			child.Value = time.Duration(field.Int()).String()
+		case field.Kind() == reflect.Struct:
+			formatConfigDurations(child, field)
+		}
+	}
+}
+
+// DefaultConfigFile returns the path of the kubesel configuration file.
+// This is `$XDG_CONFIG_HOME/kubesel/config.yaml`, unless the KUBESEL_CONFIG
+// environment variable is set.
+func DefaultConfigFile() string {
+	if file := os.Getenv(ConfigFileEnvVar); file != "" {
+		return file
+	}
+
+	return filepath.Join(findConfigHomeDir(), "kubesel", "config.yaml")
+}
+
+// LoadConfig loads the configuration file at [DefaultConfigFile]. The file
+// is only read once, and later calls return the same [Config].
+func LoadConfig() (*Config, error) {
+	return loadDefaultConfig()
+}
+
+var loadDefaultConfig = sync.OnceValues(func() (*Config, error) {
+	return LoadConfigFile(DefaultConfigFile())
+})
+
+// LoadConfigFile loads a kubesel configuration file. Options that are not
+// in the file are given their values from [DefaultConfig]. If the file does
+// not exist, the default config is returned.
+//
+// If the file is invalid, this returns a [ConfigError].
+func LoadConfigFile(file string) (*Config, error) {
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return DefaultConfig(), nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error reading config file: %w", err)
+	}
+
+	config, errs := parseConfig(data)
+	if len(errs) > 0 {
+		return nil, &ConfigError{Path: file, Errors: errs}
+	}
+
+	return config, nil
+}
+
+// ConfigKeys returns the keys of all the options in the [Config], in the
+// form used by [SetConfigFileValue].
+func ConfigKeys() []string {
+	return appendConfigKeys(nil, "", reflect.TypeFor[Config]())
+}
+
+func appendConfigKeys(keys []string, prefix string, typ reflect.Type) []string {
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		if field.Type.Kind() == reflect.Struct {
+			keys = appendConfigKeys(keys, prefix+name+".", field.Type)
+		} else {
+			keys = append(keys, prefix+name)
+		}
+	}
+
+	return keys
+}
+
+// SetConfigFileValue changes a single option in a kubesel configuration file.
+// The key is the option's path, separated by dots (e.g. `list.output`), and
+// the value is parsed as YAML. The rest of the file is left as-is, including
+// its comments.
+//
+// If the changed file would be invalid, it is not saved and a [ConfigError]
+// is returned.
+func SetConfigFileValue(file string, key string, value string) error {
+	data, err := os.ReadFile(file)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("error reading config file: %w", err)
+	}
+
+	// Parse the file and the value.
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return &ConfigError{Path: file, Errors: newConfigYamlErrors(err)}
+	}
+
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{
+			Kind:    yaml.DocumentNode,
+			Content: []*yaml.Node{{Kind: yaml.MappingNode}},
+		}
+	}
+
+	var valueDoc yaml.Node
+	if err := yaml.Unmarshal([]byte(value), &valueDoc); err != nil || len(valueDoc.Content) == 0 {
+		valueDoc.Content = []*yaml.Node{{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}}
+	}
+
+	// Find the mapping containing the key, creating it if necessary.
+	node := doc.Content[0]
+	if node.Kind != yaml.MappingNode {
+		return &ConfigError{Path: file, Errors: []error{
+			&ConfigFieldError{Message: "the config file is not a mapping"},
+		}}
+	}
+
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		child := findConfigSection(node, part)
+		if child == nil {
+			child = &yaml.Node{Kind: yaml.MappingNode}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: part}, child)
+		}
+
+		if child.Kind != yaml.MappingNode {
+			return &ConfigError{Path: file, Errors: []error{
+				&ConfigFieldError{Field: key, Message: fmt.Sprintf("%s is not a section", part)},
+			}}
+		}
+
+		node = child
+	}
+
+	// Replace the value.
+	last := parts[len(parts)-1]
+	if existing := findConfigSection(node, last); existing != nil {
+		*existing = *valueDoc.Content[0]
+	} else {
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: last}, valueDoc.Content[0])
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return fmt.Errorf("marshalling config file: %w", err)
+	}
+
+	// Check it before saving it.
+	if _, errs := parseConfig(buf.Bytes()); len(errs) > 0 {
+		return &ConfigError{Path: file, Errors: errs}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+		return fmt.Errorf("mkdir: %w", err)
+	}
+
+	if err := writeFileAtomically(file, buf.Bytes()); err != nil {
+		return fmt.Errorf("error saving config file: %w", err)
+	}
+
+	return nil
+}
+
+// Validate checks that the values in the config are valid.
+// If any of them are not, the errors are [ConfigFieldError] instances.
+func (c *Config) Validate() error {
+	return errors.Join(c.validate()...)
+}
+
+func (c *Config) validate() []error {
+	var errs []error
+	invalid := func(field string, pattern string, args ...any) {
+		errs = append(errs, &ConfigFieldError{
+			Field:   field,
+			Message: fmt.Sprintf(pattern, args...),
+		})
+	}
+
+	switch c.Color {
+	case "", "auto", "always", "never":
+	default:
+		invalid("color", "must be auto, always, or never")
+	}
+
+	switch c.GC.Aggressiveness {
+	case "", "off", "low", "normal", "high":
+	default:
+		invalid("gc.aggressiveness", "must be off, low, normal, or high")
+	}
+
+	if c.KubeconfigCommandTimeout < 0 {
+		invalid("kubeconfig-command-timeout", "cannot be negative")
+	}
+
+	if c.Init.KubeconfigCommandTTL < 0 {
+		invalid("init.kubeconfig-command-ttl", "cannot be negative")
+	}
+
+	for _, pattern := range c.ProtectedContexts {
+		if _, err := path.Match(pattern, ""); err != nil {
+			invalid("protected-contexts", "invalid pattern %q", pattern)
+		}
+	}
+
+	for alias, name := range c.ContextAliases {
+		if alias == "" || name == "" {
+			invalid("context-aliases", "aliases and context names cannot be empty")
+		}
+	}
+
+	return errs
+}
+
+// IsProtectedContext returns true if the context matches one of the
+// [Config.ProtectedContexts] patterns.
+func (c *Config) IsProtectedContext(name string) bool {
+	for _, pattern := range c.ProtectedContexts {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseConfig parses the contents of a configuration file on top of the
+// [DefaultConfig]. Unknown options are considered errors.
+func parseConfig(data []byte) (*Config, []error) {
+	config := DefaultConfig()
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	err := decoder.Decode(config)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, newConfigYamlErrors(err)
+	}
+
+	if errs := config.validate(); len(errs) > 0 {
+		return nil, errs
+	}
+
+	return config, nil
+}
+
+// unknownConfigFieldRegex matches the error returned by the YAML decoder
+// when the config file contains an unknown option.
+var unknownConfigFieldRegex = regexp.MustCompile(`^(line \d+: )?field (\S+) not found in type \S+$`)
+
+// newConfigYamlErrors converts an error from the YAML decoder into a list of
+// [ConfigFieldError] instances.
+func newConfigYamlErrors(err error) []error {
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		errs := make([]error, 0, len(typeErr.Errors))
+		for _, message := range typeErr.Errors {
+			message = unknownConfigFieldRegex.ReplaceAllString(message, "${1}unknown option ${2}")
+			errs = append(errs, &ConfigFieldError{Message: message})
+		}
+
+		return errs
+	}
+
+	return []error{&ConfigFieldError{
+		Message: strings.TrimPrefix(err.Error(), "yaml: "),
+	}}
+}
+
+// findConfigSection returns the value of a key inside a YAML mapping node,
+// or nil if the key does not exist.
+func findConfigSection(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+// findConfigHomeDir returns the XDG_CONFIG_HOME directory.
+// If the environment variable is set, it will be used.
+//
+// Otherwise, it should be ~/.config
+func findConfigHomeDir() string {
+	configHome, ok := os.LookupEnv("XDG_CONFIG_HOME")
+	if ok {
+		return configHome
+	}
+
+	// Command-line tools on Darwin should be similar to Linux.
+	if runtime.GOOS == "darwin" {
+		return filepath.Join(xdg.Home, ".config")
+	}
+
+	// Use the library.
+	return xdg.ConfigHome
+}
+
+// ConfigError is returned when the kubesel configuration file is invalid.
+type ConfigError struct {
+	Path   string
+	Errors []error
+}
+
+func (e *ConfigError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		messages = append(messages, err.Error())
+	}
+
+	return fmt.Sprintf("invalid config file %s: %s", e.Path, strings.Join(messages, "; "))
+}
+
+func (e *ConfigError) Unwrap() []error {
+	return e.Errors
+}
+
+// ConfigFieldError describes a problem with the kubesel configuration file.
+// If the problem is with a specific option, the Field is its path separated
+// by dots (e.g. `list.output`).
+type ConfigFieldError struct {
+	Field   string
+	Message string
+}
+
+func (e *ConfigFieldError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+func (e *ConfigFieldError) Unwrap() error {
+	return ErrInvalidConfig
+}
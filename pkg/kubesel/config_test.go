@@ -0,0 +1,257 @@
+package kubesel
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lithammer/dedent"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseConfig(t *testing.T) {
+	testcases := map[string]struct {
+		Config         string
+		Expected       func(config *Config)
+		ExpectedErrors []string
+	}{
+		"Empty file is the default config": {
+			Config:   "",
+			Expected: func(config *Config) {},
+		},
+
+		"Options are parsed on top of the defaults": {
+			Config: dedent.Dedent(`
+				color: never
+				init:
+				  kubeconfig-command-ttl: 1h
+				protected-contexts: [prod-*]
+			`),
+			Expected: func(config *Config) {
+				config.Color = "never"
+				config.Init.KubeconfigCommandTTL = time.Hour
+				config.ProtectedContexts = []string{"prod-*"}
+			},
+		},
+
+		"Unknown options are errors": {
+			Config: dedent.Dedent(`
+				color: never
+				colour: always
+			`),
+			ExpectedErrors: []string{"line 3: unknown option colour"},
+		},
+
+		"Unknown nested options are errors": {
+			Config: dedent.Dedent(`
+				list:
+				  format: table
+			`),
+			ExpectedErrors: []string{"line 3: unknown option format"},
+		},
+
+		"Bad durations are errors": {
+			Config: dedent.Dedent(`
+				kubeconfig-command-timeout: soon
+			`),
+			ExpectedErrors: []string{"line 2: cannot unmarshal !!str `soon` into time.Duration"},
+		},
+
+		"Negative durations are errors": {
+			Config: dedent.Dedent(`
+				kubeconfig-command-timeout: -1s
+				init:
+				  kubeconfig-command-ttl: -1s
+			`),
+			ExpectedErrors: []string{
+				"kubeconfig-command-timeout: cannot be negative",
+				"init.kubeconfig-command-ttl: cannot be negative",
+			},
+		},
+
+		"Invalid values are errors": {
+			Config: dedent.Dedent(`
+				color: sometimes
+				gc:
+				  aggressiveness: extreme
+				protected-contexts: ["prod-["]
+			`),
+			ExpectedErrors: []string{
+				"color: must be auto, always, or never",
+				"gc.aggressiveness: must be off, low, normal, or high",
+				`protected-contexts: invalid pattern "prod-["`,
+			},
+		},
+
+		"Empty context aliases are errors": {
+			Config: dedent.Dedent(`
+				context-aliases:
+				  prod: ""
+			`),
+			ExpectedErrors: []string{"context-aliases: aliases and context names cannot be empty"},
+		},
+	}
+
+	t.Parallel()
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			config, errs := parseConfig([]byte(tc.Config))
+			if tc.ExpectedErrors != nil {
+				require.Nil(t, config)
+
+				messages := make([]string, 0, len(errs))
+				for _, err := range errs {
+					require.True(t, errors.Is(err, ErrInvalidConfig), "error wraps ErrInvalidConfig: %v", err)
+					messages = append(messages, err.Error())
+				}
+
+				require.Equal(t, tc.ExpectedErrors, messages)
+				return
+			}
+
+			require.Empty(t, errs)
+			expected := DefaultConfig()
+			tc.Expected(expected)
+			require.Equal(t, expected, config)
+		})
+	}
+}
+
+func TestMarshalConfigWritesDurationsAsStrings(t *testing.T) {
+	t.Parallel()
+
+	config := DefaultConfig()
+	config.KubeconfigCommandTimeout = 5 * time.Second
+	config.Init.KubeconfigCommandTTL = 90 * time.Minute
+
+	data, err := yaml.Marshal(config)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "kubeconfig-command-timeout: 5s\n")
+	require.Contains(t, string(data), "kubeconfig-command-ttl: 1h30m0s\n")
+
+	// It can be read back.
+	parsed, errs := parseConfig(data)
+	require.Empty(t, errs)
+	require.Equal(t, config, parsed)
+}
+
+func TestSetConfigFileValue(t *testing.T) {
+	testcases := map[string]struct {
+		Config   string
+		Key      string
+		Value    string
+		Expected string
+	}{
+		"Creates the file": {
+			Config: "",
+			Key:    "color",
+			Value:  "never",
+			Expected: dedent.Dedent(`
+				color: never
+			`)[1:],
+		},
+
+		"Replaces a value and keeps comments": {
+			Config: dedent.Dedent(`
+				# My config.
+				color: always # for my terminal
+				# The list command.
+				list:
+				  output: table
+			`)[1:],
+			Key:   "list.output",
+			Value: "columns",
+			Expected: dedent.Dedent(`
+				# My config.
+				color: always # for my terminal
+				# The list command.
+				list:
+				  output: columns
+			`)[1:],
+		},
+
+		"Adds a missing section": {
+			Config: dedent.Dedent(`
+				# My config.
+				color: always
+			`)[1:],
+			Key:   "gc.aggressiveness",
+			Value: "low",
+			Expected: dedent.Dedent(`
+				# My config.
+				color: always
+				gc:
+				  aggressiveness: low
+			`)[1:],
+		},
+
+		"Parses the value as YAML": {
+			Config: dedent.Dedent(`
+				color: always
+			`)[1:],
+			Key:   "protected-contexts",
+			Value: "[prod-*, staging]",
+			Expected: dedent.Dedent(`
+				color: always
+				protected-contexts: [prod-*, staging]
+			`)[1:],
+		},
+	}
+
+	t.Parallel()
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			file := filepath.Join(t.TempDir(), "config.yaml")
+			if tc.Config != "" {
+				require.NoError(t, os.WriteFile(file, []byte(tc.Config), 0o600))
+			}
+
+			require.NoError(t, SetConfigFileValue(file, tc.Key, tc.Value))
+
+			actual, err := os.ReadFile(file)
+			require.NoError(t, err)
+			require.Equal(t, tc.Expected, string(actual))
+
+			_, err = LoadConfigFile(file)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestSetConfigFileValueRefusesInvalidConfig(t *testing.T) {
+	testcases := map[string]struct {
+		Key   string
+		Value string
+	}{
+		"Unknown option": {Key: "colour", Value: "never"},
+		"Invalid value":  {Key: "color", Value: "sometimes"},
+		"Bad duration":   {Key: "init.kubeconfig-command-ttl", Value: "soon"},
+		"Not a section":  {Key: "color.value", Value: "never"},
+	}
+
+	t.Parallel()
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			original := "# My config.\ncolor: always\n"
+			file := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(file, []byte(original), 0o600))
+
+			err := SetConfigFileValue(file, tc.Key, tc.Value)
+			var configErr *ConfigError
+			require.ErrorAs(t, err, &configErr)
+
+			actual, err := os.ReadFile(file)
+			require.NoError(t, err)
+			require.Equal(t, original, string(actual), "file is unchanged")
+		})
+	}
+}
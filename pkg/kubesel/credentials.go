@@ -0,0 +1,170 @@
+package kubesel
+
+import (
+	"encoding/base64"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/eth-p/kubesel/pkg/kubeconfig"
+	"github.com/eth-p/kubesel/pkg/kubeconfig/kcutils"
+	"github.com/eth-p/kubesel/pkg/kubeconfig/loader"
+)
+
+// ExecCredentialApiVersion is the version of the client-go credential plugin
+// API used by [Kubesel.GetExecCredential].
+const ExecCredentialApiVersion = "client.authentication.k8s.io/v1"
+
+// CredentialExpiryWarning is how long before its credentials expire that a
+// user is considered to be expiring soon.
+const CredentialExpiryWarning = 24 * time.Hour
+
+// UserCredentials describes when the credentials of a user defined in a
+// kubeconfig file expire.
+type UserCredentials struct {
+	// Name is the name of the user.
+	Name string
+
+	// Path is the kubeconfig file defining the user.
+	Path string
+
+	// Expiries are the credentials that expire.
+	Expiries []kcutils.CredentialExpiry
+
+	// Err is the error encountered while reading the credentials.
+	Err error
+}
+
+// Earliest returns the credential that expires first, ignoring the ones
+// that are renewed automatically.
+func (c *UserCredentials) Earliest() (kcutils.CredentialExpiry, bool) {
+	return kcutils.EarliestExpiry(c.Expiries)
+}
+
+// IsExpired returns true if a credential has expired at the given time.
+func (c *UserCredentials) IsExpired(now time.Time) bool {
+	earliest, ok := c.Earliest()
+	return ok && !earliest.Expires.After(now)
+}
+
+// IsExpiringSoon returns true if a credential will expire within the
+// [CredentialExpiryWarning] of the given time.
+func (c *UserCredentials) IsExpiringSoon(now time.Time) bool {
+	earliest, ok := c.Earliest()
+	return ok && earliest.Expires.Before(now.Add(CredentialExpiryWarning))
+}
+
+// GetUserCredentials returns the credentials of the named user, as it is
+// defined in the merged kubeconfig. If the user does not exist, nil is
+// returned.
+func (k *Kubesel) GetUserCredentials(name string) *UserCredentials {
+	source := k.GetProvenance().AuthInfos.DefinedBy(name)
+	if source == nil {
+		return nil
+	}
+
+	return userCredentialsIn(name, source)
+}
+
+// CheckUserCredentials returns the credentials of every user in the
+// kubeconfig files, including the users shadowed by ones with the same
+// name in an earlier file.
+func (k *Kubesel) CheckUserCredentials() []UserCredentials {
+	var results []UserCredentials
+	sources := k.GetProvenance().AuthInfos
+	for _, name := range k.GetAuthInfoNames() {
+		checked := make(map[*loader.LoadedKubeconfig]bool)
+		for _, source := range sources[name] {
+			if checked[source] || k.IsManagedKubeconfigPath(source.Path) {
+				continue
+			}
+
+			checked[source] = true
+			results = append(results, *userCredentialsIn(name, source))
+		}
+	}
+
+	return results
+}
+
+func userCredentialsIn(name string, source *loader.LoadedKubeconfig) *UserCredentials {
+	expiries, err := kcutils.FindCredentialExpiries(
+		kcutils.FindAuthInfo(name, &source.Config),
+		filepath.Dir(source.Path),
+	)
+
+	return &UserCredentials{
+		Name:     name,
+		Path:     source.Path,
+		Expiries: expiries,
+		Err:      err,
+	}
+}
+
+// ExecCredential holds the credentials of a user in the format printed by a
+// client-go credential plugin.
+type ExecCredential struct {
+	ApiVersion string               `json:"apiVersion"`
+	Kind       string               `json:"kind"`
+	Status     ExecCredentialStatus `json:"status"`
+}
+
+// ExecCredentialStatus holds the credentials inside an [ExecCredential].
+type ExecCredentialStatus struct {
+	Token                 string `json:"token,omitempty"`
+	ClientCertificateData string `json:"clientCertificateData,omitempty"`
+	ClientKeyData         string `json:"clientKeyData,omitempty"`
+}
+
+// GetExecCredential returns the token and client certificate of the named
+// user, reading them from files if needed. This is what the [Options]
+// CredentialCommand is expected to print.
+func (k *Kubesel) GetExecCredential(name string) (*ExecCredential, error) {
+	var authInfo *kubeconfig.AuthInfo
+	source := k.GetProvenance().AuthInfos.DefinedBy(name)
+	if source != nil {
+		authInfo = kcutils.FindAuthInfo(name, &source.Config).Clone()
+	}
+
+	if authInfo == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownUser, name)
+	}
+
+	err := kcutils.FlattenAuthInfo(authInfo, filepath.Dir(source.Path))
+	if err != nil {
+		return nil, err
+	}
+
+	credential := &ExecCredential{
+		ApiVersion: ExecCredentialApiVersion,
+		Kind:       "ExecCredential",
+	}
+
+	if authInfo.Token != nil {
+		credential.Status.Token = *authInfo.Token
+	}
+
+	// Kubeconfig files store the PEM-encoded certificate and key as base64,
+	// but credential plugins print the PEM data directly.
+	for _, field := range []struct {
+		name string
+		data *string
+		dest *string
+	}{
+		{"client-certificate-data", authInfo.ClientCertificateData, &credential.Status.ClientCertificateData},
+		{"client-key-data", authInfo.ClientKeyData, &credential.Status.ClientKeyData},
+	} {
+		if field.data == nil {
+			continue
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(*field.data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", field.name, err)
+		}
+
+		*field.dest = string(decoded)
+	}
+
+	return credential, nil
+}
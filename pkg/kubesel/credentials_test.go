@@ -0,0 +1,87 @@
+package kubesel
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lithammer/dedent"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetExecCredential(t *testing.T) {
+	dir := t.TempDir()
+	kcPath := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "token"), []byte("file-token\n"), 0o600))
+	require.NoError(t, os.WriteFile(kcPath, []byte(dedent.Dedent(`
+		apiVersion: v1
+		kind: Config
+		users:
+		  - name: inline
+		    user:
+		      token: inline-token
+		      client-certificate-data: Q0VSVElGSUNBVEU=
+		      client-key-data: S0VZ
+		  - name: from-file
+		    user: {tokenFile: token}
+		  - name: bad-data
+		    user: {client-certificate-data: "not base64!"}
+	`)), 0o600))
+
+	ksel, err := New(Options{
+		KubeconfigPaths: []string{kcPath},
+		DataDir:         filepath.Join(dir, "data"),
+	})
+	require.NoError(t, err)
+
+	testcases := map[string]struct {
+		User        string
+		Expected    ExecCredentialStatus
+		ExpectedErr bool
+	}{
+		"Inline credentials are decoded": {
+			User: "inline",
+			Expected: ExecCredentialStatus{
+				Token:                 "inline-token",
+				ClientCertificateData: "CERTIFICATE",
+				ClientKeyData:         "KEY",
+			},
+		},
+		"Files are read relative to the kubeconfig": {
+			User:     "from-file",
+			Expected: ExecCredentialStatus{Token: "file-token"},
+		},
+		"Invalid base64": {
+			User:        "bad-data",
+			ExpectedErr: true,
+		},
+	}
+
+	t.Parallel()
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			credential, err := ksel.GetExecCredential(tc.User)
+			if tc.ExpectedErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, ExecCredentialApiVersion, credential.ApiVersion)
+			require.Equal(t, "ExecCredential", credential.Kind)
+			require.Equal(t, tc.Expected, credential.Status)
+		})
+	}
+}
+
+func TestGetExecCredentialForUnknownUser(t *testing.T) {
+	t.Parallel()
+
+	ksel, err := New(newSessionTestOptions(t))
+	require.NoError(t, err)
+
+	_, err = ksel.GetExecCredential("no-such-user")
+	require.ErrorIs(t, err, ErrUnknownUser)
+}
@@ -1,13 +1,109 @@
 package kubesel
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
+	"time"
 
 	"github.com/adrg/xdg"
+	"github.com/eth-p/kubesel/pkg/kubeconfig/loader"
 )
 
+// DataDirEnvVar is the environment variable containing the directory where
+// kubesel stores its sessions and caches.
+const DataDirEnvVar = "KUBESEL_DATA_DIR"
+
+// KubeconfigDirsEnvVar is the environment variable containing the list of
+// directories that kubeconfig files are sourced from.
+const KubeconfigDirsEnvVar = "KUBESEL_KUBECONFIG_DIRS"
+
+// KubeconfigCommandsEnvVar is the environment variable containing the list of
+// commands that print kubeconfig files, separated by newlines.
+const KubeconfigCommandsEnvVar = "KUBESEL_KUBECONFIG_COMMANDS"
+
+// KubeconfigCommandTTLEnvVar is the environment variable containing how long
+// the output of a kubeconfig command is reused before running it again.
+const KubeconfigCommandTTLEnvVar = "KUBESEL_KUBECONFIG_COMMAND_TTL"
+
+// DefaultKubeconfigCommandTTL is how long the output of a kubeconfig command
+// is reused if the KUBESEL_KUBECONFIG_COMMAND_TTL environment variable is
+// not set.
+const DefaultKubeconfigCommandTTL = 15 * time.Minute
+
+// DefaultKubeconfigCommandTimeout is how long a kubeconfig command can run
+// if the `kubeconfig-command-timeout` option is not set in the config file.
+const DefaultKubeconfigCommandTimeout = 10 * time.Second
+
+// DefaultOptions returns the [Options] used by the kubesel command. These are
+// read from the environment and the kubesel configuration file:
+//
+//   - The kubeconfig files are found the same way kubectl finds them, using
+//     the `KUBECONFIG` environment variable or `~/.kube/config`.
+//   - The data directory is `$KUBESEL_DATA_DIR`, the `data-dir` option in
+//     the config file, or `$XDG_DATA_HOME/kubesel`.
+//   - The kubeconfig directories, commands, and command TTL are read from
+//     the `KUBESEL_KUBECONFIG_*` environment variables.
+//   - The kubeconfig command timeout is the `kubeconfig-command-timeout`
+//     option in the config file.
+//   - The config file is loaded with [LoadConfig].
+func DefaultOptions() (Options, error) {
+	config, err := LoadConfig()
+	if err != nil {
+		return Options{}, err
+	}
+
+	return DefaultOptionsForConfig(config)
+}
+
+// DefaultOptionsForConfig is like [DefaultOptions], but uses the provided
+// [Config] instead of loading the config file.
+func DefaultOptionsForConfig(config *Config) (Options, error) {
+	kcFiles, err := loader.FindKubeConfigFilesFromEnv()
+	if err != nil {
+		return Options{}, fmt.Errorf("error finding kubeconfig files: %w", err)
+	}
+
+	dataDir := DefaultDataDir()
+	if os.Getenv(DataDirEnvVar) == "" && config.DataDir != "" {
+		dataDir = expandHomeDir(config.DataDir)
+	}
+
+	return Options{
+		Config:               config,
+		KubeconfigPaths:      kcFiles,
+		DataDir:              dataDir,
+		KubeconfigDirs:       findKubeconfigSourceDirs(),
+		KubeconfigCommands:   findKubeconfigCommands(),
+		KubeconfigCommandTTL: findKubeconfigCommandTTL(),
+
+		KubeconfigCommandTimeout: config.KubeconfigCommandTimeout,
+	}, nil
+}
+
+// DefaultDataDir returns the directory where kubesel stores its sessions and
+// caches by default. If the KUBESEL_DATA_DIR environment variable is set, it
+// will be used.
+func DefaultDataDir() string {
+	if dataDir := os.Getenv(DataDirEnvVar); dataDir != "" {
+		return filepath.Clean(dataDir)
+	}
+
+	return filepath.Join(findDataHomeDir(), "kubesel")
+}
+
+// expandHomeDir replaces a leading `~` in the path with the user's home
+// directory.
+func expandHomeDir(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~"); ok && (rest == "" || rest[0] == '/') {
+		return filepath.Join(xdg.Home, rest)
+	}
+
+	return filepath.Clean(path)
+}
+
 // findDataHomeDir returns the XDG_DATA_HOME directory.
 // If the environment variable is set, it will be used.
 //
@@ -26,3 +122,41 @@ func findDataHomeDir() string {
 	// Use the library.
 	return xdg.DataHome
 }
+
+// findKubeconfigSourceDirs returns the directories listed in the
+// KUBESEL_KUBECONFIG_DIRS environment variable.
+func findKubeconfigSourceDirs() []string {
+	var dirs []string
+	for _, dir := range filepath.SplitList(os.Getenv(KubeconfigDirsEnvVar)) {
+		if dir != "" {
+			dirs = append(dirs, filepath.Clean(dir))
+		}
+	}
+
+	return dirs
+}
+
+// findKubeconfigCommands returns the commands listed in the
+// KUBESEL_KUBECONFIG_COMMANDS environment variable.
+func findKubeconfigCommands() []string {
+	var commands []string
+	for _, command := range strings.Split(os.Getenv(KubeconfigCommandsEnvVar), "\n") {
+		if command = strings.TrimSpace(command); command != "" {
+			commands = append(commands, command)
+		}
+	}
+
+	return commands
+}
+
+// findKubeconfigCommandTTL returns the duration in the
+// KUBESEL_KUBECONFIG_COMMAND_TTL environment variable, or the default if
+// it is unset or invalid.
+func findKubeconfigCommandTTL() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv(KubeconfigCommandTTLEnvVar))
+	if err != nil || ttl < 0 {
+		return DefaultKubeconfigCommandTTL
+	}
+
+	return ttl
+}
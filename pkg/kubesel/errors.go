@@ -17,6 +17,66 @@ var (
 	// ErrUnmanaged is returned when a  [ManagedKubeconfig] does not exist.
 	ErrUnmanaged = errors.New("no kubesel-managed kubeconfig file")
 
+	// ErrEditingManaged is returned when trying to edit a kubesel-managed
+	// kubeconfig file as if it were a regular kubeconfig file.
+	ErrEditingManaged = errors.New("cannot edit a kubesel-managed kubeconfig file")
+
+	// ErrEditingGenerated is returned when trying to edit a kubeconfig file
+	// that was generated by a kubeconfig command.
+	ErrEditingGenerated = errors.New("cannot edit a kubeconfig file generated by a command")
+
+	// ErrKubeconfigCommandFailed is returned when a kubeconfig command
+	// fails or does not print a valid kubeconfig.
+	ErrKubeconfigCommandFailed = errors.New("kubeconfig command failed")
+
+	// ErrNoWritableKubeconfig is returned when there are no kubeconfig files
+	// that new items can be added to.
+	ErrNoWritableKubeconfig = errors.New("no kubeconfig file to write to")
+
+	// ErrInvalidConfig is returned when the kubesel configuration file
+	// contains an invalid value.
+	ErrInvalidConfig = errors.New("invalid config")
+
+	// ErrNoDataDir is returned when creating a [Kubesel] without a directory
+	// to store its sessions in.
+	ErrNoDataDir = errors.New("no kubesel data directory")
+
+	// ErrUnknownContext is returned when switching to a context that does
+	// not exist.
+	ErrUnknownContext = errors.New("unknown context")
+
+	// ErrUnknownCluster is returned when switching to a cluster that does
+	// not exist.
+	ErrUnknownCluster = errors.New("unknown cluster")
+
+	// ErrUnknownUser is returned when switching to a user that does not
+	// exist.
+	ErrUnknownUser = errors.New("unknown user")
+
+	// ErrNothingToOverride is returned when trying to override the cluster
+	// or user of a session that does not have one.
+	ErrNothingToOverride = errors.New("nothing to override")
+
+	// ErrNoImpersonatedUser is returned when trying to impersonate groups or
+	// extra information without impersonating a user.
+	ErrNoImpersonatedUser = errors.New("no user to impersonate")
+
+	// ErrReservedName is returned when overriding a cluster or user while a
+	// kubeconfig file uses the name reserved for the session-scoped copy.
+	ErrReservedName = errors.New("name is reserved for kubesel sessions")
+
+	// ErrCannotOverrideUser is returned when trying to override a user whose
+	// credentials cannot be used without copying them into the session.
+	ErrCannotOverrideUser = errors.New("cannot override user")
+
+	// ErrCredentialsExpired is returned when checking the credentials of
+	// users finds some that have expired.
+	ErrCredentialsExpired = errors.New("credentials expired")
+
+	// ErrInvalidLabelSelector is returned when a label selector cannot be
+	// parsed.
+	ErrInvalidLabelSelector = errors.New("invalid label selector")
+
 	// ErrOwnerProcessNotExist is returned when trying to create a
 	// [ManagedKubeconfig] whose owner is not a living process.
 	ErrOwnerProcessNotExist = errors.New("owner process does not exist")
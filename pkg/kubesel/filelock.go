@@ -0,0 +1,84 @@
+package kubesel
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	// fileLockTimeout is how long to wait for another kubesel process to
+	// release a lock file before giving up.
+	fileLockTimeout = 2 * time.Second
+
+	// fileLockRetryInterval is how long to wait before trying to take a lock
+	// file again.
+	fileLockRetryInterval = 10 * time.Millisecond
+
+	// fileLockStaleAge is how old a lock file must be before it is assumed
+	// to be left behind by a kubesel process that exited without releasing
+	// it.
+	fileLockStaleAge = 10 * time.Second
+)
+
+// lockFile prevents other kubesel processes from changing a file until the
+// returned unlock function is called. This is used around read-modify-write
+// updates, so concurrent updates aren't lost.
+//
+// The lock is a `.lock` file next to the file, which is created exclusively.
+// This works the same on every platform, unlike flock(2).
+func lockFile(path string) (unlock func(), err error) {
+	lockPath := path + ".lock"
+	deadline := time.Now().Add(fileLockTimeout)
+	for {
+		file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			_ = file.Close()
+			return func() { _ = os.Remove(lockPath) }, nil
+		}
+
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("creating lock file: %w", err)
+		}
+
+		if isStaleLockFile(lockPath) {
+			breakStaleLock(lockPath)
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for lock file %s", lockPath)
+		}
+
+		time.Sleep(fileLockRetryInterval)
+	}
+}
+
+// isStaleLockFile returns true if the lock file is old enough to have been
+// left behind by a kubesel process that exited without releasing it.
+func isStaleLockFile(lockPath string) bool {
+	stat, err := os.Stat(lockPath)
+	return err == nil && time.Since(stat.ModTime()) > fileLockStaleAge
+}
+
+// breakStaleLock removes a stale lock file.
+//
+// Other processes may have found the same stale lock, and one of them may
+// have already replaced it with a new lock. Rather than removing whatever is
+// at the path, the lock file is atomically renamed aside and checked again.
+// If it turns out to be a new lock, it is put back where it was.
+func breakStaleLock(lockPath string) {
+	aside := fmt.Sprintf("%s.%d.stale", lockPath, os.Getpid())
+	if err := os.Rename(lockPath, aside); err != nil {
+		return
+	}
+
+	if !isStaleLockFile(aside) {
+		// Linking fails instead of replacing the lock if it was taken again,
+		// which can only happen in the moment that it was moved aside.
+		_ = os.Link(aside, lockPath)
+	}
+
+	_ = os.Remove(aside)
+}
@@ -0,0 +1,96 @@
+package kubesel
+
+import (
+	"embed"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"al.essio.dev/pkg/shellescape"
+)
+
+//go:embed shell-init/init.*
+var initScripts embed.FS
+
+// InitScriptShells are the shells supported by [InitScript].
+var InitScriptShells = []string{
+	"bash",
+	"fish",
+	"zsh",
+}
+
+// InitScriptOptions are the options for [InitScript].
+type InitScriptOptions struct {
+	// Executable is the path of the kubesel executable. The script runs it
+	// to create the session.
+	Executable string
+
+	// KubeconfigFiles are added to the `KUBECONFIG` environment variable
+	// before creating the session.
+	KubeconfigFiles []string
+
+	// KubeconfigDirs are the directories that kubeconfig files are sourced
+	// from in the session.
+	KubeconfigDirs []string
+
+	// KubeconfigCommands are shell commands that print kubeconfig files for
+	// the session.
+	KubeconfigCommands []string
+
+	// KubeconfigCommandTTL is how long the output of the kubeconfig commands
+	// is reused in the session. If nil, it is left unset so the session
+	// uses [DefaultKubeconfigCommandTTL].
+	KubeconfigCommandTTL *time.Duration
+
+	// LoadCompletions makes the script load the shell completions for the
+	// kubesel executable.
+	LoadCompletions bool
+}
+
+// InitScript returns a shell script that creates a new kubesel session for
+// the shell that sources it. The supported shells are listed in
+// [InitScriptShells].
+func InitScript(shell string, opts InitScriptOptions) (string, error) {
+	templateSource, err := initScripts.ReadFile("shell-init/init." + shell)
+	if err != nil {
+		return "", fmt.Errorf("unsupported shell: %s", shell)
+	}
+
+	// Parse the script as a Go template.
+	tpl := template.New("init-script").
+		Funcs(template.FuncMap{
+			"shellquote": shellescape.Quote,
+			"join":       strings.Join,
+		})
+
+	tpl, err = tpl.Parse(string(templateSource))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse %s init script as template: %w", shell, err)
+	}
+
+	kubeconfigCommandTTL := ""
+	if opts.KubeconfigCommandTTL != nil {
+		kubeconfigCommandTTL = opts.KubeconfigCommandTTL.String()
+	}
+
+	// Evaluate the template.
+	var sb strings.Builder
+	err = tpl.Execute(&sb, map[string]any{
+		"kubesel_executable":  opts.Executable,
+		"kubesel_name":        filepath.Base(opts.Executable),
+		"load_completions":    opts.LoadCompletions,
+		"add_kubeconfigs":     opts.KubeconfigFiles,
+		"add_kubeconfig_dirs": opts.KubeconfigDirs,
+
+		"add_kubeconfig_commands": opts.KubeconfigCommands,
+		"kubeconfig_command_ttl":  kubeconfigCommandTTL,
+	})
+
+	if err != nil {
+		return "", fmt.Errorf("failed to evaluate %s init script template: %w", shell, err)
+	}
+
+	return sb.String(), nil
+}
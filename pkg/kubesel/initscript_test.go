@@ -0,0 +1,48 @@
+package kubesel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitScript(t *testing.T) {
+	ttl := time.Hour
+	opts := InitScriptOptions{
+		Executable:           "/opt/my tools/kubesel",
+		KubeconfigDirs:       []string{"/kube/dir"},
+		KubeconfigCommands:   []string{"kind get kubeconfig"},
+		KubeconfigCommandTTL: &ttl,
+	}
+
+	t.Parallel()
+	for _, shell := range InitScriptShells {
+		t.Run(shell, func(t *testing.T) {
+			t.Parallel()
+
+			script, err := InitScript(shell, opts)
+			require.NoError(t, err)
+			require.Contains(t, script, `'/opt/my tools/kubesel' __init`, "the executable is quoted")
+			require.Contains(t, script, "/kube/dir")
+			require.Contains(t, script, "'kind get kubeconfig'")
+			require.Contains(t, script, "1h0m0s")
+			require.NotContains(t, script, "completion", "completions are not loaded")
+
+			withCompletions := opts
+			withCompletions.LoadCompletions = true
+			withCompletions.KubeconfigCommandTTL = nil
+			script, err = InitScript(shell, withCompletions)
+			require.NoError(t, err)
+			require.Contains(t, script, "completion")
+			require.NotContains(t, script, KubeconfigCommandTTLEnvVar, "the TTL is left unset")
+		})
+	}
+}
+
+func TestInitScriptRejectsUnknownShells(t *testing.T) {
+	t.Parallel()
+
+	_, err := InitScript("powershell", InitScriptOptions{Executable: "kubesel"})
+	require.ErrorContains(t, err, "unsupported shell: powershell")
+}
@@ -1,10 +1,34 @@
 package kubesel
 
 const (
-	kcextApiVersion           = "dev.eth-p.kubesel/v1"
-	kcextManagedByKubeselKind = "ManagedByKubesel"
+	kcextApiVersion              = "dev.eth-p.kubesel/v1"
+	kcextManagedByKubeselKind    = "ManagedByKubesel"
+	kcextDecryptedKubeconfigKind = "DecryptedKubeconfig"
+	kcextLabelsKind              = "Labels"
+	kcextSessionOverridesKind    = "SessionOverrides"
 )
 
 type kcextManagedByKubesel struct {
 	Owner ownerData `json:"owner"`
 }
+
+type kcextDecryptedKubeconfig struct {
+	Source string `json:"source"`
+}
+
+type kcextLabels struct {
+	Labels map[string]string `json:"labels"`
+}
+
+// kcextSessionOverrides describes the session-scoped cluster and user of a
+// [ManagedKubeconfig]. The Cluster and User are the names of the items they
+// were derived from.
+type kcextSessionOverrides struct {
+	Cluster               string              `json:"cluster,omitempty"`
+	ProxyURL              string              `json:"proxyURL,omitempty"`
+	InsecureSkipTLSVerify bool                `json:"insecureSkipTLSVerify,omitempty"`
+	User                  string              `json:"user,omitempty"`
+	As                    string              `json:"as,omitempty"`
+	AsGroups              []string            `json:"asGroups,omitempty"`
+	AsUserExtra           map[string][]string `json:"asUserExtra,omitempty"`
+}
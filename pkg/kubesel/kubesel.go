@@ -5,67 +5,297 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/eth-p/kubesel/pkg/kubeconfig"
 	"github.com/eth-p/kubesel/pkg/kubeconfig/loader"
 )
 
 type Kubesel struct {
-	kubeconfigs *loader.LoadedKubeconfigCollection
+	// mu prevents [Kubesel.Reload] from happening while [Kubesel.Watch] is
+	// reloading the files or calling its onChange function.
+	mu sync.Mutex
 
+	kubeconfigPaths []string
+
+	config     *Config
 	dataDir    string
 	sessionDir string
+	sourceDirs []string
+	commands   []string
+	commandTTL time.Duration
+
+	// commandTimeout is how long a kubeconfig command can run before it is
+	// killed, and skipCommands prevents kubeconfig commands from running.
+	commandTimeout time.Duration
+	skipCommands   bool
+
+	// credentialCommand is the command used as an exec plugin by users
+	// derived from another user with credentials stored inline.
+	credentialCommand []string
+
+	// state is replaced as a whole by [Kubesel.Reload], so the getters can
+	// be used while [Kubesel.Watch] is reloading the files.
+	state atomic.Pointer[kubeselState]
+}
+
+// kubeselState is everything found by [Kubesel.Reload] and loaded lazily
+// from the files it found.
+type kubeselState struct {
+	kubeconfigFiles []string
 
+	// decryptedFiles are the encrypted kubeconfig files which kubectl reads
+	// through decrypted copies.
+	decryptedFiles []string
+
+	lazyKubeconfigs       func() *loader.LoadedKubeconfigCollection
+	loadedKubeconfigs     atomic.Bool
 	lazyManagedKubeconfig func() (*ManagedKubeconfig, error)
 	lazyClusterNames      func() []string
 	lazyAuthInfoNames     func() []string
 	lazyContextNames      func() []string
+	lazyContextAliases    func() map[string]string
+	lazyUsage             func() *usageFile
 }
 
-// NewKubesel reads the kubectl configuration files and sets up this instance
-// of kubesel.
-func NewKubesel() (*Kubesel, error) {
-	dataDir := filepath.Join(findDataHomeDir(), "kubesel")
-	sessionDir := filepath.Join(dataDir, "sessions")
+// Options are used to create a [Kubesel] instance with [New].
+//
+// Unlike [NewKubesel], nothing is read from environment variables. Use
+// [DefaultOptions] to get the options that the kubesel command uses.
+type Options struct {
+	// Config is the kubesel configuration. If nil, the [DefaultConfig] is
+	// used.
+	Config *Config
+
+	// KubeconfigPaths are the kubeconfig files to use, in the same order as
+	// they would appear in the `KUBECONFIG` environment variable.
+	KubeconfigPaths []string
+
+	// DataDir is the directory where kubesel stores its sessions and caches.
+	DataDir string
+
+	// KubeconfigDirs are the directories that kubeconfig files are sourced
+	// from, in addition to the KubeconfigPaths.
+	KubeconfigDirs []string
+
+	// KubeconfigCommands are shell commands that print kubeconfig files.
+	KubeconfigCommands []string
+
+	// KubeconfigCommandTTL is how long the output of a kubeconfig command is
+	// reused before running it again. If zero, the commands are run every
+	// time the kubeconfig files are loaded.
+	KubeconfigCommandTTL time.Duration
+
+	// KubeconfigCommandTimeout is how long a kubeconfig command can run
+	// before it is killed and its previous output is used instead. If zero,
+	// [DefaultKubeconfigCommandTimeout] is used.
+	KubeconfigCommandTimeout time.Duration
+
+	// SkipKubeconfigCommands prevents kubeconfig commands from running. The
+	// previous output of the commands is used, even if it is older than the
+	// TTL. This is useful for things that need to be fast, like completions.
+	SkipKubeconfigCommands bool
+
+	// CredentialCommand is a command that prints the credentials of the user
+	// named by its last argument as a client-go ExecCredential, such as
+	// `kubesel __credentials`. See [Kubesel.GetExecCredential].
+	//
+	// It is used when overriding a user whose credentials are stored inline
+	// in its kubeconfig file, so the credentials don't need to be copied into
+	// the session. If empty, those users cannot be overridden.
+	CredentialCommand []string
+}
 
-	// Load the kubeconfig files.
-	kcFiles, err := loader.FindKubeConfigFiles()
+// NewKubesel finds the kubectl configuration files and sets up this instance
+// of kubesel. The options are read from environment variables, as described
+// by [DefaultOptions].
+//
+// The files are loaded lazily. Getting the [ManagedKubeconfig] only reads the
+// session file, and the rest of the files are only read and merged once
+// their contents are needed.
+func NewKubesel() (*Kubesel, error) {
+	opts, err := DefaultOptions()
 	if err != nil {
-		return nil, fmt.Errorf("error finding kubeconfig files: %w", err)
+		return nil, err
 	}
 
-	kubeconfigs := loader.LoadMultipleFiles(kcFiles)
+	return New(opts)
+}
+
+// New sets up an instance of kubesel using the provided [Options].
+//
+// Like [NewKubesel], the files are loaded lazily.
+func New(opts Options) (*Kubesel, error) {
+	if opts.DataDir == "" {
+		return nil, ErrNoDataDir
+	}
+
+	sourceDirs := make([]string, 0, len(opts.KubeconfigDirs))
+	for _, dir := range opts.KubeconfigDirs {
+		sourceDirs = append(sourceDirs, filepath.Clean(dir))
+	}
+
+	config := opts.Config
+	if config == nil {
+		config = DefaultConfig()
+	}
+
+	commandTimeout := opts.KubeconfigCommandTimeout
+	if commandTimeout <= 0 {
+		commandTimeout = DefaultKubeconfigCommandTimeout
+	}
 
 	// Create the Kubesel instance.
 	kubesel := &Kubesel{
-		kubeconfigs: kubeconfigs,
-		sessionDir:  sessionDir,
-		dataDir:     dataDir,
+		config:          config,
+		kubeconfigPaths: slices.Clone(opts.KubeconfigPaths),
+		dataDir:         opts.DataDir,
+		sessionDir:      filepath.Join(opts.DataDir, "sessions"),
+		sourceDirs:      sourceDirs,
+		commands:        slices.Clone(opts.KubeconfigCommands),
+		commandTTL:      opts.KubeconfigCommandTTL,
+		commandTimeout:  commandTimeout,
+		skipCommands:    opts.SkipKubeconfigCommands,
+
+		credentialCommand: slices.Clone(opts.CredentialCommand),
+	}
+
+	err := kubesel.Reload()
+	if err != nil {
+		return nil, err
 	}
 
-	kubesel.lazyManagedKubeconfig = sync.OnceValues(kubesel.findManagedKubeconfig)
-	kubesel.lazyClusterNames = sync.OnceValue(kubesel.findClusterNames)
-	kubesel.lazyAuthInfoNames = sync.OnceValue(kubesel.findAuthInfoNames)
-	kubesel.lazyContextNames = sync.OnceValue(kubesel.findContextNames)
 	return kubesel, nil
 }
 
+// Reload finds the kubectl configuration files again, discarding anything
+// that was already loaded. The kubeconfig source directories are scanned
+// again for new files. The files are loaded lazily, the same as they are
+// by [NewKubesel].
+//
+// The other methods can be called while reloading. They use either the files
+// found before or after the reload, and never a mix of both.
+func (k *Kubesel) Reload() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.reload()
+}
+
+func (k *Kubesel) reload() error {
+	// Find the kubeconfig files.
+	// Files generated for the session (e.g. the sources file) are skipped,
+	// since the files they were generated from are loaded directly.
+	kcFiles, err := loader.AppendKubeConfigFilesInDirs(slices.Clone(k.kubeconfigPaths), k.sourceDirs...)
+	if err != nil {
+		return fmt.Errorf("error finding kubeconfig files: %w", err)
+	}
+
+	kcFiles, decryptedFiles := k.resolveDecryptedFilePaths(kcFiles)
+	kcFiles = slices.DeleteFunc(kcFiles, k.isCompanionFilePath)
+
+	// The output of kubeconfig commands are merged last.
+	// The commands themselves are only run once the files are loaded.
+	for _, command := range k.commands {
+		kcFiles = append(kcFiles, k.commandSourceFilePath(command))
+	}
+
+	state := &kubeselState{
+		kubeconfigFiles: kcFiles,
+		decryptedFiles:  decryptedFiles,
+	}
+
+	state.lazyKubeconfigs = sync.OnceValue(func() *loader.LoadedKubeconfigCollection {
+		defer state.loadedKubeconfigs.Store(true)
+		return k.loadKubeconfigs(state.kubeconfigFiles)
+	})
+
+	state.lazyManagedKubeconfig = sync.OnceValues(func() (*ManagedKubeconfig, error) {
+		return k.findManagedKubeconfig(state.kubeconfigFiles)
+	})
+
+	state.lazyClusterNames = sync.OnceValue(func() []string {
+		return findClusterNames(state.lazyKubeconfigs().Merged)
+	})
+
+	state.lazyAuthInfoNames = sync.OnceValue(func() []string {
+		return findAuthInfoNames(state.lazyKubeconfigs().Merged)
+	})
+
+	state.lazyContextNames = sync.OnceValue(func() []string {
+		return findContextNames(state.lazyKubeconfigs().Merged)
+	})
+
+	state.lazyContextAliases = sync.OnceValue(func() map[string]string {
+		return k.findContextAliases(state.lazyContextNames())
+	})
+
+	state.lazyUsage = sync.OnceValue(k.loadUsage)
+	k.state.Store(state)
+	return nil
+}
+
+// loaded returns the state found by the last [Kubesel.Reload].
+func (k *Kubesel) loaded() *kubeselState {
+	return k.state.Load()
+}
+
+// GetConfig returns the kubesel configuration.
+func (k *Kubesel) GetConfig() *Config {
+	return k.config
+}
+
 // GetMergedKubeconfig returns merged contents of the files specified by the
 // `KUBECONFIG` environment variable.
 func (k *Kubesel) GetMergedKubeconfig() *kubeconfig.Config {
-	return k.kubeconfigs.Merged
+	return k.loaded().lazyKubeconfigs().Merged
+}
+
+// GetProvenance returns the [loader.Provenance] of the named items inside the
+// merged kubeconfig. This can be used to find the kubeconfig file which
+// defined an item, and which other files had their definitions shadowed.
+func (k *Kubesel) GetProvenance() *loader.Provenance {
+	return k.loaded().lazyKubeconfigs().Provenance
 }
 
 // GetKubeconfigFilePaths returns the list of kubeconfig files specified by the
-// `KUBECONFIG` environment variable.
+// `KUBECONFIG` environment variable, followed by the files found inside the
+// kubeconfig source directories and the files containing the output of the
+// kubeconfig commands.
 func (k *Kubesel) GetKubeconfigFilePaths() []string {
-	files := make([]string, len(k.kubeconfigs.Configs))
-	for i, kc := range k.kubeconfigs.Configs {
-		files[i] = kc.Path
+	return slices.Clone(k.loaded().kubeconfigFiles)
+}
+
+// GetKubeconfigLoadErrors returns a [loader.LoadError] for each kubeconfig
+// file that could not be read or parsed. Files that do not exist are not
+// considered errors, since kubectl ignores them too.
+//
+// This loads all the kubeconfig files if they weren't already.
+func (k *Kubesel) GetKubeconfigLoadErrors() []*loader.LoadError {
+	var loadErrs []*loader.LoadError
+	for _, kc := range k.loaded().lazyKubeconfigs().Configs {
+		errs := slices.DeleteFunc(slices.Clone(kc.Errors), func(err error) bool {
+			return errors.Is(err, os.ErrNotExist)
+		})
+
+		if len(errs) > 0 {
+			loadErrs = append(loadErrs, &loader.LoadError{
+				Path:   kc.Path,
+				Errors: errs,
+			})
+		}
 	}
-	return files
+
+	return loadErrs
+}
+
+// HasLoadedKubeconfigs returns true if all the kubeconfig files have already
+// been loaded and merged.
+func (k *Kubesel) HasLoadedKubeconfigs() bool {
+	return k.loaded().loadedKubeconfigs.Load()
 }
 
 // GetManagedKubeconfig returns the current [ManagedKubeconfig], if one exists.
@@ -75,25 +305,25 @@ func (k *Kubesel) GetKubeconfigFilePaths() []string {
 // the `KUBECONFIG` environment variable that is located within kubesel's
 // sessions directory.
 func (k *Kubesel) GetManagedKubeconfig() (*ManagedKubeconfig, error) {
-	return k.lazyManagedKubeconfig()
+	return k.loaded().lazyManagedKubeconfig()
 }
 
 // GetClusterNames returns the list of known [kubeconfig.NamedCluster] names
 // inside the merged kubeconfig.
 func (k *Kubesel) GetClusterNames() []string {
-	return k.lazyClusterNames()
+	return k.loaded().lazyClusterNames()
 }
 
 // GetAuthInfoNames returns the list of known [kubeconfig.NamedAuthInfo] names
 // inside the merged kubeconfig.
 func (k *Kubesel) GetAuthInfoNames() []string {
-	return k.lazyAuthInfoNames()
+	return k.loaded().lazyAuthInfoNames()
 }
 
 // GetContextNames returns the list of known [kubeconfig.NamedContext] names
 // inside the merged kubeconfig.
 func (k *Kubesel) GetContextNames() []string {
-	return k.lazyContextNames()
+	return k.loaded().lazyContextNames()
 }
 
 // CreateManagedKubeconfig creates a new kubesel [ManagedKubeconfig] for the
@@ -118,6 +348,7 @@ func (k *Kubesel) CreateManagedKubeconfig(owner Owner) (*ManagedKubeconfig, erro
 		return nil, err
 	}
 
+	managedConfig.kubesel = k
 	err = managedConfig.Save()
 	if err != nil {
 		return nil, fmt.Errorf("error saving managed kubeconfig: %w", err)
@@ -137,18 +368,62 @@ func (k *Kubesel) GetManagedKubeconfigPathForOwner(owner Owner) string {
 	return filepath.Join(k.sessionDir, owner.fileName())
 }
 
-// findManagedKubeconfig looks for the first loaded kubeconfig file found
-// within kubesel's session directory.
-func (k *Kubesel) findManagedKubeconfig() (*ManagedKubeconfig, error) {
-	for _, kc := range k.kubeconfigs.Configs {
-		if k.IsManagedKubeconfigPath(kc.Path) {
-			return newManagedKubeconfigFromExistingKubeconfig(kc)
+// loadKubeconfigs loads and merges all the kubeconfig files.
+//
+// Kubeconfig commands with outdated output are run first. If a command fails,
+// its error is added to the errors of its file.
+func (k *Kubesel) loadKubeconfigs(files []string) *loader.LoadedKubeconfigCollection {
+	commandErrs := k.refreshCommandSources()
+	collection := loader.LoadMultipleFilesCached(files, loader.CacheOptions{
+		File: k.cacheFile(),
+
+		// Session files are different for every shell, and change every
+		// time the context or namespace is switched.
+		Skip: k.IsManagedKubeconfigPath,
+	})
+
+	for _, kc := range collection.Configs {
+		if err, ok := commandErrs[kc.Path]; ok {
+			kc.Errors = append(kc.Errors, err)
+		}
+	}
+
+	return collection
+}
+
+// findManagedKubeconfig looks for the first kubeconfig file found within
+// kubesel's session directory.
+//
+// Only the managed kubeconfig file is read. It is loaded separately from the
+// merged kubeconfig files, since most commands that change the session do not
+// need anything else.
+func (k *Kubesel) findManagedKubeconfig(files []string) (*ManagedKubeconfig, error) {
+	for _, path := range files {
+		if k.IsManagedKubeconfigPath(path) {
+			return k.loadManagedKubeconfig(path)
 		}
 	}
 
 	return nil, ErrUnmanaged
 }
 
+// loadManagedKubeconfig loads an existing [ManagedKubeconfig] file.
+func (k *Kubesel) loadManagedKubeconfig(path string) (*ManagedKubeconfig, error) {
+	managedKc, err := newManagedKubeconfigFromExistingKubeconfig(loader.LoadFromFile(path))
+	if err != nil {
+		return nil, err
+	}
+
+	managedKc.kubesel = k
+	return managedKc, nil
+}
+
+// cacheFile returns the path of the file used to cache parsed kubeconfig
+// files between invocations.
+func (k *Kubesel) cacheFile() string {
+	return filepath.Join(k.dataDir, "cache", "kubeconfigs.json")
+}
+
 // ensureSessionDirExists creates the directory containing managed kubeconfig
 // files if it does not already exist.
 func (k *Kubesel) ensureSessionsDirExists() error {
@@ -161,10 +436,10 @@ func (k *Kubesel) ensureSessionsDirExists() error {
 }
 
 // findClusterNames returns all the cluster names in the merged kubeconfig.
-func (k *Kubesel) findClusterNames() []string {
-	names := make([]string, 0, len(k.kubeconfigs.Merged.Clusters))
-	for _, kcCluster := range k.kubeconfigs.Merged.Clusters {
-		if kcCluster.Name != nil {
+func findClusterNames(merged *kubeconfig.Config) []string {
+	names := make([]string, 0, len(merged.Clusters))
+	for _, kcCluster := range merged.Clusters {
+		if kcCluster.Name != nil && !IsManagedCluster(&kcCluster) {
 			names = append(names, *kcCluster.Name)
 		}
 	}
@@ -173,10 +448,10 @@ func (k *Kubesel) findClusterNames() []string {
 }
 
 // findAuthInfoNames returns all the authinfo names in the merged kubeconfig.
-func (k *Kubesel) findAuthInfoNames() []string {
-	names := make([]string, 0, len(k.kubeconfigs.Merged.AuthInfos))
-	for _, kcAuthInfo := range k.kubeconfigs.Merged.AuthInfos {
-		if kcAuthInfo.Name != nil {
+func findAuthInfoNames(merged *kubeconfig.Config) []string {
+	names := make([]string, 0, len(merged.AuthInfos))
+	for _, kcAuthInfo := range merged.AuthInfos {
+		if kcAuthInfo.Name != nil && !IsManagedAuthInfo(&kcAuthInfo) {
 			names = append(names, *kcAuthInfo.Name)
 		}
 	}
@@ -185,9 +460,9 @@ func (k *Kubesel) findAuthInfoNames() []string {
 }
 
 // findContextNames returns all the context names in the merged kubeconfig.
-func (k *Kubesel) findContextNames() []string {
-	names := make([]string, 0, len(k.kubeconfigs.Merged.Contexts))
-	for _, kcContext := range k.kubeconfigs.Merged.Contexts {
+func findContextNames(merged *kubeconfig.Config) []string {
+	names := make([]string, 0, len(merged.Contexts))
+	for _, kcContext := range merged.Contexts {
 		if kcContext.Name != nil && !IsManagedContext(&kcContext) {
 			names = append(names, *kcContext.Name)
 		}
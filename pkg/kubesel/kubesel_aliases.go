@@ -0,0 +1,50 @@
+package kubesel
+
+import (
+	"slices"
+)
+
+// GetContextAliases returns the context aliases from the kubesel config, as a
+// map of the alias to the context name.
+//
+// Aliases for contexts that don't exist are left out, as are aliases that
+// have the same name as an existing context.
+func (k *Kubesel) GetContextAliases() map[string]string {
+	return k.loaded().lazyContextAliases()
+}
+
+// GetAliasesForContext returns the sorted aliases of a context.
+func (k *Kubesel) GetAliasesForContext(name string) []string {
+	var aliases []string
+	for alias, target := range k.GetContextAliases() {
+		if target == name {
+			aliases = append(aliases, alias)
+		}
+	}
+
+	slices.Sort(aliases)
+	return aliases
+}
+
+// ResolveContextAlias returns the name of the context that an alias refers
+// to. If the name is not an alias, it is returned as-is.
+func (k *Kubesel) ResolveContextAlias(name string) string {
+	if target, ok := k.GetContextAliases()[name]; ok {
+		return target
+	}
+
+	return name
+}
+
+// findContextAliases returns the context aliases for contexts that exist,
+// given the names of the contexts.
+func (k *Kubesel) findContextAliases(names []string) map[string]string {
+	aliases := make(map[string]string, len(k.config.ContextAliases))
+	for alias, target := range k.config.ContextAliases {
+		if slices.Contains(names, target) && !slices.Contains(names, alias) {
+			aliases[alias] = target
+		}
+	}
+
+	return aliases
+}
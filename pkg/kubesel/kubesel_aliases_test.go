@@ -0,0 +1,65 @@
+package kubesel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindContextAliases(t *testing.T) {
+	testcases := map[string]struct {
+		Aliases  map[string]string
+		Expected map[string]string
+	}{
+		"Aliases for existing contexts are kept": {
+			Aliases:  map[string]string{"a": "ctx-a", "b": "ctx-b", "also-a": "ctx-a"},
+			Expected: map[string]string{"a": "ctx-a", "b": "ctx-b", "also-a": "ctx-a"},
+		},
+		"Aliases for missing contexts are dropped": {
+			Aliases:  map[string]string{"a": "ctx-a", "gone": "ctx-gone"},
+			Expected: map[string]string{"a": "ctx-a"},
+		},
+		"Aliases that shadow a context are dropped": {
+			Aliases:  map[string]string{"ctx-a": "ctx-b", "b": "ctx-b"},
+			Expected: map[string]string{"b": "ctx-b"},
+		},
+		"No aliases": {
+			Aliases:  nil,
+			Expected: map[string]string{},
+		},
+	}
+
+	t.Parallel()
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := newSessionTestOptions(t)
+			opts.Config = &Config{ContextAliases: tc.Aliases}
+			ksel, err := New(opts)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.Expected, ksel.findContextAliases([]string{"ctx-a", "ctx-b"}))
+		})
+	}
+}
+
+func TestResolveContextAlias(t *testing.T) {
+	t.Parallel()
+
+	opts := newSessionTestOptions(t)
+	opts.Config = &Config{ContextAliases: map[string]string{
+		"a":     "ctx-a",
+		"ctx-b": "ctx-a",
+		"gone":  "ctx-gone",
+	}}
+
+	ksel, err := New(opts)
+	require.NoError(t, err)
+
+	require.Equal(t, "ctx-a", ksel.ResolveContextAlias("a"), "aliases are resolved")
+	require.Equal(t, "ctx-b", ksel.ResolveContextAlias("ctx-b"), "contexts take priority over aliases")
+	require.Equal(t, "gone", ksel.ResolveContextAlias("gone"), "aliases for missing contexts are ignored")
+	require.Equal(t, "unknown", ksel.ResolveContextAlias("unknown"))
+	require.Equal(t, []string{"a"}, ksel.GetAliasesForContext("ctx-a"))
+}
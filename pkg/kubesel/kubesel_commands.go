@@ -0,0 +1,171 @@
+package kubesel
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/eth-p/kubesel/internal/parallel"
+	"github.com/eth-p/kubesel/pkg/kubeconfig/loader"
+)
+
+// GetKubeconfigCommands returns the commands that print kubeconfig files.
+// These are specified by the `KUBESEL_KUBECONFIG_COMMANDS` environment
+// variable.
+func (k *Kubesel) GetKubeconfigCommands() []string {
+	return k.commands
+}
+
+// IsCommandSourceFilePath returns true if the kubeconfig file at the specified
+// path contains the output of a kubeconfig command.
+func (k *Kubesel) IsCommandSourceFilePath(path string) bool {
+	return filepath.Dir(filepath.Clean(path)) == k.commandCacheDir()
+}
+
+// GetKubeconfigCommandForPath returns the kubeconfig command whose output is
+// stored in the file at the specified path.
+func (k *Kubesel) GetKubeconfigCommandForPath(path string) (string, bool) {
+	for _, command := range k.commands {
+		if k.commandSourceFilePath(command) == path {
+			return command, true
+		}
+	}
+
+	return "", false
+}
+
+// commandSourceFilePath returns the path of the file containing the output
+// of a kubeconfig command.
+func (k *Kubesel) commandSourceFilePath(command string) string {
+	hash := sha256.Sum256([]byte(command))
+	return filepath.Join(k.commandCacheDir(), hex.EncodeToString(hash[:8])+".yaml")
+}
+
+// commandCacheDir returns the directory containing the output of kubeconfig
+// commands.
+func (k *Kubesel) commandCacheDir() string {
+	return filepath.Join(k.dataDir, "cache", "commands")
+}
+
+// refreshCommandSources runs the kubeconfig commands whose output is older
+// than the TTL, updating their files. The commands run in parallel.
+//
+// If a command fails, its previous output is kept. The error is returned in
+// a map of the command's file path to the error.
+func (k *Kubesel) refreshCommandSources() map[string]error {
+	errs := make(map[string]error)
+	for i, err := range parallel.Ordered(k.commands, k.refreshCommandSource) {
+		if err != nil {
+			errs[k.commandSourceFilePath(k.commands[i])] = err
+		}
+	}
+
+	return errs
+}
+
+// isCommandSourceOutdated returns true if the output of a kubeconfig command
+// is missing or older than the TTL.
+//
+// If kubeconfig commands are skipped, the previous output is used no matter
+// how old it is.
+func (k *Kubesel) isCommandSourceOutdated(command string) bool {
+	if k.skipCommands {
+		return false
+	}
+
+	stat, err := os.Stat(k.commandSourceFilePath(command))
+	return err != nil || time.Since(stat.ModTime()) >= k.commandTTL
+}
+
+// refreshCommandSource runs a kubeconfig command if its output is outdated,
+// saving the output if it is a valid kubeconfig.
+func (k *Kubesel) refreshCommandSource(command string) error {
+	if !k.isCommandSourceOutdated(command) {
+		return nil
+	}
+
+	path := k.commandSourceFilePath(command)
+	output, err := runKubeconfigCommand(command, k.commandTimeout)
+	if err != nil {
+		return err
+	}
+
+	// Check the output before saving it.
+	kc := loader.LoadFromReader(bytes.NewReader(output))
+	if len(kc.Errors) > 0 {
+		return fmt.Errorf("%w: %s: %w", ErrKubeconfigCommandFailed, command, errors.Join(kc.Errors...))
+	}
+
+	err = os.MkdirAll(k.commandCacheDir(), 0o700)
+	if err != nil {
+		return fmt.Errorf("mkdir: %w", err)
+	}
+
+	err = writeFileAtomically(path, output)
+	if err != nil {
+		return fmt.Errorf("error saving output of %s: %w", command, err)
+	}
+
+	return nil
+}
+
+// kubeconfigCommandWaitDelay is how long to wait for the output of a killed
+// kubeconfig command to close. Processes started by the shell may keep it open
+// after the shell itself is killed.
+const kubeconfigCommandWaitDelay = 100 * time.Millisecond
+
+// runKubeconfigCommand runs a kubeconfig command with the shell, returning
+// its standard output. The command is killed if it runs longer than the
+// timeout.
+func runKubeconfigCommand(command string, timeout time.Duration) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	proc := exec.CommandContext(ctx, shellCommand[0], append(shellCommand[1:], command)...)
+	proc.Stdin = nil
+	proc.WaitDelay = kubeconfigCommandWaitDelay
+	proc.Stdout = &stdout
+	proc.Stderr = &stderr
+
+	err := proc.Run()
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("%w: %s: timed out after %s", ErrKubeconfigCommandFailed, command, timeout)
+	}
+
+	if err != nil {
+		details := strings.TrimSpace(stderr.String())
+		if details == "" {
+			details = err.Error()
+		}
+
+		return nil, fmt.Errorf("%w: %s: %s", ErrKubeconfigCommandFailed, command, details)
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// shellCommand is the shell used to run kubeconfig commands, followed by the
+// arguments that make it run the command given as the last argument.
+//
+// On Windows, `cmd.exe` is used unless a `sh` is installed.
+var shellCommand = findShellCommand()
+
+func findShellCommand() []string {
+	if runtime.GOOS == "windows" {
+		if _, err := exec.LookPath("sh"); err != nil {
+			return []string{"cmd.exe", "/C"}
+		}
+	}
+
+	return []string{"sh", "-c"}
+}
@@ -0,0 +1,138 @@
+package kubesel
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const commandTestKubeconfig = `{apiVersion: v1, kind: Config, contexts: [{name: from-command, context: {}}]}`
+
+// newCommandTestKubesel returns a [Kubesel] with a kubeconfig command that
+// prints the file returned as kcFile, and counts how many times it ran.
+func newCommandTestKubesel(t *testing.T, opts Options) (ksel *Kubesel, command string, kcFile string, runs func() int) {
+	t.Helper()
+
+	dir := t.TempDir()
+	kcFile = filepath.Join(dir, "kubeconfig.yaml")
+	countFile := filepath.Join(dir, "count")
+	require.NoError(t, os.WriteFile(kcFile, []byte(commandTestKubeconfig), 0o600))
+
+	command = "echo >> '" + countFile + "' && cat '" + kcFile + "'"
+	opts.DataDir = filepath.Join(dir, "data")
+	opts.KubeconfigCommands = append(opts.KubeconfigCommands, command)
+
+	ksel, err := New(opts)
+	require.NoError(t, err)
+
+	runs = func() int {
+		count, err := os.ReadFile(countFile)
+		if os.IsNotExist(err) {
+			return 0
+		}
+
+		require.NoError(t, err)
+		return strings.Count(string(count), "\n")
+	}
+
+	return ksel, command, kcFile, runs
+}
+
+func TestKubeconfigCommandIsCachedForTTL(t *testing.T) {
+	testcases := map[string]struct {
+		TTL          time.Duration
+		Skip         bool
+		ExpectedRuns int
+	}{
+		"Output is reused within the TTL": {
+			TTL:          time.Hour,
+			ExpectedRuns: 1,
+		},
+		"Output is refreshed after the TTL": {
+			TTL:          0,
+			ExpectedRuns: 2,
+		},
+		"Output is reused when commands are skipped": {
+			TTL:          0,
+			Skip:         true,
+			ExpectedRuns: 1,
+		},
+	}
+
+	t.Parallel()
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ksel, command, _, runs := newCommandTestKubesel(t, Options{KubeconfigCommandTTL: tc.TTL})
+			require.NoError(t, ksel.refreshCommandSource(command))
+			require.Equal(t, 1, runs(), "the command runs if there is no previous output")
+			require.FileExists(t, ksel.commandSourceFilePath(command))
+
+			ksel.skipCommands = tc.Skip
+			require.NoError(t, ksel.refreshCommandSource(command))
+			require.Equal(t, tc.ExpectedRuns, runs())
+		})
+	}
+}
+
+func TestKubeconfigCommandTimeout(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	ksel, err := New(Options{
+		DataDir:                  dir,
+		KubeconfigCommandTimeout: 50 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	started := time.Now()
+	err = ksel.refreshCommandSource("sleep 5")
+	require.ErrorIs(t, err, ErrKubeconfigCommandFailed)
+	require.ErrorContains(t, err, "timed out")
+	require.Less(t, time.Since(started), 2*time.Second, "the command is killed")
+	require.NoFileExists(t, ksel.commandSourceFilePath("sleep 5"))
+}
+
+func TestKubeconfigCommandKeepsPreviousOutput(t *testing.T) {
+	testcases := map[string]struct {
+		Break func(kcFile string) error
+	}{
+		"Command fails": {
+			Break: os.Remove,
+		},
+		"Command prints an invalid kubeconfig": {
+			Break: func(kcFile string) error {
+				return os.WriteFile(kcFile, []byte("clusters: [not a cluster]"), 0o600)
+			},
+		},
+	}
+
+	t.Parallel()
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ksel, command, kcFile, _ := newCommandTestKubesel(t, Options{KubeconfigCommandTTL: 0})
+			require.Contains(t, ksel.GetContextNames(), "from-command")
+
+			require.NoError(t, tc.Break(kcFile))
+			require.NoError(t, ksel.Reload())
+			require.Contains(t, ksel.GetContextNames(), "from-command", "the previous output is used")
+
+			var errs []error
+			for _, kc := range ksel.loaded().lazyKubeconfigs().Configs {
+				if kc.Path == ksel.commandSourceFilePath(command) {
+					errs = kc.Errors
+				}
+			}
+
+			require.Len(t, errs, 1, "the error is reported for the command's file")
+			require.ErrorIs(t, errs[0], ErrKubeconfigCommandFailed)
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package kubesel
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eth-p/kubesel/pkg/kubeconfig"
+	"github.com/eth-p/kubesel/pkg/kubeconfig/kcutils"
+	"github.com/eth-p/kubesel/pkg/kubeconfig/loader"
+	"gopkg.in/yaml.v3"
+)
+
+// decryptedFilePurposePrefix is the prefix of the purpose of a decrypted
+// kubeconfig file in [Owner.companionFileName]. It is followed by a hash of
+// the encrypted file's path.
+const decryptedFilePurposePrefix = "decrypted-"
+
+// decryptedExtensionName is the name of the extension that records which
+// encrypted file a decrypted kubeconfig file was created from.
+const decryptedExtensionName = "decrypted-by-kubesel"
+
+// IsEncryptedFilePath returns true if the kubeconfig file at the specified
+// path was encrypted with SOPS.
+//
+// This loads all the kubeconfig files if they weren't already.
+func (k *Kubesel) IsEncryptedFilePath(path string) bool {
+	for _, kc := range k.loaded().lazyKubeconfigs().Configs {
+		if kc.Path == path {
+			return kc.Encrypted
+		}
+	}
+
+	return false
+}
+
+// HasDecryptedFiles returns true if any of the kubeconfig files were found
+// through the decrypted copies made by [Kubesel.UpdateDecryptedFile].
+func (k *Kubesel) HasDecryptedFiles() bool {
+	return len(k.loaded().decryptedFiles) > 0
+}
+
+// GetDecryptedFilePathForOwner returns the path of the decrypted copy of an
+// encrypted kubeconfig file for the given [Owner]'s session.
+func (k *Kubesel) GetDecryptedFilePathForOwner(owner Owner, path string) string {
+	hash := sha256.Sum256([]byte(path))
+	purpose := decryptedFilePurposePrefix + hex.EncodeToString(hash[:8])
+	return filepath.Join(k.sessionDir, owner.companionFileName(purpose))
+}
+
+// UpdateDecryptedFile writes a decrypted copy of an encrypted kubeconfig file
+// for the [Owner]'s session, returning its path. If the copy is newer than the
+// encrypted file, it is left as-is.
+//
+// Kubectl cannot read encrypted kubeconfig files. Instead, the decrypted copy
+// replaces the encrypted file in the `KUBECONFIG` environment variable when
+// kubesel is initialized, and is kept up to date by calling
+// [Kubesel.UpdateDecryptedFiles] whenever kubesel runs. The copy can only be
+// read by the current user, and is removed by garbage collection once the
+// session ends.
+func (k *Kubesel) UpdateDecryptedFile(owner Owner, path string) (string, error) {
+	decryptedPath := k.GetDecryptedFilePathForOwner(owner, path)
+	if isNewerFile(decryptedPath, path) {
+		return decryptedPath, nil
+	}
+
+	kc := loader.LoadFromFile(path)
+	if err := kc.Err(); err != nil {
+		return "", err
+	}
+
+	// Relative paths need to be resolved, since the copy is in a different
+	// directory than the file that defined them.
+	config := kc.Config.Clone()
+	kcutils.ResolveConfigPaths(config, filepath.Dir(path))
+
+	// Record where it came from, so kubesel can use the encrypted file
+	// instead of the copy.
+	ext := &kubeconfig.Extension{
+		ApiVersion: kcutils.PointerFor(kcextApiVersion),
+		Kind:       kcutils.PointerFor(kcextDecryptedKubeconfigKind),
+	}
+
+	err := kcutils.EncodeExtension(&kcextDecryptedKubeconfig{Source: path}, ext)
+	if err != nil {
+		return "", fmt.Errorf("encoding %s extension: %w", kcextDecryptedKubeconfigKind, err)
+	}
+
+	config.Extensions = append(config.Extensions, kubeconfig.NamedExtension{
+		Name:      kcutils.PointerFor(decryptedExtensionName),
+		Extension: ext,
+	})
+
+	marshalled, err := yaml.Marshal(config)
+	if err != nil {
+		return "", fmt.Errorf("marshalling kubeconfig: %w", err)
+	}
+
+	// Write it.
+	if err := k.ensureSessionsDirExists(); err != nil {
+		return "", err
+	}
+
+	err = writeFileAtomically(decryptedPath, marshalled)
+	if err != nil {
+		return "", fmt.Errorf("error saving decrypted file: %w", err)
+	}
+
+	return decryptedPath, nil
+}
+
+// UpdateDecryptedFiles updates the decrypted copies of encrypted kubeconfig
+// files that were found in the `KUBECONFIG` environment variable.
+func (k *Kubesel) UpdateDecryptedFiles(owner Owner) error {
+	var errs []error
+	for _, path := range k.loaded().decryptedFiles {
+		_, err := k.UpdateDecryptedFile(owner, path)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// resolveDecryptedFilePaths replaces the paths of decrypted kubeconfig files
+// with the paths of the encrypted files they were created from.
+//
+// Kubesel reads the encrypted files directly, so it always sees their latest
+// contents. If the encrypted file can't be determined, the decrypted file is
+// left in the list to be skipped as a companion file.
+//
+// The paths of the encrypted files are also returned separately.
+func (k *Kubesel) resolveDecryptedFilePaths(files []string) ([]string, []string) {
+	var decrypted []string
+	for i, file := range files {
+		if !k.IsManagedKubeconfigPath(file) {
+			continue
+		}
+
+		purpose, ok := companionFilePurpose(filepath.Base(file))
+		if !ok || !strings.HasPrefix(purpose, decryptedFilePurposePrefix) {
+			continue
+		}
+
+		source, err := readDecryptedFileSource(file)
+		if err != nil {
+			continue
+		}
+
+		files[i] = source
+		decrypted = append(decrypted, source)
+	}
+
+	return files, decrypted
+}
+
+// readDecryptedFileSource returns the path of the encrypted file that a
+// decrypted kubeconfig file was created from.
+func readDecryptedFileSource(path string) (string, error) {
+	kc := loader.LoadFromFile(path)
+	if err := kc.Err(); err != nil {
+		return "", err
+	}
+
+	rawExt := kcutils.FindExtensionFrom(decryptedExtensionName, &kc.Config)
+	if rawExt == nil || !rawExt.Is(kcextApiVersion, kcextDecryptedKubeconfigKind) {
+		return "", fmt.Errorf("the %q extension is missing", decryptedExtensionName)
+	}
+
+	var ext kcextDecryptedKubeconfig
+	err := kcutils.DecodeExtension(rawExt, &ext)
+	if err != nil {
+		return "", fmt.Errorf("could not decode %s: %w", kcextDecryptedKubeconfigKind, err)
+	}
+
+	return ext.Source, nil
+}
+
+// isNewerFile returns true if the file exists and was modified after the
+// other file.
+func isNewerFile(path string, other string) bool {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+
+	otherStat, err := os.Stat(other)
+	if err != nil {
+		return false
+	}
+
+	return stat.ModTime().After(otherStat.ModTime())
+}
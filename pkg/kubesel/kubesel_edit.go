@@ -0,0 +1,85 @@
+package kubesel
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/eth-p/kubesel/pkg/kubeconfig"
+	"github.com/eth-p/kubesel/pkg/kubeconfig/kcutils"
+	"github.com/eth-p/kubesel/pkg/kubeconfig/loader"
+)
+
+// EditKubeconfigFile reads the kubeconfig file at the given path, passes its
+// contents to the edit function, and atomically saves the changes.
+//
+// The file is read again from disk rather than using the already-loaded copy,
+// so only the contents of that specific file are written back to it. If the
+// file does not exist, the edit function receives a new, empty config.
+//
+// Kubesel-managed kubeconfig files cannot be edited this way. Trying to do so
+// will return [ErrEditingManaged]. Files generated by kubeconfig commands
+// cannot be edited either, and will return [ErrEditingGenerated].
+func (k *Kubesel) EditKubeconfigFile(path string, edit func(kc *kubeconfig.Config) error) error {
+	if k.IsManagedKubeconfigPath(path) {
+		return fmt.Errorf("%w: %s", ErrEditingManaged, path)
+	}
+
+	if k.IsCommandSourceFilePath(path) {
+		return fmt.Errorf("%w: %s", ErrEditingGenerated, path)
+	}
+
+	kc := loader.LoadFromFile(path)
+	if len(kc.Errors) > 0 {
+		if !errors.Is(errors.Join(kc.Errors...), os.ErrNotExist) {
+			return fmt.Errorf("error loading %s: %w", path, errors.Join(kc.Errors...))
+		}
+
+		kc = &loader.LoadedKubeconfig{Path: path}
+		kc.Config.ApiVersion = kcutils.PointerFor("v1")
+		kc.Config.Kind = kcutils.PointerFor("Config")
+	}
+
+	err := edit(&kc.Config)
+	if err != nil {
+		return err
+	}
+
+	err = kc.Save()
+	if err != nil {
+		return fmt.Errorf("error saving %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// GetKubeconfigFilePathForNewItems returns the path of the kubeconfig file
+// where newly-created clusters, contexts, and users should be added.
+//
+// Like kubectl, this is the first kubeconfig file that exists. If none of the
+// files exist, the first unmanaged file will be used. Encrypted files and
+// files generated by kubeconfig commands are skipped, since they cannot be
+// saved. If there are no unmanaged files at all,
+// [ErrNoWritableKubeconfig] is returned.
+func (k *Kubesel) GetKubeconfigFilePathForNewItems() (string, error) {
+	fallback := ""
+	for _, kc := range k.loaded().lazyKubeconfigs().Configs {
+		if k.IsManagedKubeconfigPath(kc.Path) || k.IsCommandSourceFilePath(kc.Path) || kc.Encrypted {
+			continue
+		}
+
+		if len(kc.Errors) == 0 {
+			return kc.Path, nil
+		}
+
+		if fallback == "" {
+			fallback = kc.Path
+		}
+	}
+
+	if fallback == "" {
+		return "", ErrNoWritableKubeconfig
+	}
+
+	return fallback, nil
+}
@@ -8,10 +8,15 @@ import (
 	"os"
 	"path/filepath"
 	"slices"
+	"time"
 
 	"github.com/eth-p/kubesel/pkg/kubeconfig/loader"
 )
 
+// tempFileStaleAge is how old a temporary file must be before it is assumed
+// to have been left behind by a process that was killed while writing it.
+const tempFileStaleAge = 10 * time.Minute
+
 type GarbageCollectOptions struct {
 	MaxFilesToCheck  int
 	MaxFilesToDelete int
@@ -24,7 +29,8 @@ type GarbageCollectResult struct {
 }
 
 // GarbageCollect removes kubesel-managed files belonging to processes which
-// are no longer alive.
+// are no longer alive. Files belonging to other hosts are left alone, since
+// their processes can't be checked from this one.
 func (k *Kubesel) GarbageCollect(opts *GarbageCollectOptions) (*GarbageCollectResult, error) {
 	err := k.ensureSessionsDirExists()
 	if err != nil {
@@ -39,9 +45,11 @@ func (k *Kubesel) GarbageCollect(opts *GarbageCollectOptions) (*GarbageCollectRe
 	}
 
 	// Filter the files to only ones (likely) created by kubesel.
-	// Consider any file ending in `.yaml` to be one.
+	// Consider any file ending in `.yaml` to be one, along with the
+	// temporary files left behind by interrupted writes.
 	entries = slices.DeleteFunc(entries, func(entry os.DirEntry) bool {
-		return filepath.Ext(entry.Name()) != ".yaml"
+		ext := filepath.Ext(entry.Name())
+		return ext != ".yaml" && ext != loader.TempFileExt
 	})
 
 	// Prepare.
@@ -98,6 +106,28 @@ func (k *Kubesel) GarbageCollect(opts *GarbageCollectOptions) (*GarbageCollectRe
 }
 
 func (k *Kubesel) canGarbageCollect(path string) (bool, error) {
+	// If it's a temporary file, it can be deleted once it's too old to
+	// still be in the middle of being written.
+	if filepath.Ext(path) == loader.TempFileExt {
+		stat, err := os.Stat(path)
+		if err != nil {
+			return false, err
+		}
+
+		return time.Since(stat.ModTime()) > tempFileStaleAge, nil
+	}
+
+	// If it's a file generated for a session, it can be deleted once the
+	// session's managed kubeconfig file is gone.
+	if sessionFile, ok := sessionFileNameForCompanion(filepath.Base(path)); ok {
+		_, err := os.Stat(filepath.Join(filepath.Dir(path), sessionFile))
+		if errors.Is(err, os.ErrNotExist) {
+			return true, nil
+		}
+
+		return false, err
+	}
+
 	kc := loader.LoadFromFile(path)
 
 	// If the file can't be parsed as a kubeconfig file, don't touch it.
@@ -117,6 +147,13 @@ func (k *Kubesel) canGarbageCollect(path string) (bool, error) {
 		return false, err
 	}
 
+	// If the owner is on a different host, its process can't be checked.
+	// That host is responsible for cleaning it up.
+	isLocal, err := managedKc.owner.IsLocal()
+	if err != nil || !isLocal {
+		return false, err
+	}
+
 	// If the owner isn't alive, it can be deleted.
 	isAlive, err := managedKc.owner.IsAlive()
 	if err != nil {
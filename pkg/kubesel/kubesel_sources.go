@@ -0,0 +1,168 @@
+package kubesel
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/eth-p/kubesel/pkg/kubeconfig"
+	"github.com/eth-p/kubesel/pkg/kubeconfig/kcutils"
+	"github.com/eth-p/kubesel/pkg/kubeconfig/loader"
+	"gopkg.in/yaml.v3"
+)
+
+// sourcesFilePurpose is the purpose of the generated sources file in
+// [Owner.companionFileName].
+const sourcesFilePurpose = "sources"
+
+// GetKubeconfigSourceDirs returns the directories that kubeconfig files are
+// sourced from. These are specified by the `KUBESEL_KUBECONFIG_DIRS`
+// environment variable.
+func (k *Kubesel) GetKubeconfigSourceDirs() []string {
+	return k.sourceDirs
+}
+
+// IsSourceDirFilePath returns true if the kubeconfig file at the specified
+// path was found inside one of the kubeconfig source directories.
+func (k *Kubesel) IsSourceDirFilePath(path string) bool {
+	return slices.Contains(k.sourceDirs, filepath.Dir(filepath.Clean(path)))
+}
+
+// GetSourcesFilePathForOwner returns the path of the generated sources file
+// for the given [Owner]'s session.
+func (k *Kubesel) GetSourcesFilePathForOwner(owner Owner) string {
+	return filepath.Join(k.sessionDir, owner.companionFileName(sourcesFilePurpose))
+}
+
+// IsSourceFilePath returns true if the kubeconfig file at the specified path
+// is included in the sources file. These are the files inside the kubeconfig
+// source directories, and the files containing the output of the kubeconfig
+// commands.
+func (k *Kubesel) IsSourceFilePath(path string) bool {
+	return k.IsSourceDirFilePath(path) || k.IsCommandSourceFilePath(path)
+}
+
+// UpdateSourcesFile writes the combined contents of the kubeconfig files
+// inside the source directories and the output of the kubeconfig commands to
+// the [Owner]'s sources file, returning its path. If there are no source
+// directories or commands, nothing is written and an empty string is returned.
+//
+// Kubectl does not know about the source directories or commands. Instead,
+// the sources file is added to the `KUBECONFIG` environment variable when
+// kubesel is initialized, and kept up to date by calling this whenever
+// kubesel runs.
+func (k *Kubesel) UpdateSourcesFile(owner Owner) (string, error) {
+	if len(k.sourceDirs) == 0 && len(k.commands) == 0 {
+		return "", nil
+	}
+
+	// Combine the files.
+	combined := &kubeconfig.Config{}
+	for _, kc := range k.loaded().lazyKubeconfigs().Configs {
+		if len(kc.Errors) > 0 || !k.IsSourceFilePath(kc.Path) {
+			continue
+		}
+
+		// Relative paths need to be resolved, since they are relative to
+		// the file that defined them.
+		config := kc.Config.Clone()
+		kcutils.ResolveConfigPaths(config, filepath.Dir(kc.Path))
+
+		combined = kubeconfig.MergeConfig(combined, config)
+	}
+
+	// The current-context comes from the managed kubeconfig instead.
+	combined.ApiVersion = kcutils.PointerFor("v1")
+	combined.Kind = kcutils.PointerFor("Config")
+	combined.CurrentContext = nil
+
+	marshalled, err := yaml.Marshal(combined)
+	if err != nil {
+		return "", fmt.Errorf("marshalling kubeconfig: %w", err)
+	}
+
+	// Write it, but only if it changed. Otherwise, it is touched so
+	// [Kubesel.IsSourcesFileOutdated] knows it is up to date.
+	path := k.GetSourcesFilePathForOwner(owner)
+	if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, marshalled) {
+		now := time.Now()
+		return path, os.Chtimes(path, now, now)
+	}
+
+	if err := k.ensureSessionsDirExists(); err != nil {
+		return "", err
+	}
+
+	err = writeFileAtomically(path, marshalled)
+	if err != nil {
+		return "", fmt.Errorf("error saving sources file: %w", err)
+	}
+
+	return path, nil
+}
+
+// IsSourcesFileOutdated returns true if the [Owner]'s sources file needs to be
+// updated by [Kubesel.UpdateSourcesFile]. Only the modification times of the
+// files are checked, so nothing needs to be loaded.
+//
+// The sources file is outdated if it doesn't exist, if files in the source
+// directories were added, removed, or changed after it was written, or if the
+// output of a kubeconfig command changed or is older than the TTL.
+func (k *Kubesel) IsSourcesFileOutdated(owner Owner) bool {
+	if len(k.sourceDirs) == 0 && len(k.commands) == 0 {
+		return false
+	}
+
+	stat, err := os.Stat(k.GetSourcesFilePathForOwner(owner))
+	if err != nil {
+		return true
+	}
+
+	written := stat.ModTime()
+	for _, dir := range k.sourceDirs {
+		if isModifiedAfter(dir, written) {
+			return true
+		}
+	}
+
+	for _, path := range k.loaded().kubeconfigFiles {
+		if k.IsSourceDirFilePath(path) && isModifiedAfter(path, written) {
+			return true
+		}
+	}
+
+	for _, command := range k.commands {
+		if k.isCommandSourceOutdated(command) || isModifiedAfter(k.commandSourceFilePath(command), written) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isModifiedAfter returns true if the file exists and was modified after the
+// given time.
+func isModifiedAfter(path string, t time.Time) bool {
+	stat, err := os.Stat(path)
+	return err == nil && stat.ModTime().After(t)
+}
+
+// isCompanionFilePath returns true if the path is a file generated for a
+// session, such as the sources file.
+func (k *Kubesel) isCompanionFilePath(path string) bool {
+	if !k.IsManagedKubeconfigPath(path) {
+		return false
+	}
+
+	_, isCompanion := sessionFileNameForCompanion(filepath.Base(path))
+	return isCompanion
+}
+
+// writeFileAtomically writes a file only readable by the current user,
+// atomically replacing its prior contents.
+func writeFileAtomically(path string, data []byte) error {
+	return loader.WriteFileAtomically(path, data, 0o600)
+}
@@ -0,0 +1,36 @@
+package kubesel
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloadWhileReading(t *testing.T) {
+	t.Parallel()
+
+	ksel, err := New(newSessionTestOptions(t))
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		for range 20 {
+			require.NoError(t, ksel.Reload())
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		for range 20 {
+			require.Contains(t, ksel.GetContextNames(), "ctx-a")
+			require.NotEmpty(t, ksel.GetKubeconfigFilePaths())
+			ksel.GetContextAliases()
+		}
+	}()
+
+	wg.Wait()
+}
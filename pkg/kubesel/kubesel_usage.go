@@ -0,0 +1,178 @@
+package kubesel
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// UsageKind is a kind of item whose usage is recorded by
+// [Kubesel.RecordUsage].
+type UsageKind string
+
+const (
+	UsageContext   UsageKind = "context"
+	UsageCluster   UsageKind = "cluster"
+	UsageUser      UsageKind = "user"
+	UsageNamespace UsageKind = "namespace"
+)
+
+// maxUsageCount is the total number of recorded uses (of a single kind)
+// before older uses start to be forgotten.
+const maxUsageCount = 500
+
+// usageAging is multiplied with the use counts when there are more than
+// [maxUsageCount] uses.
+const usageAging = 0.9
+
+type usageFile struct {
+	Items map[UsageKind]map[string]*usageStat `yaml:"items"`
+}
+
+type usageStat struct {
+	Count    float64   `yaml:"count"`
+	LastUsed time.Time `yaml:"last-used"`
+}
+
+// frecency returns a score based on how often and how recently the item
+// was used.
+func (s *usageStat) frecency(now time.Time) float64 {
+	const day = 24 * time.Hour
+
+	var weight float64
+	switch age := now.Sub(s.LastUsed); {
+	case age < time.Hour:
+		weight = 4
+	case age < day:
+		weight = 2
+	case age < 7*day:
+		weight = 1
+	case age < 30*day:
+		weight = 0.5
+	default:
+		weight = 0.25
+	}
+
+	return s.Count * weight
+}
+
+// RecordUsage records that an item was switched to. The recorded uses are
+// used to rank items by [Kubesel.GetFrecency].
+//
+// The usage file is locked while it is updated, so uses recorded by other
+// kubesel processes at the same time aren't lost.
+func (k *Kubesel) RecordUsage(kind UsageKind, name string) error {
+	err := os.MkdirAll(filepath.Dir(k.usageFile()), 0o700)
+	if err != nil {
+		return fmt.Errorf("mkdir: %w", err)
+	}
+
+	unlock, err := lockFile(k.usageFile())
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	usage, err := readUsageFile(k.usageFile())
+	if err != nil {
+		return err
+	}
+
+	if usage.Items == nil {
+		usage.Items = make(map[UsageKind]map[string]*usageStat)
+	}
+
+	stats := usage.Items[kind]
+	if stats == nil {
+		stats = make(map[string]*usageStat)
+		usage.Items[kind] = stats
+	}
+
+	stat := stats[name]
+	if stat == nil {
+		stat = &usageStat{}
+		stats[name] = stat
+	}
+
+	stat.Count++
+	stat.LastUsed = time.Now()
+
+	// Forget old uses once there are too many.
+	// Items that are only remembered for less than one use are removed.
+	var total float64
+	for _, stat := range stats {
+		total += stat.Count
+	}
+
+	if total > maxUsageCount {
+		for name, stat := range stats {
+			stat.Count *= usageAging
+			if stat.Count < 1 {
+				delete(stats, name)
+			}
+		}
+	}
+
+	// Save it.
+	marshalled, err := yaml.Marshal(usage)
+	if err != nil {
+		return fmt.Errorf("marshalling usage: %w", err)
+	}
+
+	return writeFileAtomically(k.usageFile(), marshalled)
+}
+
+// GetFrecency returns a score for each item that was used before, based on
+// how often and how recently it was used. Items that were never used are
+// not included.
+func (k *Kubesel) GetFrecency(kind UsageKind) map[string]float64 {
+	usage := k.loaded().lazyUsage()
+	now := time.Now()
+
+	scores := make(map[string]float64, len(usage.Items[kind]))
+	for name, stat := range usage.Items[kind] {
+		scores[name] = stat.frecency(now)
+	}
+
+	return scores
+}
+
+// usageFile returns the path of the file used to record which items were
+// used.
+func (k *Kubesel) usageFile() string {
+	return filepath.Join(k.dataDir, "usage.yaml")
+}
+
+// loadUsage reads the usage file. If it can't be read, no uses are returned.
+func (k *Kubesel) loadUsage() *usageFile {
+	usage, err := readUsageFile(k.usageFile())
+	if err != nil {
+		return &usageFile{}
+	}
+
+	return usage
+}
+
+func readUsageFile(path string) (*usageFile, error) {
+	var usage usageFile
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &usage, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("reading usage: %w", err)
+	}
+
+	err = yaml.Unmarshal(data, &usage)
+	if err != nil {
+		return nil, fmt.Errorf("parsing usage: %w", err)
+	}
+
+	return &usage, nil
+}
@@ -0,0 +1,225 @@
+package kubesel
+
+import (
+	"cmp"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// newUsageTestKubesel creates a [Kubesel] with an empty data directory,
+// optionally writing the usage file.
+func newUsageTestKubesel(t *testing.T, usage *usageFile) *Kubesel {
+	t.Helper()
+
+	dataDir := t.TempDir()
+	if usage != nil {
+		data, err := yaml.Marshal(usage)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dataDir, "usage.yaml"), data, 0o600))
+	}
+
+	ksel, err := New(Options{DataDir: dataDir})
+	require.NoError(t, err)
+	return ksel
+}
+
+func TestUsageStatFrecencyDecays(t *testing.T) {
+	now := time.Now()
+	testcases := map[string]struct {
+		Age      time.Duration
+		Expected float64
+	}{
+		"Within an hour":   {Age: 30 * time.Minute, Expected: 40},
+		"Within a day":     {Age: 5 * time.Hour, Expected: 20},
+		"Within a week":    {Age: 3 * 24 * time.Hour, Expected: 10},
+		"Within a month":   {Age: 14 * 24 * time.Hour, Expected: 5},
+		"Older than that":  {Age: 90 * 24 * time.Hour, Expected: 2.5},
+		"Exactly one hour": {Age: time.Hour, Expected: 20},
+	}
+
+	t.Parallel()
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			stat := &usageStat{Count: 10, LastUsed: now.Add(-tc.Age)}
+			require.Equal(t, tc.Expected, stat.frecency(now))
+		})
+	}
+}
+
+func TestGetFrecencyRanksByFrequencyAndRecency(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	ksel := newUsageTestKubesel(t, &usageFile{
+		Items: map[UsageKind]map[string]*usageStat{
+			UsageContext: {
+				"used-often-long-ago": {Count: 20, LastUsed: now.Add(-60 * 24 * time.Hour)},
+				"used-once-just-now":  {Count: 1, LastUsed: now.Add(-time.Minute)},
+				"used-often-recently": {Count: 20, LastUsed: now.Add(-2 * time.Hour)},
+				"used-some-this-week": {Count: 6, LastUsed: now.Add(-3 * 24 * time.Hour)},
+			},
+			UsageCluster: {
+				"cluster": {Count: 1, LastUsed: now},
+			},
+		},
+	})
+
+	frecency := ksel.GetFrecency(UsageContext)
+	ranked := slices.SortedFunc(maps.Keys(frecency), func(a, b string) int {
+		return cmp.Compare(frecency[b], frecency[a])
+	})
+
+	require.Equal(t, []string{
+		"used-often-recently",
+		"used-some-this-week",
+		"used-often-long-ago",
+		"used-once-just-now",
+	}, ranked)
+
+	require.NotContains(t, frecency, "cluster", "other kinds are not included")
+	require.Empty(t, ksel.GetFrecency(UsageUser), "nothing is returned for unused kinds")
+}
+
+func TestRecordUsage(t *testing.T) {
+	t.Parallel()
+
+	ksel := newUsageTestKubesel(t, nil)
+	require.NoError(t, ksel.RecordUsage(UsageContext, "a"))
+	require.NoError(t, ksel.RecordUsage(UsageContext, "a"))
+	require.NoError(t, ksel.RecordUsage(UsageContext, "b"))
+	require.NoError(t, ksel.RecordUsage(UsageNamespace, "a"))
+
+	usage, err := readUsageFile(ksel.usageFile())
+	require.NoError(t, err)
+	require.Equal(t, 2.0, usage.Items[UsageContext]["a"].Count)
+	require.Equal(t, 1.0, usage.Items[UsageContext]["b"].Count)
+	require.Equal(t, 1.0, usage.Items[UsageNamespace]["a"].Count)
+	require.WithinDuration(t, time.Now(), usage.Items[UsageContext]["a"].LastUsed, time.Minute)
+
+	entries, err := os.ReadDir(filepath.Dir(ksel.usageFile()))
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "no temporary or lock files are left behind")
+}
+
+func TestRecordUsageForgetsOldUses(t *testing.T) {
+	t.Parallel()
+
+	lastUsed := time.Now().Add(-time.Hour)
+	ksel := newUsageTestKubesel(t, &usageFile{
+		Items: map[UsageKind]map[string]*usageStat{
+			UsageContext: {
+				"busy":   {Count: maxUsageCount - 1, LastUsed: lastUsed},
+				"rare":   {Count: 1, LastUsed: lastUsed},
+				"common": {Count: 10, LastUsed: lastUsed},
+			},
+			UsageCluster: {
+				"rare": {Count: 1, LastUsed: lastUsed},
+			},
+		},
+	})
+
+	require.NoError(t, ksel.RecordUsage(UsageContext, "busy"))
+
+	usage, err := readUsageFile(ksel.usageFile())
+	require.NoError(t, err)
+	require.InDelta(t, maxUsageCount*usageAging, usage.Items[UsageContext]["busy"].Count, 0.001)
+	require.InDelta(t, 10*usageAging, usage.Items[UsageContext]["common"].Count, 0.001)
+	require.NotContains(t, usage.Items[UsageContext], "rare", "items with less than one use are forgotten")
+	require.Contains(t, usage.Items[UsageCluster], "rare", "other kinds are not aged")
+}
+
+func TestRecordUsageConcurrently(t *testing.T) {
+	t.Parallel()
+
+	const uses = 20
+	dataDir := t.TempDir()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, uses)
+	for range uses {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// Separate instances, like separate kubesel processes.
+			ksel, err := New(Options{DataDir: dataDir})
+			if err == nil {
+				err = ksel.RecordUsage(UsageContext, "shared")
+			}
+
+			errs <- err
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	usage, err := readUsageFile(filepath.Join(dataDir, "usage.yaml"))
+	require.NoError(t, err)
+	require.Equal(t, float64(uses), usage.Items[UsageContext]["shared"].Count, "no uses are lost")
+}
+
+func TestLockFileRemovesStaleLocks(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "file.yaml")
+	require.NoError(t, os.WriteFile(path+".lock", nil, 0o600))
+
+	stale := time.Now().Add(-2 * fileLockStaleAge)
+	require.NoError(t, os.Chtimes(path+".lock", stale, stale))
+
+	unlock, err := lockFile(path)
+	require.NoError(t, err)
+	unlock()
+
+	_, err = os.Stat(path + ".lock")
+	require.ErrorIs(t, err, os.ErrNotExist, "the lock is released")
+}
+
+func TestBreakStaleLockKeepsNewLocks(t *testing.T) {
+	testcases := map[string]struct {
+		Age            time.Duration
+		ExpectedExists bool
+	}{
+		"Stale lock is removed": {Age: 2 * fileLockStaleAge, ExpectedExists: false},
+		"New lock is put back":  {Age: 0, ExpectedExists: true},
+	}
+
+	t.Parallel()
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			lockPath := filepath.Join(dir, "file.yaml.lock")
+			require.NoError(t, os.WriteFile(lockPath, nil, 0o600))
+
+			modTime := time.Now().Add(-tc.Age)
+			require.NoError(t, os.Chtimes(lockPath, modTime, modTime))
+
+			// A new lock is seen when another process replaced the stale
+			// lock after it was checked, but before it was broken.
+			breakStaleLock(lockPath)
+
+			_, err := os.Stat(lockPath)
+			require.Equal(t, tc.ExpectedExists, err == nil)
+
+			entries, err := os.ReadDir(dir)
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(entries), 1, "nothing is left aside")
+		})
+	}
+}
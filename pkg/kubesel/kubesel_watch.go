@@ -0,0 +1,196 @@
+package kubesel
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is how long to wait for more changes before reloading.
+// Saving a file usually causes multiple events (e.g. create, write, rename).
+const watchDebounce = 100 * time.Millisecond
+
+// Watch waits for changes to the kubeconfig files, the managed kubeconfig
+// file, or the contents of the kubeconfig source directories. When they
+// change, the files are reloaded with [Kubesel.Reload] and the onChange
+// function is called.
+//
+// This blocks until the context is cancelled or an error occurs. Reloading
+// happens on the calling goroutine, so the [Kubesel] can be used from the
+// onChange function. Calls to [Kubesel.Reload] from other goroutines wait
+// until the onChange function returns, and onChange must not call it.
+func (k *Kubesel) Watch(ctx context.Context, onChange func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("creating watcher: %w", err)
+	}
+
+	defer watcher.Close()
+
+	// The files to watch are found again after every reload, since the
+	// kubeconfig files in the source directories can be added or removed.
+	k.mu.Lock()
+	targets := k.watchTargets()
+	k.mu.Unlock()
+
+	watchedDirs := make(map[string]bool)
+	updateWatchedDirs(watcher, watchedDirs, targets)
+	if len(watchedDirs) == 0 {
+		return fmt.Errorf("no kubeconfig files to watch")
+	}
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case err := <-watcher.Errors:
+			return fmt.Errorf("watching kubeconfig files: %w", err)
+
+		case event := <-watcher.Events:
+			if targets.matches(event.Name) {
+				debounce = time.After(watchDebounce)
+			}
+
+		case <-debounce:
+			debounce = nil
+
+			k.mu.Lock()
+			err := k.reload()
+			if err == nil {
+				targets = k.watchTargets()
+				onChange()
+			}
+			k.mu.Unlock()
+
+			if err != nil {
+				return err
+			}
+
+			updateWatchedDirs(watcher, watchedDirs, targets)
+		}
+	}
+}
+
+// watchTargets are the files and directories which cause the kubeconfig files
+// to be reloaded when they change.
+type watchTargets struct {
+	// files are the kubeconfig files and the managed kubeconfig file.
+	files []string
+
+	// dirs are the directories whose files are all watched. These are the
+	// kubeconfig source directories.
+	dirs []string
+}
+
+// watchTargets returns the current [watchTargets].
+func (k *Kubesel) watchTargets() watchTargets {
+	targets := watchTargets{
+		dirs: slices.Clone(k.sourceDirs),
+	}
+
+	for _, path := range k.loaded().kubeconfigFiles {
+		targets.files = append(targets.files, filepath.Clean(path))
+	}
+
+	return targets
+}
+
+// matches returns true if changes to the file at the path should cause the
+// kubeconfig files to be reloaded.
+//
+// This includes changes to the parents of watched directories that don't
+// exist yet, since creating them is a change to their contents.
+func (t watchTargets) matches(path string) bool {
+	path = filepath.Clean(path)
+	if slices.Contains(t.files, path) {
+		return true
+	}
+
+	for _, dir := range t.dirs {
+		if filepath.Dir(path) == dir || isParentDir(path, dir) {
+			return true
+		}
+	}
+
+	for _, file := range t.files {
+		if isParentDir(path, filepath.Dir(file)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// watchedDirs returns the directories that need to be watched for the
+// targets. Files are watched through their directories, since saving a file
+// atomically replaces it with a new one.
+//
+// If a directory doesn't exist, its closest existing parent is watched
+// instead, so its creation can be noticed.
+func (t watchTargets) watchedDirs() []string {
+	dirs := slices.Clone(t.dirs)
+	for _, file := range t.files {
+		dirs = append(dirs, filepath.Dir(file))
+	}
+
+	for i, dir := range dirs {
+		dirs[i] = closestExistingDir(dir)
+	}
+
+	slices.Sort(dirs)
+	return slices.Compact(dirs)
+}
+
+// updateWatchedDirs makes the watcher watch the directories needed for the
+// targets, and stop watching the ones that are no longer needed.
+func updateWatchedDirs(watcher *fsnotify.Watcher, watchedDirs map[string]bool, targets watchTargets) {
+	wanted := targets.watchedDirs()
+	for dir := range watchedDirs {
+		if !slices.Contains(wanted, dir) {
+			_ = watcher.Remove(dir)
+			delete(watchedDirs, dir)
+		}
+	}
+
+	for _, dir := range wanted {
+		if watchedDirs[dir] {
+			continue
+		}
+
+		if err := watcher.Add(dir); err == nil {
+			watchedDirs[dir] = true
+		}
+	}
+}
+
+// closestExistingDir returns the directory, or its closest parent if it does
+// not exist.
+func closestExistingDir(dir string) string {
+	for {
+		if stat, err := os.Stat(dir); err == nil && stat.IsDir() {
+			return dir
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+
+		dir = parent
+	}
+}
+
+// isParentDir returns true if the parent path is a parent directory of the
+// child path.
+func isParentDir(parent string, child string) bool {
+	rel, err := filepath.Rel(parent, child)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,278 @@
+package kubesel
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/eth-p/kubesel/pkg/kubeconfig"
+	"github.com/eth-p/kubesel/pkg/kubeconfig/kcutils"
+)
+
+// labelsExtensionName is the name of the extension that attaches labels to
+// a cluster or context.
+const labelsExtensionName = "kubesel-labels"
+
+// Labels are key/value pairs attached to a cluster or context, used to
+// organize and filter them.
+//
+// They are stored in the kubeconfig files as an extension:
+//
+//	extensions:
+//	  - name: kubesel-labels
+//	    extension:
+//	      apiVersion: dev.eth-p.kubesel/v1
+//	      kind: Labels
+//	      labels:
+//	        env: prod
+type Labels map[string]string
+
+// String returns the labels as a comma-separated list of "key=value" pairs,
+// sorted by key.
+func (l Labels) String() string {
+	keys := slices.Sorted(maps.Keys(l))
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = key + "=" + l[key]
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+// GetClusterLabels returns the labels of a cluster.
+func (k *Kubesel) GetClusterLabels(name string) (Labels, error) {
+	kcCluster := kcutils.FindCluster(name, k.GetMergedKubeconfig())
+	if kcCluster == nil {
+		return Labels{}, nil
+	}
+
+	return labelsFrom(kcCluster)
+}
+
+// GetContextLabels returns the labels of a context. A context also has the
+// labels of its cluster, unless the context overrides them.
+func (k *Kubesel) GetContextLabels(name string) (Labels, error) {
+	kcContext := kcutils.FindContext(name, k.GetMergedKubeconfig())
+	if kcContext == nil {
+		return Labels{}, nil
+	}
+
+	labels := Labels{}
+	if kcContext.Cluster != nil {
+		clusterLabels, err := k.GetClusterLabels(*kcContext.Cluster)
+		if err != nil {
+			return nil, err
+		}
+
+		maps.Copy(labels, clusterLabels)
+	}
+
+	contextLabels, err := labelsFrom(kcContext)
+	if err != nil {
+		return nil, err
+	}
+
+	maps.Copy(labels, contextLabels)
+	return labels, nil
+}
+
+// labelsFrom decodes the labels extension of a kubeconfig cluster or context.
+func labelsFrom[T kubeconfig.Cluster | kubeconfig.Context](extensible *T) (Labels, error) {
+	rawExt := kcutils.FindExtensionFrom(labelsExtensionName, extensible)
+	if rawExt == nil {
+		return Labels{}, nil
+	}
+
+	if !rawExt.Is(kcextApiVersion, kcextLabelsKind) {
+		return nil, fmt.Errorf("the %q extension has the wrong apiVersion or kind", labelsExtensionName)
+	}
+
+	var ext kcextLabels
+	err := kcutils.DecodeExtension(rawExt, &ext)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode %s: %w", kcextLabelsKind, err)
+	}
+
+	if ext.Labels == nil {
+		return Labels{}, nil
+	}
+
+	return ext.Labels, nil
+}
+
+// LabelSelector selects items by their [Labels]. An empty selector selects
+// everything.
+type LabelSelector []LabelRequirement
+
+// LabelRequirement is a single requirement of a [LabelSelector]. The Values
+// are only used by the [LabelIn] and [LabelNotIn] operators.
+type LabelRequirement struct {
+	Key      string
+	Operator LabelOperator
+	Value    string
+	Values   []string
+}
+
+// LabelOperator is the comparison done by a [LabelRequirement].
+type LabelOperator string
+
+const (
+	LabelEquals    LabelOperator = "="
+	LabelNotEquals LabelOperator = "!="
+	LabelExists    LabelOperator = ""
+	LabelNotExists LabelOperator = "!"
+	LabelIn        LabelOperator = "in"
+	LabelNotIn     LabelOperator = "notin"
+)
+
+// labelSetRequirementRegex matches a set-based requirement, such as
+// `env in (prod, staging)`.
+var labelSetRequirementRegex = regexp.MustCompile(`^(\S+)\s+(in|notin)\s*\(([^()]*)\)$`)
+
+// ParseLabelSelector parses a comma-separated label selector, using the same
+// syntax as kubectl:
+//
+//	env=prod               (the label is "prod")
+//	env==prod              (same as above)
+//	env!=prod              (the label is missing or not "prod")
+//	env in (prod,staging)  (the label is "prod" or "staging")
+//	env notin (prod)       (the label is missing or not "prod")
+//	env                    (the label exists)
+//	!env                   (the label does not exist)
+func ParseLabelSelector(selector string) (LabelSelector, error) {
+	parts, ok := splitLabelSelector(selector)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q has unbalanced parentheses", ErrInvalidLabelSelector, selector)
+	}
+
+	var result LabelSelector
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		var req LabelRequirement
+		if match := labelSetRequirementRegex.FindStringSubmatch(part); match != nil {
+			req = LabelRequirement{Key: match[1], Operator: LabelOperator(match[2])}
+			for _, value := range strings.Split(match[3], ",") {
+				req.Values = append(req.Values, strings.TrimSpace(value))
+			}
+		} else if key, value, ok := strings.Cut(part, "!="); ok {
+			req = LabelRequirement{Key: key, Operator: LabelNotEquals, Value: value}
+		} else if key, value, ok := strings.Cut(part, "=="); ok {
+			req = LabelRequirement{Key: key, Operator: LabelEquals, Value: value}
+		} else if key, value, ok := strings.Cut(part, "="); ok {
+			req = LabelRequirement{Key: key, Operator: LabelEquals, Value: value}
+		} else if key, ok := strings.CutPrefix(part, "!"); ok {
+			req = LabelRequirement{Key: key, Operator: LabelNotExists}
+		} else {
+			req = LabelRequirement{Key: part, Operator: LabelExists}
+		}
+
+		req.Key = strings.TrimSpace(req.Key)
+		req.Value = strings.TrimSpace(req.Value)
+		if !isValidLabelToken(req.Key) || (req.Value != "" && !isValidLabelToken(req.Value)) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidLabelSelector, part)
+		}
+
+		for _, value := range req.Values {
+			if !isValidLabelToken(value) {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidLabelSelector, part)
+			}
+		}
+
+		result = append(result, req)
+	}
+
+	return result, nil
+}
+
+// splitLabelSelector splits a label selector at the commas which are not
+// inside parentheses. It returns false if the parentheses are unbalanced.
+func splitLabelSelector(selector string) ([]string, bool) {
+	var parts []string
+	depth, start := 0, 0
+	for i, c := range selector {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, selector[start:i])
+				start = i + 1
+			}
+		}
+
+		if depth < 0 || depth > 1 {
+			return nil, false
+		}
+	}
+
+	return append(parts, selector[start:]), depth == 0
+}
+
+// isValidLabelToken returns true if the string can be used as a label key or
+// value in a selector.
+func isValidLabelToken(token string) bool {
+	return token != "" && !strings.ContainsAny(token, "!=(), \t")
+}
+
+// Matches returns true if the labels meet every requirement of the selector.
+func (s LabelSelector) Matches(labels Labels) bool {
+	for _, req := range s {
+		if !req.Matches(labels) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// String returns the selector in the syntax accepted by [ParseLabelSelector].
+func (s LabelSelector) String() string {
+	parts := make([]string, len(s))
+	for i, req := range s {
+		parts[i] = req.String()
+	}
+
+	return strings.Join(parts, ",")
+}
+
+// Matches returns true if the labels meet the requirement.
+func (r LabelRequirement) Matches(labels Labels) bool {
+	value, ok := labels[r.Key]
+	switch r.Operator {
+	case LabelEquals:
+		return ok && value == r.Value
+	case LabelNotEquals:
+		return !ok || value != r.Value
+	case LabelExists:
+		return ok
+	case LabelNotExists:
+		return !ok
+	case LabelIn:
+		return ok && slices.Contains(r.Values, value)
+	case LabelNotIn:
+		return !ok || !slices.Contains(r.Values, value)
+	}
+
+	return false
+}
+
+// String returns the requirement in the syntax accepted by
+// [ParseLabelSelector].
+func (r LabelRequirement) String() string {
+	switch r.Operator {
+	case LabelNotExists:
+		return "!" + r.Key
+	case LabelIn, LabelNotIn:
+		return fmt.Sprintf("%s %s (%s)", r.Key, r.Operator, strings.Join(r.Values, ","))
+	}
+
+	return r.Key + string(r.Operator) + r.Value
+}
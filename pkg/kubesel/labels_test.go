@@ -0,0 +1,157 @@
+package kubesel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLabelSelector(t *testing.T) {
+	testcases := map[string]struct {
+		Selector string
+		Expected LabelSelector
+	}{
+		"Empty": {
+			Selector: "",
+			Expected: nil,
+		},
+
+		"Equals": {
+			Selector: "env=prod",
+			Expected: LabelSelector{{Key: "env", Operator: LabelEquals, Value: "prod"}},
+		},
+
+		"Double equals": {
+			Selector: "env==prod",
+			Expected: LabelSelector{{Key: "env", Operator: LabelEquals, Value: "prod"}},
+		},
+
+		"Equals an empty value": {
+			Selector: "env=",
+			Expected: LabelSelector{{Key: "env", Operator: LabelEquals, Value: ""}},
+		},
+
+		"Not equals": {
+			Selector: "env!=prod",
+			Expected: LabelSelector{{Key: "env", Operator: LabelNotEquals, Value: "prod"}},
+		},
+
+		"Exists": {
+			Selector: "env",
+			Expected: LabelSelector{{Key: "env", Operator: LabelExists}},
+		},
+
+		"Not exists": {
+			Selector: "!env",
+			Expected: LabelSelector{{Key: "env", Operator: LabelNotExists}},
+		},
+
+		"In": {
+			Selector: "env in (prod, staging)",
+			Expected: LabelSelector{{Key: "env", Operator: LabelIn, Values: []string{"prod", "staging"}}},
+		},
+
+		"Not in": {
+			Selector: "env notin (prod)",
+			Expected: LabelSelector{{Key: "env", Operator: LabelNotIn, Values: []string{"prod"}}},
+		},
+
+		"Multiple requirements": {
+			Selector: " env in (prod,staging), region!=eu ,!deprecated,, team",
+			Expected: LabelSelector{
+				{Key: "env", Operator: LabelIn, Values: []string{"prod", "staging"}},
+				{Key: "region", Operator: LabelNotEquals, Value: "eu"},
+				{Key: "deprecated", Operator: LabelNotExists},
+				{Key: "team", Operator: LabelExists},
+			},
+		},
+	}
+
+	t.Parallel()
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := ParseLabelSelector(tc.Selector)
+			require.NoError(t, err)
+			require.Equal(t, tc.Expected, actual)
+
+			// It can be parsed again from its string form.
+			reparsed, err := ParseLabelSelector(actual.String())
+			require.NoError(t, err)
+			require.Equal(t, tc.Expected, reparsed)
+		})
+	}
+}
+
+func TestParseLabelSelectorRejectsBadSyntax(t *testing.T) {
+	testcases := map[string]string{
+		"Missing key":              "=prod",
+		"Missing key after !":      "!",
+		"Too many operators":       "env=prod=1",
+		"Not equals and equals":    "env!=prod=1",
+		"Space in key":             "my env=prod",
+		"Unknown set operator":     "env within (prod)",
+		"Set without parentheses":  "env in prod",
+		"Unclosed parentheses":     "env in (prod,staging",
+		"Unopened parentheses":     "env in prod)",
+		"Nested parentheses":       "env in ((prod))",
+		"Empty set value":          "env in (prod,)",
+		"Empty set":                "env in ()",
+		"Parentheses without set":  "(env=prod)",
+		"Operator in set value":    "env in (a=b)",
+		"Not exists with operator": "!env=prod",
+	}
+
+	t.Parallel()
+	for name, selector := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := ParseLabelSelector(selector)
+			require.ErrorIs(t, err, ErrInvalidLabelSelector)
+		})
+	}
+}
+
+func TestLabelSelectorMatches(t *testing.T) {
+	labels := Labels{"env": "prod", "region": "eu"}
+	testcases := map[string]struct {
+		Selector string
+		Labels   Labels
+		Expected bool
+	}{
+		"Empty selector matches everything": {Selector: "", Labels: labels, Expected: true},
+		"Empty selector matches no labels":  {Selector: "", Labels: Labels{}, Expected: true},
+
+		"Equals matches":              {Selector: "env=prod", Labels: labels, Expected: true},
+		"Equals doesn't match":        {Selector: "env=dev", Labels: labels, Expected: false},
+		"Equals needs the label":      {Selector: "team=a", Labels: labels, Expected: false},
+		"Not equals matches":          {Selector: "env!=dev", Labels: labels, Expected: true},
+		"Not equals doesn't match":    {Selector: "env!=prod", Labels: labels, Expected: false},
+		"Not equals matches missing":  {Selector: "team!=a", Labels: labels, Expected: true},
+		"Exists matches":              {Selector: "env", Labels: labels, Expected: true},
+		"Exists doesn't match":        {Selector: "team", Labels: labels, Expected: false},
+		"Not exists matches":          {Selector: "!team", Labels: labels, Expected: true},
+		"Not exists doesn't match":    {Selector: "!env", Labels: labels, Expected: false},
+		"In matches":                  {Selector: "env in (dev,prod)", Labels: labels, Expected: true},
+		"In doesn't match":            {Selector: "env in (dev,staging)", Labels: labels, Expected: false},
+		"In needs the label":          {Selector: "team in (a,b)", Labels: labels, Expected: false},
+		"Not in matches":              {Selector: "env notin (dev)", Labels: labels, Expected: true},
+		"Not in doesn't match":        {Selector: "env notin (dev,prod)", Labels: labels, Expected: false},
+		"Not in matches missing":      {Selector: "team notin (a)", Labels: labels, Expected: true},
+		"Every requirement must hold": {Selector: "env=prod,region=us", Labels: labels, Expected: false},
+		"All requirements hold":       {Selector: "env=prod,region in (eu,us),!team", Labels: labels, Expected: true},
+	}
+
+	t.Parallel()
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			selector, err := ParseLabelSelector(tc.Selector)
+			require.NoError(t, err)
+			require.Equal(t, tc.Expected, selector.Matches(tc.Labels))
+		})
+	}
+}
@@ -2,7 +2,6 @@ package kubesel
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/eth-p/kubesel/pkg/kubeconfig"
 	"github.com/eth-p/kubesel/pkg/kubeconfig/kcutils"
@@ -10,7 +9,10 @@ import (
 )
 
 const (
-	managedContextName   = "kubesel"
+	// ManagedContextName is the name of the context inside a
+	// [ManagedKubeconfig].
+	ManagedContextName = "kubesel"
+
 	managedExtensionName = "managed-by-kubesel"
 )
 
@@ -19,40 +21,24 @@ type ManagedKubeconfig struct {
 	file  string
 	owner Owner
 
-	config  *kubeconfig.Config
-	context *kubeconfig.Context
+	config    *kubeconfig.Config
+	context   *kubeconfig.Context
+	overrides kcextSessionOverrides
+
+	// kubesel is the [Kubesel] the managed kubeconfig was loaded by.
+	// This is used to check the cluster and user in [ManagedKubeconfig.Apply].
+	kubesel *Kubesel
 }
 
 // Save writes the updated [ManagedKubeconfig] to disk, atomically replacing
 // its prior contents.
 func (s *ManagedKubeconfig) Save() error {
-	file, err := os.OpenFile(s.file+".swp", os.O_CREATE|os.O_WRONLY, 0o600)
-	if err != nil {
-		return fmt.Errorf("creating file: %w", err)
-	}
-
 	marshalled, err := yaml.Marshal(s.config)
 	if err != nil {
 		return fmt.Errorf("marshalling kubeconfig: %w", err)
 	}
 
-	_, err = file.Write(marshalled)
-	if err != nil {
-		return fmt.Errorf("writing to file: %w", err)
-	}
-
-	err = file.Close()
-	if err != nil {
-		return fmt.Errorf("closing file: %w", err)
-	}
-
-	// Rename over existing file for atomic save.
-	err = os.Rename(file.Name(), s.file)
-	if err != nil {
-		return fmt.Errorf("replacing file: %w", err)
-	}
-
-	return nil
+	return writeFileAtomically(s.file, marshalled)
 }
 
 // Path returns the path of the managed kubeconfig file.
@@ -60,48 +46,65 @@ func (s *ManagedKubeconfig) Path() string {
 	return s.file
 }
 
+// Owner returns the [Owner] of the managed kubeconfig file.
+func (s *ManagedKubeconfig) Owner() Owner {
+	return s.owner
+}
+
 // GetClusterName returns the name of the active [kubeconfig.Cluster] in
 // the kubesel-managed kubeconfig.
 func (s *ManagedKubeconfig) GetClusterName() string {
-	return *s.context.Cluster
+	if s.overrides.Cluster != "" {
+		return s.overrides.Cluster
+	}
+
+	return valueOrEmpty(s.context.Cluster)
 }
 
 // GetAuthInfoName returns the name of the active [kubeconfig.AuthInfo] in
 // the kubesel-managed kubeconfig.
 func (s *ManagedKubeconfig) GetAuthInfoName() string {
-	return *s.context.User
+	if s.overrides.User != "" {
+		return s.overrides.User
+	}
+
+	return valueOrEmpty(s.context.User)
 }
 
 // GetNamespace returns the name of the active namespace in the kubesel-managed
 // kubeconfig.
 func (s *ManagedKubeconfig) GetNamespace() string {
-	return *s.context.Namespace
+	return valueOrEmpty(s.context.Namespace)
 }
 
 // SetClusterName changes the active [kubeconfig.Cluster] in the kubesel-managed
-// kubeconfig. To commit the change [ManagedKubeconfig.Save] should be called
-// after.
+// kubeconfig, removing its [ClusterOverrides]. To commit the change
+// [ManagedKubeconfig.Save] should be called after.
 func (s *ManagedKubeconfig) SetClusterName(name string) {
-	*s.context.Cluster = name
+	name, _ = s.deriveCluster(name, ClusterOverrides{})
+	s.context.Cluster = &name
+	s.updateOverridesExtension()
 }
 
 // SetAuthInfoName changes the active [kubeconfig.AuthInfo] in the
-// kubesel-managed kubeconfig. To commit the change [ManagedKubeconfig.Save]
-// should be called after.
+// kubesel-managed kubeconfig, removing its [AuthInfoOverrides]. To commit the
+// change [ManagedKubeconfig.Save] should be called after.
 func (s *ManagedKubeconfig) SetAuthInfoName(name string) {
-	*s.context.User = name
+	name, _ = s.deriveAuthInfo(name, AuthInfoOverrides{})
+	s.context.User = &name
+	s.updateOverridesExtension()
 }
 
 // SetNamespace changes the active namespace in the kubesel-managed kubeconfig.
 // To commit the change [ManagedKubeconfig.Save] should be called after.
 func (s *ManagedKubeconfig) SetNamespace(name string) {
-	*s.context.Namespace = name
+	s.context.Namespace = &name
 }
 
 // IsManagedContext checks if the provided [kubeconfig.NamedContext] is managed
 // by kubesel.
 func IsManagedContext(kcNamedContext *kubeconfig.NamedContext) bool {
-	if kcNamedContext.Name != nil && (*kcNamedContext.Name == managedContextName) {
+	if kcNamedContext.Name != nil && (*kcNamedContext.Name == ManagedContextName) {
 		return true
 	}
 
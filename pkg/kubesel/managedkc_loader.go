@@ -32,7 +32,7 @@ func newManagedKubeconfigFromExistingKubeconfig(kc *loader.LoadedKubeconfig) (*M
 		)
 	}
 
-	if kcCurrentContext != managedContextName {
+	if kcCurrentContext != ManagedContextName {
 		return nil, fmt.Errorf(
 			"%w: the current-context is not managed by kubesel",
 			ErrManagedKubeconfigCorrupt,
@@ -78,10 +78,25 @@ func newManagedKubeconfigFromExistingKubeconfig(kc *loader.LoadedKubeconfig) (*M
 		)
 	}
 
+	// Decode the session overrides, if there are any.
+	var overrides kcextSessionOverrides
+	if rawExt := kcutils.FindExtensionFrom(overridesExtensionName, &kc.Config); rawExt != nil {
+		err = kcutils.DecodeExtension(rawExt, &overrides)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"%w: could not decode %s: %w",
+				ErrManagedKubeconfigCorrupt,
+				kcextSessionOverridesKind,
+				err,
+			)
+		}
+	}
+
 	return &ManagedKubeconfig{
-		file:    kc.Path,
-		config:  &kc.Config,
-		context: kcContext,
+		file:      kc.Path,
+		config:    &kc.Config,
+		context:   kcContext,
+		overrides: overrides,
 		owner: Owner{
 			ownerData: ext.Owner,
 		},
@@ -115,10 +130,10 @@ func newManagedKubeconfig(sessionFile string, owner Owner) (*ManagedKubeconfig,
 
 	// Create the kubeconfig.
 	kc := &kubeconfig.Config{
-		CurrentContext: kcutils.PointerFor(managedContextName),
+		CurrentContext: kcutils.PointerFor(ManagedContextName),
 		Contexts: []kubeconfig.NamedContext{
 			{
-				Name:    kcutils.PointerFor(managedContextName),
+				Name:    kcutils.PointerFor(ManagedContextName),
 				Context: kcContext,
 			},
 		},
@@ -0,0 +1,349 @@
+package kubesel
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/eth-p/kubesel/pkg/kubeconfig"
+	"github.com/eth-p/kubesel/pkg/kubeconfig/kcutils"
+	"github.com/eth-p/kubesel/pkg/kubeconfig/loader"
+)
+
+const (
+	// ManagedNamePrefix starts the names of the session-scoped cluster and
+	// user inside a [ManagedKubeconfig]. They only exist while the session
+	// has [ClusterOverrides] or [AuthInfoOverrides].
+	//
+	// The rest of the name is the name of the session file, so the names
+	// are different for every session. Clusters and users with names that
+	// start with this prefix are reserved for kubesel.
+	ManagedNamePrefix = "kubesel-session:"
+
+	overridesExtensionName = "kubesel-session-overrides"
+)
+
+// ClusterOverrides are changes made to the selected [kubeconfig.Cluster] for
+// a single session. The kubeconfig file defining the cluster is not changed.
+type ClusterOverrides struct {
+	// ProxyURL is the URL of the proxy used to connect to the cluster.
+	ProxyURL string
+
+	// InsecureSkipTLSVerify disables checking the cluster's certificate.
+	InsecureSkipTLSVerify bool
+}
+
+// IsZero returns true if the overrides do not change anything.
+func (o ClusterOverrides) IsZero() bool {
+	return o.ProxyURL == "" && !o.InsecureSkipTLSVerify
+}
+
+// ApplyTo changes the cluster to use the proxy and TLS settings.
+func (o ClusterOverrides) ApplyTo(cluster *kubeconfig.Cluster) {
+	if o.ProxyURL != "" {
+		cluster.ProxyURL = &o.ProxyURL
+	}
+
+	// Kubernetes refuses to use a certificate authority together with
+	// insecure-skip-tls-verify.
+	if o.InsecureSkipTLSVerify {
+		cluster.InsecureSkipTLSVerify = kcutils.PointerFor(true)
+		cluster.CertificateAuthorityFile = nil
+		cluster.CertificateAuthorityData = nil
+	}
+}
+
+// AuthInfoOverrides are changes made to the selected [kubeconfig.AuthInfo]
+// for a single session. The kubeconfig file defining the user is not
+// changed.
+type AuthInfoOverrides struct {
+	// As is the user to impersonate.
+	As string
+
+	// AsGroups are the groups to impersonate.
+	AsGroups []string
+
+	// AsUserExtra is the extra information about the impersonated user.
+	AsUserExtra map[string][]string
+}
+
+// IsZero returns true if the overrides do not change anything.
+func (o AuthInfoOverrides) IsZero() bool {
+	return o.As == "" && len(o.AsGroups) == 0 && len(o.AsUserExtra) == 0
+}
+
+// ApplyTo changes the user to impersonate the overridden user and groups.
+func (o AuthInfoOverrides) ApplyTo(authInfo *kubeconfig.AuthInfo) {
+	if o.As != "" {
+		authInfo.As = &o.As
+	}
+
+	if len(o.AsGroups) > 0 {
+		authInfo.AsGroups = slices.Clone(o.AsGroups)
+	}
+
+	if len(o.AsUserExtra) > 0 {
+		authInfo.AsUserExtra = cloneUserExtra(o.AsUserExtra)
+	}
+}
+
+func cloneUserExtra(extra map[string][]string) map[string][]string {
+	if extra == nil {
+		return nil
+	}
+
+	cloned := make(map[string][]string, len(extra))
+	for key, values := range extra {
+		cloned[key] = slices.Clone(values)
+	}
+
+	return cloned
+}
+
+// IsManagedCluster checks if the provided [kubeconfig.NamedCluster] is the
+// session-scoped cluster of a [ManagedKubeconfig].
+func IsManagedCluster(kcNamedCluster *kubeconfig.NamedCluster) bool {
+	return kcNamedCluster.Name != nil && strings.HasPrefix(*kcNamedCluster.Name, ManagedNamePrefix)
+}
+
+// IsManagedAuthInfo checks if the provided [kubeconfig.NamedAuthInfo] is the
+// session-scoped user of a [ManagedKubeconfig].
+func IsManagedAuthInfo(kcNamedAuthInfo *kubeconfig.NamedAuthInfo) bool {
+	return kcNamedAuthInfo.Name != nil && strings.HasPrefix(*kcNamedAuthInfo.Name, ManagedNamePrefix)
+}
+
+// managedItemName returns the name of the session-scoped cluster and user.
+func (s *ManagedKubeconfig) managedItemName() string {
+	base := filepath.Base(s.file)
+	return ManagedNamePrefix + strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// checkManagedItemName returns an error if a kubeconfig file other than the
+// session's defines an item with the session-scoped name.
+func (s *ManagedKubeconfig) checkManagedItemName(kind string, sources loader.NamedItemSources) error {
+	name := s.managedItemName()
+	for _, source := range sources[name] {
+		if !s.kubesel.IsManagedKubeconfigPath(source.Path) {
+			return fmt.Errorf("%w: the %s %q in %s", ErrReservedName, kind, name, source.Path)
+		}
+	}
+
+	return nil
+}
+
+// GetClusterOverrides returns the [ClusterOverrides] of the session.
+func (s *ManagedKubeconfig) GetClusterOverrides() ClusterOverrides {
+	return ClusterOverrides{
+		ProxyURL:              s.overrides.ProxyURL,
+		InsecureSkipTLSVerify: s.overrides.InsecureSkipTLSVerify,
+	}
+}
+
+// GetAuthInfoOverrides returns the [AuthInfoOverrides] of the session.
+func (s *ManagedKubeconfig) GetAuthInfoOverrides() AuthInfoOverrides {
+	return AuthInfoOverrides{
+		As:          s.overrides.As,
+		AsGroups:    slices.Clone(s.overrides.AsGroups),
+		AsUserExtra: cloneUserExtra(s.overrides.AsUserExtra),
+	}
+}
+
+// deriveCluster replaces the session-scoped cluster with a copy of the named
+// cluster that has the overrides applied. It returns the name the managed
+// context should use.
+//
+// If there are no overrides, the session-scoped cluster is removed and the
+// named cluster is used directly.
+func (s *ManagedKubeconfig) deriveCluster(name string, overrides ClusterOverrides) (string, error) {
+	s.config.Clusters = slices.DeleteFunc(s.config.Clusters, func(n kubeconfig.NamedCluster) bool {
+		return IsManagedCluster(&n)
+	})
+
+	s.overrides.Cluster = ""
+	s.overrides.ProxyURL = ""
+	s.overrides.InsecureSkipTLSVerify = false
+	if overrides.IsZero() {
+		return name, nil
+	}
+
+	if s.kubesel == nil {
+		return "", errors.New("cannot override a cluster without the kubeconfig files")
+	}
+
+	provenance := s.kubesel.GetProvenance()
+	if err := s.checkManagedItemName("cluster", provenance.Clusters); err != nil {
+		return "", err
+	}
+
+	// Copy the cluster. It's no longer in the same directory as the file
+	// that defined it, so the paths need to be absolute.
+	var cluster *kubeconfig.Cluster
+	source := provenance.Clusters.DefinedBy(name)
+	if source != nil {
+		cluster = kcutils.FindCluster(name, &source.Config).Clone()
+	}
+
+	if cluster == nil {
+		return "", fmt.Errorf("%w: %s", ErrUnknownCluster, name)
+	}
+
+	kcutils.ResolveClusterPaths(cluster, filepath.Dir(source.Path))
+	overrides.ApplyTo(cluster)
+
+	managedName := s.managedItemName()
+	s.config.Clusters = append(s.config.Clusters, kubeconfig.NamedCluster{
+		Name:    &managedName,
+		Cluster: cluster,
+	})
+
+	s.overrides.Cluster = name
+	s.overrides.ProxyURL = overrides.ProxyURL
+	s.overrides.InsecureSkipTLSVerify = overrides.InsecureSkipTLSVerify
+	return managedName, nil
+}
+
+// deriveAuthInfo replaces the session-scoped user with one that authenticates
+// as the named user and has the overrides applied. It returns the name the
+// managed context should use.
+//
+// If there are no overrides, the session-scoped user is removed and the
+// named user is used directly.
+func (s *ManagedKubeconfig) deriveAuthInfo(name string, overrides AuthInfoOverrides) (string, error) {
+	s.config.AuthInfos = slices.DeleteFunc(s.config.AuthInfos, func(n kubeconfig.NamedAuthInfo) bool {
+		return IsManagedAuthInfo(&n)
+	})
+
+	s.overrides.User = ""
+	s.overrides.As = ""
+	s.overrides.AsGroups = nil
+	s.overrides.AsUserExtra = nil
+	if overrides.IsZero() {
+		return name, nil
+	}
+
+	if s.kubesel == nil {
+		return "", errors.New("cannot override a user without the kubeconfig files")
+	}
+
+	provenance := s.kubesel.GetProvenance()
+	if err := s.checkManagedItemName("user", provenance.AuthInfos); err != nil {
+		return "", err
+	}
+
+	var original *kubeconfig.AuthInfo
+	source := provenance.AuthInfos.DefinedBy(name)
+	if source != nil {
+		original = kcutils.FindAuthInfo(name, &source.Config)
+	}
+
+	if original == nil {
+		return "", fmt.Errorf("%w: %s", ErrUnknownUser, name)
+	}
+
+	authInfo, err := referenceAuthInfo(name, original, filepath.Dir(source.Path), s.kubesel.credentialCommand)
+	if err != nil {
+		return "", err
+	}
+
+	overrides.ApplyTo(authInfo)
+
+	managedName := s.managedItemName()
+	s.config.AuthInfos = append(s.config.AuthInfos, kubeconfig.NamedAuthInfo{
+		Name: &managedName,
+		User: authInfo,
+	})
+
+	s.overrides.User = name
+	s.overrides.As = overrides.As
+	s.overrides.AsGroups = slices.Clone(overrides.AsGroups)
+	s.overrides.AsUserExtra = cloneUserExtra(overrides.AsUserExtra)
+	return managedName, nil
+}
+
+// updateOverridesExtension stores the overrides in the managed kubeconfig,
+// so the names of the overridden cluster and user are kept.
+func (s *ManagedKubeconfig) updateOverridesExtension() {
+	s.config.Extensions = slices.DeleteFunc(s.config.Extensions, func(n kubeconfig.NamedExtension) bool {
+		return n.Name != nil && *n.Name == overridesExtensionName
+	})
+
+	if s.overrides.Cluster == "" && s.overrides.User == "" {
+		return
+	}
+
+	extRaw := &kubeconfig.Extension{
+		ApiVersion: kcutils.PointerFor(kcextApiVersion),
+		Kind:       kcutils.PointerFor(kcextSessionOverridesKind),
+	}
+
+	err := kcutils.EncodeExtension(&s.overrides, extRaw)
+	if err != nil {
+		panic(fmt.Errorf(
+			"failed to encode SessionOverrides extension: %w",
+			err,
+		))
+	}
+
+	s.config.Extensions = append(s.config.Extensions, kubeconfig.NamedExtension{
+		Name:      kcutils.PointerFor(overridesExtensionName),
+		Extension: extRaw,
+	})
+}
+
+// referenceAuthInfo returns a user that authenticates the same way as the
+// original user, without storing any of its secrets.
+//
+// Credentials kept in files or provided by an exec plugin are referenced
+// directly. Credentials stored inline in the kubeconfig file are read when
+// needed by running the credentialCommand as an exec plugin, so they aren't
+// duplicated or left stale when they are rotated.
+func referenceAuthInfo(name string, original *kubeconfig.AuthInfo, baseDir string, credentialCommand []string) (*kubeconfig.AuthInfo, error) {
+	hasInlineCredentials := original.Token != nil ||
+		original.ClientCertificateData != nil ||
+		original.ClientKeyData != nil
+
+	switch {
+	case original.AuthProvider != nil:
+		// Auth providers write refreshed tokens back to the kubeconfig file
+		// that defines the user, which would be the session file.
+		return nil, fmt.Errorf("%w: %s uses an auth-provider", ErrCannotOverrideUser, name)
+
+	case original.Password != nil:
+		return nil, fmt.Errorf("%w: %s uses a password", ErrCannotOverrideUser, name)
+
+	case !hasInlineCredentials:
+		authInfo := &kubeconfig.AuthInfo{
+			ClientCertificateFile: original.ClientCertificateFile,
+			ClientKeyFile:         original.ClientKeyFile,
+			TokenFile:             original.TokenFile,
+			Exec:                  original.Exec.Clone(),
+		}
+
+		// Like kubectl, exec commands with a relative path are relative to
+		// the kubeconfig file.
+		kcutils.ResolveAuthInfoPaths(authInfo, baseDir)
+		if exec := authInfo.Exec; exec != nil && exec.Command != nil &&
+			strings.ContainsRune(*exec.Command, filepath.Separator) && !filepath.IsAbs(*exec.Command) {
+			exec.Command = kcutils.PointerFor(filepath.Join(baseDir, *exec.Command))
+		}
+
+		return authInfo, nil
+
+	case original.Exec != nil:
+		return nil, fmt.Errorf("%w: %s uses an exec plugin together with inline credentials", ErrCannotOverrideUser, name)
+
+	case len(credentialCommand) == 0:
+		return nil, fmt.Errorf("%w: %s has credentials stored in its kubeconfig file", ErrCannotOverrideUser, name)
+	}
+
+	return &kubeconfig.AuthInfo{
+		Exec: &kubeconfig.ExecConfig{
+			Command:         kcutils.PointerFor(credentialCommand[0]),
+			Args:            append(slices.Clone(credentialCommand[1:]), name),
+			ApiVersion:      kcutils.PointerFor(ExecCredentialApiVersion),
+			InteractiveMode: kcutils.PointerFor("Never"),
+		},
+	}, nil
+}
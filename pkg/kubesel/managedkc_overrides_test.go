@@ -0,0 +1,134 @@
+package kubesel
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eth-p/kubesel/pkg/kubeconfig"
+	"github.com/eth-p/kubesel/pkg/kubeconfig/kcutils"
+	"github.com/lithammer/dedent"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReferenceAuthInfo(t *testing.T) {
+	p := kcutils.PointerFor[string]
+	credentialCommand := []string{"/usr/bin/kubesel", "__credentials"}
+
+	testcases := map[string]struct {
+		Original    kubeconfig.AuthInfo
+		Expected    *kubeconfig.AuthInfo
+		ExpectedErr error
+	}{
+		"Files are made absolute": {
+			Original: kubeconfig.AuthInfo{
+				ClientCertificateFile: p("user.crt"),
+				ClientKeyFile:         p("/abs/user.key"),
+				TokenFile:             p("token"),
+			},
+			Expected: &kubeconfig.AuthInfo{
+				ClientCertificateFile: p("/base/user.crt"),
+				ClientKeyFile:         p("/abs/user.key"),
+				TokenFile:             p("/base/token"),
+			},
+		},
+		"Exec command with a relative path is made absolute": {
+			Original: kubeconfig.AuthInfo{
+				Exec: &kubeconfig.ExecConfig{Command: p("./bin/login"), Args: []string{"a"}},
+			},
+			Expected: &kubeconfig.AuthInfo{
+				Exec: &kubeconfig.ExecConfig{Command: p("/base/bin/login"), Args: []string{"a"}},
+			},
+		},
+		"Exec command in PATH is kept": {
+			Original: kubeconfig.AuthInfo{
+				Exec: &kubeconfig.ExecConfig{Command: p("login")},
+			},
+			Expected: &kubeconfig.AuthInfo{
+				Exec: &kubeconfig.ExecConfig{Command: p("login")},
+			},
+		},
+		"Inline credentials use the credential command": {
+			Original: kubeconfig.AuthInfo{
+				Token:                 p("secret"),
+				ClientCertificateData: p("Y2VydA=="),
+			},
+			Expected: &kubeconfig.AuthInfo{
+				Exec: &kubeconfig.ExecConfig{
+					Command:         p("/usr/bin/kubesel"),
+					Args:            []string{"__credentials", "my-user"},
+					ApiVersion:      p(ExecCredentialApiVersion),
+					InteractiveMode: p("Never"),
+				},
+			},
+		},
+		"Auth provider": {
+			Original:    kubeconfig.AuthInfo{AuthProvider: &kubeconfig.AuthProviderConfig{}},
+			ExpectedErr: ErrCannotOverrideUser,
+		},
+		"Password": {
+			Original:    kubeconfig.AuthInfo{Password: p("hunter2")},
+			ExpectedErr: ErrCannotOverrideUser,
+		},
+		"Exec plugin with inline credentials": {
+			Original: kubeconfig.AuthInfo{
+				Token: p("secret"),
+				Exec:  &kubeconfig.ExecConfig{Command: p("login")},
+			},
+			ExpectedErr: ErrCannotOverrideUser,
+		},
+	}
+
+	t.Parallel()
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := referenceAuthInfo("my-user", &tc.Original, "/base", credentialCommand)
+			require.ErrorIs(t, err, tc.ExpectedErr)
+			require.Equal(t, tc.Expected, actual)
+		})
+	}
+}
+
+func TestReferenceAuthInfoWithoutCredentialCommand(t *testing.T) {
+	t.Parallel()
+
+	_, err := referenceAuthInfo("my-user", &kubeconfig.AuthInfo{Token: kcutils.PointerFor("secret")}, "/base", nil)
+	require.ErrorIs(t, err, ErrCannotOverrideUser)
+}
+
+func TestDeriveClusterResolvesPaths(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	kcPath := filepath.Join(dir, "kubeconfigs", "config.yaml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(kcPath), 0o700))
+	require.NoError(t, os.WriteFile(kcPath, []byte(dedent.Dedent(`
+		apiVersion: v1
+		kind: Config
+		clusters:
+		  - name: my-cluster
+		    cluster: {server: "https://example.com", certificate-authority: certs/ca.crt}
+	`)), 0o600))
+
+	ksel, err := New(Options{
+		KubeconfigPaths: []string{kcPath},
+		DataDir:         filepath.Join(dir, "data"),
+	})
+	require.NoError(t, err)
+
+	managedKc := openTestSession(t, ksel).Kubeconfig()
+	require.NoError(t, managedKc.Apply(State{
+		ClusterName:      "my-cluster",
+		ClusterOverrides: ClusterOverrides{ProxyURL: "http://proxy.example.com"},
+	}))
+
+	// The session file is in a different directory than the kubeconfig file,
+	// so the copied cluster needs an absolute path.
+	reloaded := reloadTestManagedKubeconfig(t, ksel, managedKc)
+	cluster := kcutils.FindCluster(managedKc.managedItemName(), reloaded.config)
+	require.NotNil(t, cluster)
+	require.Equal(t, filepath.Join(dir, "kubeconfigs", "certs", "ca.crt"), *cluster.CertificateAuthorityFile)
+	require.Equal(t, "http://proxy.example.com", *cluster.ProxyURL)
+}
@@ -0,0 +1,133 @@
+package kubesel
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/eth-p/kubesel/pkg/kubeconfig/kcutils"
+)
+
+// State is the cluster, user, and namespace used by a [ManagedKubeconfig],
+// together with the session-scoped changes made to the cluster and user.
+// An empty field means it is not set.
+type State struct {
+	ClusterName  string
+	AuthInfoName string
+	Namespace    string
+
+	ClusterOverrides  ClusterOverrides
+	AuthInfoOverrides AuthInfoOverrides
+}
+
+// State returns the current [State] of the managed kubeconfig.
+func (s *ManagedKubeconfig) State() State {
+	return State{
+		ClusterName:       s.GetClusterName(),
+		AuthInfoName:      s.GetAuthInfoName(),
+		Namespace:         s.GetNamespace(),
+		ClusterOverrides:  s.GetClusterOverrides(),
+		AuthInfoOverrides: s.GetAuthInfoOverrides(),
+	}
+}
+
+// Apply changes the managed kubeconfig to use the given [State] and saves it.
+//
+// If the cluster or user is being changed, it must exist in the kubeconfig
+// files. Otherwise, nothing is changed and a [StateError] is returned.
+// Nothing is changed if the file cannot be saved either.
+//
+// If the state has overrides, a copy of the cluster or user with the
+// overrides applied is stored in the managed kubeconfig. The kubeconfig
+// files defining the originals are never changed.
+func (s *ManagedKubeconfig) Apply(state State) error {
+	prior := s.State()
+
+	var errs []error
+	if s.kubesel != nil {
+		if state.ClusterName != "" && state.ClusterName != prior.ClusterName &&
+			!slices.Contains(s.kubesel.GetClusterNames(), state.ClusterName) {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrUnknownCluster, state.ClusterName))
+		}
+
+		if state.AuthInfoName != "" && state.AuthInfoName != prior.AuthInfoName &&
+			!slices.Contains(s.kubesel.GetAuthInfoNames(), state.AuthInfoName) {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrUnknownUser, state.AuthInfoName))
+		}
+	}
+
+	if state.ClusterName == "" && !state.ClusterOverrides.IsZero() {
+		errs = append(errs, fmt.Errorf("%w: no cluster is selected", ErrNothingToOverride))
+	}
+
+	if state.AuthInfoName == "" && !state.AuthInfoOverrides.IsZero() {
+		errs = append(errs, fmt.Errorf("%w: no user is selected", ErrNothingToOverride))
+	}
+
+	// Kubernetes only impersonates groups and extra information together
+	// with a user.
+	if impersonating := state.AuthInfoOverrides; impersonating.As == "" &&
+		(len(impersonating.AsGroups) > 0 || len(impersonating.AsUserExtra) > 0) {
+		errs = append(errs, fmt.Errorf("%w: groups and extra information need a user to impersonate", ErrNoImpersonatedUser))
+	}
+
+	if len(errs) > 0 {
+		return &StateError{Errors: errs}
+	}
+
+	// Change a copy, so nothing is changed if it fails.
+	priorConfig := s.config.Clone()
+	priorOverrides := s.overrides
+
+	err := s.setState(state)
+	if err == nil {
+		err = s.Save()
+	}
+
+	if err != nil {
+		s.config = priorConfig
+		s.context = kcutils.FindContext(ManagedContextName, priorConfig)
+		s.overrides = priorOverrides
+		return err
+	}
+
+	return nil
+}
+
+func (s *ManagedKubeconfig) setState(state State) error {
+	clusterName, err := s.deriveCluster(state.ClusterName, state.ClusterOverrides)
+	if err != nil {
+		return err
+	}
+
+	authInfoName, err := s.deriveAuthInfo(state.AuthInfoName, state.AuthInfoOverrides)
+	if err != nil {
+		return err
+	}
+
+	s.context.Cluster = nilIfEmpty(clusterName)
+	s.context.User = nilIfEmpty(authInfoName)
+	s.context.Namespace = nilIfEmpty(state.Namespace)
+	s.updateOverridesExtension()
+	return nil
+}
+
+// StateError is returned by [ManagedKubeconfig.Apply] when the [State]
+// refers to a cluster or user that does not exist, has overrides for a
+// cluster or user that is not set, or impersonates groups without a user.
+type StateError struct {
+	Errors []error
+}
+
+func (e *StateError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		messages = append(messages, err.Error())
+	}
+
+	return fmt.Sprintf("invalid session state: %s", strings.Join(messages, "; "))
+}
+
+func (e *StateError) Unwrap() []error {
+	return e.Errors
+}
@@ -0,0 +1,161 @@
+package kubesel
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newTestManagedKubeconfig opens a session for the contexts in
+// [sessionTestKubeconfig], and returns its managed kubeconfig.
+func newTestManagedKubeconfig(t *testing.T) (*Kubesel, *ManagedKubeconfig) {
+	t.Helper()
+
+	ksel, err := New(newSessionTestOptions(t))
+	require.NoError(t, err)
+
+	return ksel, openTestSession(t, ksel).Kubeconfig()
+}
+
+// reloadTestManagedKubeconfig reads the managed kubeconfig from disk again.
+func reloadTestManagedKubeconfig(t *testing.T, ksel *Kubesel, managedKc *ManagedKubeconfig) *ManagedKubeconfig {
+	t.Helper()
+
+	reloaded, err := ksel.loadManagedKubeconfig(managedKc.Path())
+	require.NoError(t, err)
+	return reloaded
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	ksel, managedKc := newTestManagedKubeconfig(t)
+	state := State{
+		ClusterName:      "cluster-a",
+		AuthInfoName:     "user-b",
+		Namespace:        "my-namespace",
+		ClusterOverrides: ClusterOverrides{ProxyURL: "http://proxy.example.com"},
+	}
+
+	require.NoError(t, managedKc.Apply(state))
+	require.Equal(t, state, managedKc.State())
+	require.Equal(t, state, reloadTestManagedKubeconfig(t, ksel, managedKc).State(), "the state is saved")
+}
+
+func TestApplyWithoutClusterOrUser(t *testing.T) {
+	testcases := map[string]struct {
+		Context          string
+		ExpectedCluster  string
+		ExpectedAuthInfo string
+	}{
+		"Context without a user": {
+			Context:         "ctx-no-user",
+			ExpectedCluster: "cluster-a",
+		},
+		"Context without a cluster": {
+			Context:          "ctx-no-cluster",
+			ExpectedAuthInfo: "user-a",
+		},
+	}
+
+	t.Parallel()
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ksel, err := New(newSessionTestOptions(t))
+			require.NoError(t, err)
+
+			session := openTestSession(t, ksel)
+			managedKc := session.Kubeconfig()
+			require.NoError(t, session.SwitchContext(context.Background(), tc.Context, SwitchContextOptions{}))
+			require.Equal(t, tc.ExpectedCluster, managedKc.GetClusterName())
+			require.Equal(t, tc.ExpectedAuthInfo, managedKc.GetAuthInfoName())
+
+			reloaded := reloadTestManagedKubeconfig(t, ksel, managedKc)
+			require.Equal(t, managedKc.State(), reloaded.State(), "the state is saved")
+		})
+	}
+}
+
+func TestApplyRejectsInvalidState(t *testing.T) {
+	testcases := map[string]struct {
+		State    State
+		Expected []error
+	}{
+		"Unknown cluster and user": {
+			State:    State{ClusterName: "cluster-missing", AuthInfoName: "user-missing"},
+			Expected: []error{ErrUnknownCluster, ErrUnknownUser},
+		},
+		"Cluster overrides without a cluster": {
+			State:    State{AuthInfoName: "user-a", ClusterOverrides: ClusterOverrides{InsecureSkipTLSVerify: true}},
+			Expected: []error{ErrNothingToOverride},
+		},
+		"User overrides without a user": {
+			State:    State{ClusterName: "cluster-a", AuthInfoOverrides: AuthInfoOverrides{As: "admin"}},
+			Expected: []error{ErrNothingToOverride},
+		},
+		"Impersonating groups without a user": {
+			State:    State{ClusterName: "cluster-a", AuthInfoName: "user-a", AuthInfoOverrides: AuthInfoOverrides{AsGroups: []string{"admins"}}},
+			Expected: []error{ErrNoImpersonatedUser},
+		},
+	}
+
+	t.Parallel()
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ksel, managedKc := newTestManagedKubeconfig(t)
+			prior := managedKc.State()
+
+			err := managedKc.Apply(tc.State)
+			var stateErr *StateError
+			require.ErrorAs(t, err, &stateErr)
+			require.Len(t, stateErr.Errors, len(tc.Expected))
+			for _, expected := range tc.Expected {
+				require.ErrorIs(t, err, expected)
+			}
+
+			require.Equal(t, prior, managedKc.State(), "nothing is changed")
+			require.Equal(t, prior, reloadTestManagedKubeconfig(t, ksel, managedKc).State(), "nothing is saved")
+		})
+	}
+}
+
+func TestApplyRollsBackWhenSaveFails(t *testing.T) {
+	t.Parallel()
+
+	ksel, managedKc := newTestManagedKubeconfig(t)
+	prior := managedKc.State()
+	saved, err := os.ReadFile(managedKc.Path())
+	require.NoError(t, err)
+
+	// Replace the session file with a directory that can't be replaced by
+	// the new file.
+	require.NoError(t, os.Remove(managedKc.Path()))
+	require.NoError(t, os.MkdirAll(filepath.Join(managedKc.Path(), "blocker"), 0o700))
+
+	err = managedKc.Apply(State{
+		ClusterName:       "cluster-a",
+		AuthInfoName:      "user-a",
+		Namespace:         "my-namespace",
+		AuthInfoOverrides: AuthInfoOverrides{As: "admin"},
+	})
+
+	require.Error(t, err)
+	require.Equal(t, prior, managedKc.State(), "the state is rolled back")
+
+	// It works again once the file can be written, and only saves the new
+	// state that was applied.
+	require.NoError(t, os.RemoveAll(managedKc.Path()))
+	require.NoError(t, os.WriteFile(managedKc.Path(), saved, 0o600))
+
+	state := prior
+	state.Namespace = "other"
+	require.NoError(t, managedKc.Apply(state))
+	require.Equal(t, state, reloadTestManagedKubeconfig(t, ksel, managedKc).State())
+}
@@ -1,8 +1,12 @@
 package kubesel
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"os"
 	"strconv"
+	"strings"
 
 	"github.com/shirou/gopsutil/v4/host"
 	"github.com/shirou/gopsutil/v4/process"
@@ -10,6 +14,10 @@ import (
 
 type PidType = int32
 
+// sessionFilePurpose is the purpose of the [ManagedKubeconfig] file in
+// [Owner.companionFileName].
+const sessionFilePurpose = "kubeconfig"
+
 // Owner identifies the shell that initialized a [ManagedKubeconfig].
 // This is used for garbage collection purposes.
 type Owner struct {
@@ -19,18 +27,95 @@ type Owner struct {
 type ownerData struct {
 	Process PidType `json:"pid"`
 	Epoch   uint64  `json:"epoch"`
+	Host    string  `json:"host,omitempty"`
 }
 
 func (o *Owner) fileName() string {
+	return o.companionFileName(sessionFilePurpose)
+}
+
+// companionFileName returns the name of a file belonging to the owner's
+// session. The purpose describes what the file is used for.
+//
+// If the owner has a host, the boot time is prefixed with its [hostID] so
+// that sessions from different hosts sharing the same data directory (e.g.
+// over NFS) don't collide.
+func (o *Owner) companionFileName(purpose string) string {
 	pidHex := strconv.FormatInt(int64(o.Process), 16)
 	bootTimeHex := strconv.FormatUint(o.Epoch, 16)
-	return fmt.Sprintf("kubesel-%s-%s-kubeconfig.yaml", bootTimeHex, pidHex)
+	if o.Host != "" {
+		bootTimeHex = hostID(o.Host) + "." + bootTimeHex
+	}
+
+	return fmt.Sprintf("kubesel-%s-%s-%s.yaml", bootTimeHex, pidHex, purpose)
+}
+
+// hostID returns a short hash of a hostname. Hostnames can contain dashes,
+// which are used to separate the parts of a session file name.
+func hostID(host string) string {
+	hash := sha256.Sum256([]byte(host))
+	return hex.EncodeToString(hash[:4])
+}
+
+// sessionFileNameForCompanion returns the name of the session file that a
+// companion file belongs to. If the file name is not a companion file,
+// this returns false.
+func sessionFileNameForCompanion(name string) (string, bool) {
+	parts, ok := splitCompanionFileName(name)
+	if !ok {
+		return "", false
+	}
+
+	return fmt.Sprintf("kubesel-%s-%s-%s.yaml", parts[1], parts[2], sessionFilePurpose), true
+}
+
+// companionFilePurpose returns the purpose of a companion file. If the file
+// name is not a companion file, this returns false.
+func companionFilePurpose(name string) (string, bool) {
+	parts, ok := splitCompanionFileName(name)
+	if !ok {
+		return "", false
+	}
+
+	return parts[3], true
+}
+
+// splitCompanionFileName splits the name of a companion file into its
+// prefix, host and boot time, pid, and purpose.
+func splitCompanionFileName(name string) ([]string, bool) {
+	trimmed, ok := strings.CutSuffix(name, ".yaml")
+	if !ok {
+		return nil, false
+	}
+
+	parts := strings.SplitN(trimmed, "-", 4)
+	if len(parts) != 4 || parts[0] != "kubesel" || parts[3] == sessionFilePurpose {
+		return nil, false
+	}
+
+	return parts, true
+}
+
+// IsLocal returns true if the owner was created on this host. Owners created
+// before kubesel recorded the host are assumed to be local.
+func (o *Owner) IsLocal() (bool, error) {
+	if o.Host == "" {
+		return true, nil
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		return false, fmt.Errorf("finding hostname: %w", err)
+	}
+
+	return o.Host == hostname, nil
 }
 
 // IsAlive returns true if the owner is still alive.
 //
 // An owner is considered alive if the [Owner]'s process is not dead,
 // and if the system hasn't rebooted since the [Owner] was first created.
+// This only makes sense for owners where [Owner.IsLocal] is true.
 func (o *Owner) IsAlive() (bool, error) {
 	// Get the system boot time.
 	bootTime, err := host.BootTime()
@@ -70,10 +155,17 @@ func OwnerForProcess(pid PidType) (*Owner, error) {
 		return nil, fmt.Errorf("%w: %d", ErrOwnerProcessNotExist, pid)
 	}
 
+	// Get the hostname.
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, fmt.Errorf("finding hostname: %w", err)
+	}
+
 	return &Owner{
 		ownerData: ownerData{
 			Process: pid,
 			Epoch:   bootTime,
+			Host:    hostname,
 		},
 	}, nil
 }
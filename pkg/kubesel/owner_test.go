@@ -0,0 +1,206 @@
+package kubesel
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOwnerFileNames(t *testing.T) {
+	testcases := map[string]struct {
+		Owner             Owner
+		ExpectedSession   string
+		ExpectedCompanion string
+	}{
+		"With a host": {
+			Owner:             Owner{ownerData{Process: 0x1f, Epoch: 0xabc, Host: "my-host"}},
+			ExpectedSession:   "kubesel-" + hostID("my-host") + ".abc-1f-kubeconfig.yaml",
+			ExpectedCompanion: "kubesel-" + hostID("my-host") + ".abc-1f-decrypted-0.yaml",
+		},
+		"Without a host": {
+			Owner:             Owner{ownerData{Process: 0x1f, Epoch: 0xabc}},
+			ExpectedSession:   "kubesel-abc-1f-kubeconfig.yaml",
+			ExpectedCompanion: "kubesel-abc-1f-decrypted-0.yaml",
+		},
+	}
+
+	t.Parallel()
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tc.ExpectedSession, tc.Owner.fileName())
+			require.Equal(t, tc.ExpectedCompanion, tc.Owner.companionFileName("decrypted-0"))
+		})
+	}
+}
+
+func TestHostIDCannotContainDashes(t *testing.T) {
+	t.Parallel()
+
+	require.NotContains(t, hostID("host-with-dashes"), "-")
+	require.NotEqual(t, hostID("host-a"), hostID("host-b"))
+}
+
+func TestParseCompanionFileName(t *testing.T) {
+	withHost := "kubesel-" + hostID("my-host") + ".abc-1f"
+	testcases := map[string]struct {
+		Name            string
+		ExpectedSession string
+		ExpectedPurpose string
+		ExpectedOK      bool
+	}{
+		"With a host": {
+			Name:            withHost + "-decrypted-0.yaml",
+			ExpectedSession: withHost + "-kubeconfig.yaml",
+			ExpectedPurpose: "decrypted-0",
+			ExpectedOK:      true,
+		},
+		"Without a host": {
+			Name:            "kubesel-abc-1f-sources.yaml",
+			ExpectedSession: "kubesel-abc-1f-kubeconfig.yaml",
+			ExpectedPurpose: "sources",
+			ExpectedOK:      true,
+		},
+		"Session file with a host": {
+			Name: withHost + "-kubeconfig.yaml",
+		},
+		"Session file without a host": {
+			Name: "kubesel-abc-1f-kubeconfig.yaml",
+		},
+		"Not a yaml file": {
+			Name: "kubesel-abc-1f-sources.yml",
+		},
+		"Not a kubesel file": {
+			Name: "config-abc-1f-sources.yaml",
+		},
+		"Missing parts": {
+			Name: "kubesel-abc-sources.yaml",
+		},
+	}
+
+	t.Parallel()
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			session, ok := sessionFileNameForCompanion(tc.Name)
+			require.Equal(t, tc.ExpectedOK, ok)
+			require.Equal(t, tc.ExpectedSession, session)
+
+			purpose, ok := companionFilePurpose(tc.Name)
+			require.Equal(t, tc.ExpectedOK, ok)
+			require.Equal(t, tc.ExpectedPurpose, purpose)
+		})
+	}
+}
+
+func TestOwnerIsLocal(t *testing.T) {
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+
+	testcases := map[string]struct {
+		Host     string
+		Expected bool
+	}{
+		"This host":                     {Host: hostname, Expected: true},
+		"Another host":                  {Host: hostname + "-other", Expected: false},
+		"No host (older kubesel files)": {Host: "", Expected: true},
+	}
+
+	t.Parallel()
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			owner := Owner{ownerData{Process: 1, Epoch: 1, Host: tc.Host}}
+			isLocal, err := owner.IsLocal()
+			require.NoError(t, err)
+			require.Equal(t, tc.Expected, isLocal)
+		})
+	}
+}
+
+func TestGarbageCollectSkipsOtherHosts(t *testing.T) {
+	t.Parallel()
+
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+
+	ksel, err := New(Options{DataDir: t.TempDir()})
+	require.NoError(t, err)
+
+	alive, err := OwnerForProcess(PidType(os.Getpid()))
+	require.NoError(t, err)
+
+	// The boot time of these owners is in the past, so they are dead if
+	// their processes can be checked.
+	owners := map[string]Owner{
+		"alive":       *alive,
+		"dead":        {ownerData{Process: 1, Epoch: 1, Host: hostname}},
+		"dead-legacy": {ownerData{Process: 2, Epoch: 1}},
+		"other-host":  {ownerData{Process: 3, Epoch: 1, Host: hostname + "-other"}},
+	}
+
+	paths := make(map[string]string, len(owners))
+	companions := make(map[string]string, len(owners))
+	for name, owner := range owners {
+		managedKc, err := ksel.CreateManagedKubeconfig(owner)
+		require.NoError(t, err)
+		paths[name] = managedKc.Path()
+
+		companions[name] = filepath.Join(ksel.sessionDir, owner.companionFileName("sources"))
+		require.NoError(t, os.WriteFile(companions[name], nil, 0o600))
+	}
+
+	// A companion file whose session file is gone can be cleaned up, even if
+	// the session was from another host.
+	orphan := Owner{ownerData{Process: 4, Epoch: 1, Host: hostname + "-other"}}
+	orphanPath := filepath.Join(ksel.sessionDir, orphan.companionFileName("sources"))
+	require.NoError(t, os.WriteFile(orphanPath, nil, 0o600))
+
+	// Run it twice, since companion files are only deleted once their
+	// session files are gone.
+	for range 2 {
+		result, err := ksel.GarbageCollect(&GarbageCollectOptions{})
+		require.NoError(t, err)
+		require.Empty(t, result.Errors)
+	}
+
+	require.FileExists(t, paths["alive"])
+	require.FileExists(t, companions["alive"])
+	require.FileExists(t, paths["other-host"], "sessions from other hosts are kept")
+	require.FileExists(t, companions["other-host"], "files for sessions from other hosts are kept")
+
+	require.NoFileExists(t, paths["dead"])
+	require.NoFileExists(t, companions["dead"])
+	require.NoFileExists(t, paths["dead-legacy"], "sessions without a host are assumed to be local")
+	require.NoFileExists(t, companions["dead-legacy"])
+	require.NoFileExists(t, orphanPath)
+}
+
+func TestGarbageCollectRemovesStaleTempFiles(t *testing.T) {
+	t.Parallel()
+
+	ksel, err := New(Options{DataDir: t.TempDir()})
+	require.NoError(t, err)
+	require.NoError(t, ksel.ensureSessionsDirExists())
+
+	stale := filepath.Join(ksel.sessionDir, "kubesel-abc.yaml.123.swp")
+	require.NoError(t, os.WriteFile(stale, nil, 0o600))
+	old := time.Now().Add(-2 * tempFileStaleAge)
+	require.NoError(t, os.Chtimes(stale, old, old))
+
+	writing := filepath.Join(ksel.sessionDir, "kubesel-abc.yaml.456.swp")
+	require.NoError(t, os.WriteFile(writing, nil, 0o600))
+
+	result, err := ksel.GarbageCollect(&GarbageCollectOptions{})
+	require.NoError(t, err)
+	require.Empty(t, result.Errors)
+
+	require.NoFileExists(t, stale)
+	require.FileExists(t, writing, "files that may still be written are kept")
+}
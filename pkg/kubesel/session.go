@@ -0,0 +1,271 @@
+package kubesel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/eth-p/kubesel/pkg/kubeconfig/kcutils"
+	"github.com/eth-p/kubesel/pkg/kubectl"
+)
+
+// Session is a kubesel session. It is a [ManagedKubeconfig] for an [Owner],
+// together with the kubeconfig files it is used alongside.
+//
+// Programs that embed kubesel can use a session to switch contexts, and
+// use [Session.Env] to run kubectl (or other tools) with the session.
+type Session struct {
+	kubesel *Kubesel
+	managed *ManagedKubeconfig
+}
+
+// SwitchContextOptions are the options for [Session.SwitchContext].
+type SwitchContextOptions struct {
+	// KeepNamespace keeps the current namespace instead of changing to the
+	// one specified by the context.
+	KeepNamespace bool
+
+	// Namespace changes to this namespace instead of the one specified by
+	// the context. This takes priority over KeepNamespace.
+	Namespace string
+}
+
+// GetSession returns the current [Session], using the [ManagedKubeconfig]
+// found in the kubeconfig files. If there is no managed kubeconfig file,
+// this returns [ErrUnmanaged].
+func (k *Kubesel) GetSession() (*Session, error) {
+	managedKc, err := k.GetManagedKubeconfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Session{kubesel: k, managed: managedKc}, nil
+}
+
+// OpenSession returns the [Session] for the given [Owner], creating it if it
+// does not already exist.
+//
+// A new session starts with the cluster, user, and namespace of the
+// `current-context` in the kubeconfig files.
+func (k *Kubesel) OpenSession(ctx context.Context, owner Owner) (*Session, error) {
+	managedKc, err := k.CreateManagedKubeconfig(owner)
+
+	// If the managed kubeconfig already exists, re-use it.
+	if errors.Is(err, ErrAlreadyManaged) {
+		path := k.GetManagedKubeconfigPathForOwner(owner)
+		managedKc, err = k.loadManagedKubeconfig(path)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Session{kubesel: k, managed: managedKc}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Use the same cluster, user, and namespace as the current context.
+	session := &Session{kubesel: k, managed: managedKc}
+	currentKc := k.GetMergedKubeconfig()
+	if currentKc.CurrentContext != nil {
+		err = session.SwitchContext(ctx, *currentKc.CurrentContext, SwitchContextOptions{})
+		var stateErr *StateError
+		if err != nil && !errors.Is(err, ErrUnknownContext) && !errors.As(err, &stateErr) {
+			return nil, err
+		}
+	}
+
+	return session, nil
+}
+
+// Kubeconfig returns the [ManagedKubeconfig] of the session.
+func (s *Session) Kubeconfig() *ManagedKubeconfig {
+	return s.managed
+}
+
+// Owner returns the [Owner] of the session.
+func (s *Session) Owner() Owner {
+	return s.managed.Owner()
+}
+
+// SwitchContext changes the session's cluster, user, and namespace to the
+// ones specified by a context. The context name must be an exact match, or
+// one of the context's aliases.
+//
+// If the context does not specify a namespace and none is given in the
+// options, the current namespace is kept. If the context does not exist,
+// this returns [ErrUnknownContext]. If ctx is done before the session is
+// changed, its error is returned and the session is left alone.
+func (s *Session) SwitchContext(ctx context.Context, name string, opts SwitchContextOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	name = s.kubesel.ResolveContextAlias(name)
+	kcContext := kcutils.FindContext(name, s.kubesel.GetMergedKubeconfig())
+	if kcContext == nil || name == ManagedContextName {
+		return fmt.Errorf("%w: %s", ErrUnknownContext, name)
+	}
+
+	// The overrides are for the prior cluster and user, so they aren't kept.
+	state := State{
+		ClusterName:  valueOrEmpty(kcContext.Cluster),
+		AuthInfoName: valueOrEmpty(kcContext.User),
+		Namespace:    s.managed.GetNamespace(),
+	}
+
+	switch {
+	case opts.Namespace != "":
+		state.Namespace = opts.Namespace
+	case !opts.KeepNamespace && kcContext.Namespace != nil:
+		state.Namespace = *kcContext.Namespace
+	}
+
+	return s.managed.Apply(state)
+}
+
+// KubeconfigPaths returns the kubeconfig files that kubectl should use for
+// the session, in the order they should appear in the `KUBECONFIG`
+// environment variable.
+//
+// The managed kubeconfig file comes first. Encrypted kubeconfig files are
+// replaced by their decrypted copies, and the files from the kubeconfig
+// source directories and commands are replaced by the sources file. If a
+// decrypted copy or the sources file could not be written, it is left out
+// and the error is returned alongside the rest of the paths.
+func (s *Session) KubeconfigPaths() ([]string, error) {
+	k := s.kubesel
+	owner := s.Owner()
+	paths := []string{s.managed.Path()}
+
+	// Add the unmanaged kubeconfig files.
+	// Only add a file the first time it appears.
+	var errs []error
+	seenFiles := make(map[string]bool, 0)
+	for _, kcPath := range k.GetKubeconfigFilePaths() {
+		if k.IsManagedKubeconfigPath(kcPath) || k.IsSourceFilePath(kcPath) {
+			continue
+		}
+
+		if seenFiles[kcPath] {
+			continue
+		}
+
+		seenFiles[kcPath] = true
+
+		// Kubectl can't read encrypted files, so it gets a decrypted copy.
+		if k.IsEncryptedFilePath(kcPath) {
+			decryptedPath, err := k.UpdateDecryptedFile(owner, kcPath)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("error decrypting %s: %w", kcPath, err))
+				continue
+			}
+
+			kcPath = decryptedPath
+		}
+
+		paths = append(paths, kcPath)
+	}
+
+	// Add the sources file at the very end.
+	// This contains the files inside the kubeconfig source directories, and
+	// the output of the kubeconfig commands.
+	sourcesFile, err := k.UpdateSourcesFile(owner)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("error creating sources file: %w", err))
+	} else if sourcesFile != "" {
+		paths = append(paths, sourcesFile)
+	}
+
+	return paths, errors.Join(errs...)
+}
+
+// Env returns the environment variables needed to use the session, in the
+// "key=value" form used by [os.Environ] and [os/exec.Cmd].
+//
+// This includes the `KUBECONFIG` variable for kubectl, and the variables
+// kubesel uses to find its data directory and the kubeconfig source
+// directories and commands.
+// Like [Session.KubeconfigPaths], an error is returned alongside the
+// variables if some of the kubeconfig files could not be prepared.
+func (s *Session) Env() ([]string, error) {
+	k := s.kubesel
+	paths, err := s.KubeconfigPaths()
+	listSeparator := string(filepath.ListSeparator)
+
+	env := []string{
+		"KUBECONFIG=" + strings.Join(paths, listSeparator),
+		DataDirEnvVar + "=" + k.dataDir,
+	}
+
+	if len(k.sourceDirs) > 0 {
+		env = append(env, KubeconfigDirsEnvVar+"="+strings.Join(k.sourceDirs, listSeparator))
+	}
+
+	if len(k.commands) > 0 {
+		env = append(env,
+			KubeconfigCommandsEnvVar+"="+strings.Join(k.commands, "\n"),
+			KubeconfigCommandTTLEnvVar+"="+k.commandTTL.String(),
+		)
+	}
+
+	return env, err
+}
+
+// GetNamespaces returns the names of the namespaces in the cluster of a
+// kubeconfig context. If the context name is empty, the session's cluster is
+// used.
+//
+// The namespaces are listed by running the `kubectl` found on the PATH with
+// the session's [Session.Env], so it can be cancelled with ctx.
+func (s *Session) GetNamespaces(ctx context.Context, kubeContext string) ([]string, error) {
+	kctl, err := kubectl.NewKubectlFromPATH()
+	if err != nil {
+		return nil, err
+	}
+
+	// The kubeconfig files that couldn't be prepared are left out of the
+	// environment. Kubectl reports an error if the context needed them.
+	env, _ := s.Env()
+
+	// Get the namespaces using kubectl.
+	args := []string{"get", "namespace", "--output=name", "--no-headers", "--server-print"}
+	if kubeContext != "" {
+		args = append(args, "--context="+kubeContext)
+	}
+
+	output, err := kctl.WithEnv(env).Exec(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	// Clean up the returned list.
+	var namespaces []string
+	for _, line := range strings.Split(output, "\n") {
+		namespace := strings.TrimPrefix(strings.TrimSpace(line), "namespace/")
+		if namespace != "" {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+
+	return namespaces, nil
+}
+
+func valueOrEmpty(ptr *string) string {
+	if ptr == nil {
+		return ""
+	}
+
+	return *ptr
+}
+
+func nilIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+
+	return &s
+}
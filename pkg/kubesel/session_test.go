@@ -0,0 +1,231 @@
+package kubesel
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lithammer/dedent"
+	"github.com/stretchr/testify/require"
+)
+
+const sessionTestKubeconfig = `
+	apiVersion: v1
+	kind: Config
+	current-context: ctx-b
+	clusters:
+	  - name: cluster-a
+	    cluster: {server: "https://a.example.com"}
+	  - name: cluster-b
+	    cluster: {server: "https://b.example.com"}
+	users:
+	  - name: user-a
+	    user: {token: a}
+	  - name: user-b
+	    user: {token: b}
+	contexts:
+	  - name: ctx-a
+	    context: {cluster: cluster-a, user: user-a}
+	  - name: ctx-b
+	    context: {cluster: cluster-b, user: user-b, namespace: ns-b}
+	  - name: ctx-no-user
+	    context: {cluster: cluster-a}
+	  - name: ctx-no-cluster
+	    context: {user: user-a}
+`
+
+// newSessionTestOptions returns [Options] with a kubeconfig file containing
+// the contexts in [sessionTestKubeconfig], and an empty data directory.
+func newSessionTestOptions(t *testing.T) Options {
+	t.Helper()
+
+	dir := t.TempDir()
+	kcPath := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(kcPath, []byte(dedent.Dedent(sessionTestKubeconfig)), 0o600))
+
+	return Options{
+		KubeconfigPaths: []string{kcPath},
+		DataDir:         filepath.Join(dir, "data"),
+	}
+}
+
+// openTestSession opens a session owned by the test process.
+func openTestSession(t *testing.T, ksel *Kubesel) *Session {
+	t.Helper()
+
+	owner, err := OwnerForProcess(PidType(os.Getpid()))
+	require.NoError(t, err)
+
+	session, err := ksel.OpenSession(context.Background(), *owner)
+	require.NoError(t, err)
+	return session
+}
+
+func TestNewRequiresDataDir(t *testing.T) {
+	t.Parallel()
+
+	opts := newSessionTestOptions(t)
+	opts.DataDir = ""
+
+	_, err := New(opts)
+	require.ErrorIs(t, err, ErrNoDataDir)
+}
+
+func TestNewDoesNotReadEnv(t *testing.T) {
+	t.Setenv(KubeconfigDirsEnvVar, t.TempDir())
+	t.Setenv(KubeconfigCommandsEnvVar, "echo")
+
+	ksel, err := New(newSessionTestOptions(t))
+	require.NoError(t, err)
+	require.Empty(t, ksel.sourceDirs)
+	require.Empty(t, ksel.commands)
+}
+
+func TestOpenSessionStartsWithCurrentContext(t *testing.T) {
+	t.Parallel()
+
+	ksel, err := New(newSessionTestOptions(t))
+	require.NoError(t, err)
+
+	session := openTestSession(t, ksel)
+	require.Equal(t, "cluster-b", session.Kubeconfig().GetClusterName())
+	require.Equal(t, "user-b", session.Kubeconfig().GetAuthInfoName())
+	require.Equal(t, "ns-b", session.Kubeconfig().GetNamespace())
+	require.FileExists(t, session.Kubeconfig().Path())
+}
+
+func TestOpenSessionReusesExistingSession(t *testing.T) {
+	t.Parallel()
+
+	opts := newSessionTestOptions(t)
+	ksel, err := New(opts)
+	require.NoError(t, err)
+
+	first := openTestSession(t, ksel)
+	require.NoError(t, first.SwitchContext(context.Background(), "ctx-a", SwitchContextOptions{}))
+
+	// A separate instance, like a later kubesel process.
+	ksel, err = New(opts)
+	require.NoError(t, err)
+
+	second := openTestSession(t, ksel)
+	require.Equal(t, first.Kubeconfig().Path(), second.Kubeconfig().Path())
+	require.Equal(t, "cluster-a", second.Kubeconfig().GetClusterName(), "the session is not reset to the current context")
+}
+
+func TestSessionSwitchContext(t *testing.T) {
+	testcases := map[string]struct {
+		Context           string
+		Options           SwitchContextOptions
+		ExpectedCluster   string
+		ExpectedNamespace string
+	}{
+		"Changes to the context's namespace": {
+			Context:           "ctx-b",
+			ExpectedCluster:   "cluster-b",
+			ExpectedNamespace: "ns-b",
+		},
+		"Keeps the namespace if the context has none": {
+			Context:           "ctx-a",
+			ExpectedCluster:   "cluster-a",
+			ExpectedNamespace: "start",
+		},
+		"Keeps the namespace if asked": {
+			Context:           "ctx-b",
+			Options:           SwitchContextOptions{KeepNamespace: true},
+			ExpectedCluster:   "cluster-b",
+			ExpectedNamespace: "start",
+		},
+		"Changes to the given namespace": {
+			Context:           "ctx-b",
+			Options:           SwitchContextOptions{KeepNamespace: true, Namespace: "other"},
+			ExpectedCluster:   "cluster-b",
+			ExpectedNamespace: "other",
+		},
+	}
+
+	t.Parallel()
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ksel, err := New(newSessionTestOptions(t))
+			require.NoError(t, err)
+
+			session := openTestSession(t, ksel)
+			state := session.Kubeconfig().State()
+			state.Namespace = "start"
+			require.NoError(t, session.Kubeconfig().Apply(state))
+
+			require.NoError(t, session.SwitchContext(context.Background(), tc.Context, tc.Options))
+			require.Equal(t, tc.ExpectedCluster, session.Kubeconfig().GetClusterName())
+			require.Equal(t, tc.ExpectedNamespace, session.Kubeconfig().GetNamespace())
+		})
+	}
+}
+
+func TestSessionSwitchContextFails(t *testing.T) {
+	t.Parallel()
+
+	ksel, err := New(newSessionTestOptions(t))
+	require.NoError(t, err)
+	session := openTestSession(t, ksel)
+
+	err = session.SwitchContext(context.Background(), "ctx-missing", SwitchContextOptions{})
+	require.ErrorIs(t, err, ErrUnknownContext)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = session.SwitchContext(ctx, "ctx-a", SwitchContextOptions{})
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, "cluster-b", session.Kubeconfig().GetClusterName(), "the session is left alone")
+}
+
+func TestSessionEnv(t *testing.T) {
+	t.Parallel()
+
+	opts := newSessionTestOptions(t)
+	opts.KubeconfigDirs = []string{t.TempDir()}
+	opts.KubeconfigCommands = []string{"kind get kubeconfig"}
+	opts.KubeconfigCommandTTL = DefaultKubeconfigCommandTTL
+	opts.SkipKubeconfigCommands = true
+
+	ksel, err := New(opts)
+	require.NoError(t, err)
+
+	session := openTestSession(t, ksel)
+	env, err := session.Env()
+	require.NoError(t, err)
+
+	vars := make(map[string]string, len(env))
+	for _, kv := range env {
+		key, value, ok := strings.Cut(kv, "=")
+		require.True(t, ok, "%q is in key=value form", kv)
+		vars[key] = value
+	}
+
+	kubeconfigs := filepath.SplitList(vars["KUBECONFIG"])
+	require.GreaterOrEqual(t, len(kubeconfigs), 2)
+	require.Equal(t, session.Kubeconfig().Path(), kubeconfigs[0], "the session comes first")
+	require.Equal(t, opts.KubeconfigPaths[0], kubeconfigs[1])
+
+	require.Equal(t, opts.DataDir, vars[DataDirEnvVar])
+	require.Equal(t, opts.KubeconfigDirs[0], vars[KubeconfigDirsEnvVar])
+	require.Equal(t, "kind get kubeconfig", vars[KubeconfigCommandsEnvVar])
+	require.Equal(t, DefaultKubeconfigCommandTTL.String(), vars[KubeconfigCommandTTLEnvVar])
+}
+
+func TestSessionEnvWithoutSources(t *testing.T) {
+	t.Parallel()
+
+	ksel, err := New(newSessionTestOptions(t))
+	require.NoError(t, err)
+
+	session := openTestSession(t, ksel)
+	env, err := session.Env()
+	require.NoError(t, err)
+	require.Len(t, env, 2, "only KUBECONFIG and the data directory are set")
+}
@@ -5,6 +5,15 @@ __kubesel_init() {
     {{- with .add_kubeconfigs }}
     export KUBECONFIG="$KUBECONFIG:"{{ join . ":" | shellquote }}
     {{- end }}
+    {{- with .add_kubeconfig_dirs }}
+    export KUBESEL_KUBECONFIG_DIRS={{ join . ":" | shellquote }}
+    {{- end }}
+    {{- with .add_kubeconfig_commands }}
+    export KUBESEL_KUBECONFIG_COMMANDS={{ join . "\n" | shellquote }}
+    {{- end }}
+    {{- with .kubeconfig_command_ttl }}
+    export KUBESEL_KUBECONFIG_COMMAND_TTL={{ . | shellquote }}
+    {{- end }}
 
     local new_kubeconfig
     new_kubeconfig="$({{ .kubesel_executable | shellquote }} __init --pid=$$)"
@@ -4,6 +4,15 @@ function __kubesel_init
     {{- with .add_kubeconfigs }}
     set -gx KUBECONFIG "$KUBECONFIG:"{{ join . ":" | shellquote }}
     {{- end }}
+    {{- with .add_kubeconfig_dirs }}
+    set -gx KUBESEL_KUBECONFIG_DIRS {{ join . ":" | shellquote }}
+    {{- end }}
+    {{- with .add_kubeconfig_commands }}
+    set -gx KUBESEL_KUBECONFIG_COMMANDS {{ join . "\n" | shellquote }}
+    {{- end }}
+    {{- with .kubeconfig_command_ttl }}
+    set -gx KUBESEL_KUBECONFIG_COMMAND_TTL {{ . | shellquote }}
+    {{- end }}
     set -l new_kubeconfig ({{ .kubesel_executable | shellquote }} __init --pid=$fish_pid)
     if test $status -eq 0
         set -gx KUBECONFIG "$new_kubeconfig"